@@ -0,0 +1,85 @@
+package lit
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// Acknowledgments that users have seen an issue are kept one per line in its
+// acks field, as "<user> acknowledged at <time>".
+const acksKey = "acks"
+
+const ackSep = " acknowledged at "
+
+// Ack is a user's acknowledgment of an issue.
+type Ack struct {
+	User string
+	Time time.Time
+}
+
+// Acks returns the acknowledgments of an issue, oldest first.
+func Acks(issue *dgrl.Branch) []Ack {
+	acks := []Ack{}
+	for _, line := range strings.Split(fieldVal(issue, acksKey), "\n") {
+		i := strings.LastIndex(line, ackSep)
+		if i < 0 {
+			continue
+		}
+		t, _ := parseTime(strings.TrimSpace(line[i+len(ackSep):]))
+		acks = append(acks, Ack{User: strings.TrimSpace(line[:i]), Time: t})
+	}
+	return acks
+}
+
+// Acked reports whether a user has acknowledged an issue.
+func Acked(issue *dgrl.Branch, username string) bool {
+	for _, ack := range Acks(issue) {
+		if ack.User == username {
+			return true
+		}
+	}
+	return false
+}
+
+// Acknowledge records that a user has seen an issue, returning false if they
+// already had.
+func (l *Lit) Acknowledge(issue *dgrl.Branch, username string) (bool, error) {
+	if Acked(issue, username) {
+		return false, nil
+	}
+	now := l.Now().UTC()
+	lines := []string{}
+	if acks := fieldVal(issue, acksKey); acks != "" {
+		lines = append(lines, acks)
+	}
+	lines = append(lines, username+ackSep+now.Format(time.RFC3339))
+	if !set(issue, acksKey, strings.Join(lines, "\n"), true, true, false) ||
+		!Touch(issue, stampAt(now, username)) {
+		return false, fmt.Errorf("error recording acknowledgment of issue %s", issue.Key())
+	}
+	return true, nil
+}
+
+// ackContains reports whether an issue is acknowledged by a user matching
+// whole, a pattern anchored at both ends, by name or the part before an "@",
+// or by anyone if val is empty.
+func ackContains(issue *dgrl.Branch, val string, whole *regexp.Regexp) bool {
+	acks := Acks(issue)
+	if val == "" || whole == nil {
+		return len(acks) > 0
+	}
+	for _, ack := range acks {
+		user := ack.User
+		if whole.MatchString(user) {
+			return true
+		}
+		if at := strings.Index(user, "@"); at > 0 && whole.MatchString(user[:at]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package lit
+
+import (
+	"math/rand"
+	"testing"
+)
+
+const benchIssues = 10000
+
+// benchTracker returns a loaded tracker on a MemFS holding num synthetic
+// issues.
+func benchTracker(b *testing.B, num int) *Lit {
+	b.Helper()
+	l := NewWithFS(NewMemFS(), "/bench")
+	if err := l.Init(); err != nil {
+		b.Fatal(err)
+	}
+	if err := l.Load(); err != nil {
+		b.Fatal(err)
+	}
+	l.Generate("bench", num, rand.New(rand.NewSource(1)))
+	if err := l.Store(); err != nil {
+		b.Fatal(err)
+	}
+	if err := l.Load(); err != nil {
+		b.Fatal(err)
+	}
+	return l
+}
+
+func BenchmarkLoad(b *testing.B) {
+	l := benchTracker(b, benchIssues)
+	b.Run("cached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if err := l.Load(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	l.DisableCache()
+	b.Run("parsed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if err := l.Load(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkStore(b *testing.B) {
+	l := benchTracker(b, benchIssues)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := l.Store(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGenerate(b *testing.B) {
+	l := benchTracker(b, 0)
+	r := rand.New(rand.NewSource(1))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Generate("bench", 1, r)
+	}
+}
+
+func BenchmarkMatch(b *testing.B) {
+	l := benchTracker(b, benchIssues)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := l.Match("summary", "foo", true); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCompare(b *testing.B) {
+	l := benchTracker(b, benchIssues)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Compare("priority", "2", true)
+	}
+}
+
+func BenchmarkSort(b *testing.B) {
+	l := benchTracker(b, benchIssues)
+	ids := l.IssueIds()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		rand.New(rand.NewSource(1)).Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+		b.StartTimer()
+		l.Sort(ids, "priority", true)
+	}
+}
+
+func BenchmarkLookup(b *testing.B) {
+	l := benchTracker(b, benchIssues)
+	ids := l.IssueIds()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if l.Issue(l.ShortID(ids[i%len(ids)])) == nil {
+			b.Fatal("issue not found")
+		}
+	}
+}
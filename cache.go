@@ -0,0 +1,132 @@
+package lit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"path/filepath"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// The parsed issue file is cached under .lit/cache, in a file named by the
+// hash of the issue file's contents, so that an unchanged tracker needn't be
+// reparsed.
+const cacheDirname = "cache"
+
+const (
+	cacheBranch = iota
+	cacheLeaf
+	cacheLongLeaf
+	cacheText
+)
+
+// cacheNode is the gob encoding of a dgrl node.
+type cacheNode struct {
+	Type int
+	Key  string
+	Val  string
+	Kids []cacheNode
+}
+
+// DisableCache makes the tracker always parse the issue file, without reading
+// or writing the cache.
+func (l *Lit) DisableCache() {
+	l.noCache = true
+}
+
+func issueHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (l *Lit) cachePath(hash string) string {
+	return filepath.Join(l.issueDir, cacheDirname, hash)
+}
+
+// readCache returns the cached issues for the given hash, if any.
+func (l *Lit) readCache(hash string) (*dgrl.Branch, bool) {
+	if l.noCache {
+		return nil, false
+	}
+	data, err := l.fs.ReadFile(l.cachePath(hash))
+	if err != nil {
+		return nil, false
+	}
+	root := cacheNode{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&root); err != nil {
+		return nil, false
+	}
+	return fromCache(root).(*dgrl.Branch), true
+}
+
+// writeCache replaces the cache with the given issues, ignoring errors, since
+// the cache is only an optimization.
+func (l *Lit) writeCache(hash string, issues *dgrl.Branch) {
+	if l.noCache {
+		return
+	}
+	l.clearCache()
+	dir := filepath.Join(l.issueDir, cacheDirname)
+	if err := l.fs.MkdirAll(dir, 0777); err != nil {
+		return
+	}
+	l.fs.WriteFile(filepath.Join(dir, ".gitignore"), []byte("*\n"), 0666)
+	buf := &bytes.Buffer{}
+	if err := gob.NewEncoder(buf).Encode(toCache(issues)); err != nil {
+		return
+	}
+	// a partly written cache fails to decode, so is just a miss
+	l.fs.WriteFile(l.cachePath(hash), buf.Bytes(), 0666)
+}
+
+// clearCache removes cached issues.
+func (l *Lit) clearCache() {
+	names, _ := l.fs.ReadDirNames(filepath.Join(l.issueDir, cacheDirname))
+	for _, name := range names {
+		if len(name) == sha256.Size*2 {
+			l.fs.Remove(l.cachePath(name))
+		}
+	}
+}
+
+func toCache(node dgrl.Node) cacheNode {
+	switch n := node.(type) {
+	case *dgrl.Branch:
+		cn := cacheNode{Type: cacheBranch, Key: n.Key(), Kids: make([]cacheNode, 0, n.NumKids())}
+		for _, k := range n.Kids() {
+			cn.Kids = append(cn.Kids, toCache(k))
+		}
+		return cn
+	case *dgrl.Leaf:
+		cn := cacheNode{Type: cacheLongLeaf, Key: n.Key(), Val: n.Value()}
+		switch {
+		case n.Type() == dgrl.LeafType:
+			cn.Type = cacheLeaf
+		case n.Key() == "":
+			cn.Type = cacheText
+		}
+		return cn
+	}
+	return cacheNode{}
+}
+
+func fromCache(cn cacheNode) dgrl.Node {
+	switch cn.Type {
+	case cacheLeaf:
+		return dgrl.NewLeaf(cn.Key, cn.Val)
+	case cacheLongLeaf:
+		return dgrl.NewLongLeaf(cn.Key, cn.Val)
+	case cacheText:
+		return dgrl.NewText(cn.Val)
+	}
+	branch := dgrl.NewBranch(cn.Key)
+	if cn.Key == "" {
+		branch = dgrl.NewRoot()
+	}
+	for _, kid := range cn.Kids {
+		branch.Append(fromCache(kid))
+	}
+	return branch
+}
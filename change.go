@@ -0,0 +1,175 @@
+package lit
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// SetField sets a field of an issue on behalf of a user.  Reserved fields are
+// set only if force is true, dates are normalized, the pre-set hook may reject
+// the change, and the workflow, if any, governs status unless forced.  A
+// transition to a closed state needs what Close does.  The change is recorded
+// for SendEvents.
+func (l *Lit) SetField(issue *dgrl.Branch, username, key, val string, force bool) error {
+	if key == "closed" && !force {
+		return fmt.Errorf("issue %s: closed is set by closing or reopening", l.ShortID(issue.Key()))
+	}
+	return l.update(issue, username, key, val, force, "")
+}
+
+// Close closes an issue on behalf of a user, with an optional resolution and
+// reason.  The issue must have the approvals it needs and the pre-close hook
+// may reject it.  If the workflow has closed states, the issue moves to the
+// one it may move to, as if its status were set, and can't be closed if there
+// is no such state or more than one.  The change is recorded for SendEvents.
+func (l *Lit) Close(issue *dgrl.Branch, username, resolution, reason string) error {
+	if resolution != "" && !hasString(l.Resolutions(), resolution) {
+		return fmt.Errorf("invalid resolution %s (%s)", resolution, strings.Join(l.Resolutions(), ", "))
+	}
+	detail := strings.TrimSpace(resolution + " " + reason)
+	if err := l.update(issue, username, "closed", l.Stamp(username), false, detail); err != nil {
+		return err
+	}
+	return l.setResolution(issue, resolution, reason)
+}
+
+// Reopen reopens an issue on behalf of a user, clearing its resolution.  As
+// for Close, the workflow may move it to an open state.  The change is
+// recorded for SendEvents.
+func (l *Lit) Reopen(issue *dgrl.Branch, username string) error {
+	if err := l.update(issue, username, "closed", "", false, ""); err != nil {
+		return err
+	}
+	return l.setResolution(issue, "", "")
+}
+
+// Comment adds a comment by a user to an issue, returning its stamp.  The
+// change is recorded for SendEvents.
+func (l *Lit) Comment(issue *dgrl.Branch, username, text string) (string, error) {
+	stamp, err := l.comment(issue, username, text)
+	if err != nil {
+		return "", err
+	}
+	l.Record(&Event{Issue: issue, Action: "commented on", User: username, Detail: text})
+	return stamp, nil
+}
+
+// comment adds a comment without recording it, for changes that record their
+// own events.
+func (l *Lit) comment(issue *dgrl.Branch, username, text string) (string, error) {
+	stamp := l.Stamp(username)
+	comment := dgrl.NewBranch(stamp)
+	comment.Append(dgrl.NewText(text))
+	issue.Append(comment)
+	if !Touch(issue, stamp) {
+		return "", fmt.Errorf("error setting update time for issue %s", l.ShortID(issue.Key()))
+	}
+	return stamp, nil
+}
+
+// update is the one path by which the command line and the server change
+// issues' fields, so that the tracker's rules apply the same way to both.
+// Unless forced, setting closed closes the issue, or reopens it if val is
+// empty, through the workflow, if it governs closing.  The event recorded
+// carries detail, or val when setting a field.
+func (l *Lit) update(issue *dgrl.Branch, username, key, val string, force bool, detail string) error {
+	id := l.ShortID(issue.Key())
+	wf, err := l.Workflow()
+	if err != nil {
+		return err
+	}
+	stamp := l.Stamp(username)
+	transition := key == "status" && wf != nil && !force
+	closing, action := false, "set "+key+" in"
+	hooks := []*Change{}
+	switch {
+	case key == "closed" && !force:
+		closing, action = val != "", "reopened"
+		if wf != nil {
+			state, err := wf.closeState(issue, closing)
+			if err != nil {
+				return err
+			}
+			if state != "" {
+				key, val, transition = "status", state, true
+				hooks = append(hooks, NewChange(issue, username, key, val))
+			}
+		}
+		if closing {
+			action = "closed"
+			hooks = append(hooks, NewChange(issue, username, "closed", stamp))
+		}
+	case IsReserved(key) && !force:
+		return &ReservedError{ID: id, Key: key}
+	case transition:
+		if err := wf.checkTransition(issue, val); err != nil {
+			return err
+		}
+		closing = wf.Closed[val] && isOpen(issue)
+		hooks = append(hooks, NewChange(issue, username, key, val))
+		if closing {
+			hooks = append(hooks, NewChange(issue, username, "closed", stamp))
+		}
+	default:
+		if val, err = l.NormalizeDate(key, val); err != nil {
+			return fmt.Errorf("issue %s: %w", id, err)
+		}
+		hooks = append(hooks, NewChange(issue, username, key, val))
+	}
+	if closing {
+		if err := l.checkApprovals(issue); err != nil {
+			return err
+		}
+	}
+	for _, change := range hooks {
+		name := "pre-set"
+		if change.Key == "closed" {
+			name = "pre-close"
+		}
+		if err := l.RunHook(name, change); err != nil {
+			return fmt.Errorf("issue %s: %w", id, err)
+		}
+	}
+	ok := false
+	switch {
+	case transition:
+		if err := wf.move(issue, val, stamp); err != nil {
+			return err
+		}
+		ok = true
+	case key == "closed" && !force && closing:
+		ok = SetForce(issue, "closed", stamp)
+	case key == "closed" && !force:
+		ok = Reopen(issue, stamp)
+	case force:
+		ok = SetForce(issue, key, val)
+	default:
+		// descriptions are long values, even of one line
+		ok = set(issue, key, val, true, strings.Contains(val, "\n") || key == "description", false)
+	}
+	if !ok || !Touch(issue, stamp) {
+		return fmt.Errorf("error updating fields in issue %s", id)
+	}
+	if strings.HasPrefix(action, "set ") {
+		detail = val
+	}
+	l.Record(&Event{Issue: issue, Action: action, User: username, Detail: detail})
+	return nil
+}
+
+// setResolution records how an issue was closed, or clears it if both
+// resolution and reason are empty, as when reopening.
+func (l *Lit) setResolution(issue *dgrl.Branch, resolution, reason string) error {
+	for _, field := range [][]string{{"resolution", resolution}, {"reason", reason}} {
+		if field[1] != "" {
+			if !SetExact(issue, field[0], field[1]) {
+				return fmt.Errorf("error updating fields in issue %s", l.ShortID(issue.Key()))
+			}
+		} else if _, ok := GetExact(issue, field[0]); ok {
+			Unset(issue, field[0])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package lit
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// CheckItem is an item in an issue's checklist.
+type CheckItem struct {
+	Text string
+	Done bool
+	line int
+}
+
+// Checklist returns the checklist items ("- [ ] step" or "- [x] step" lines)
+// in an issue's description, in order.
+func Checklist(issue *dgrl.Branch) []CheckItem {
+	desc, _ := GetExact(issue, "description")
+	items := []CheckItem{}
+	for i, line := range strings.Split(desc, "\n") {
+		if m := checkItemRe.FindStringSubmatch(line); m != nil {
+			items = append(items, CheckItem{Text: m[3], Done: m[2] != " ", line: i})
+		}
+	}
+	return items
+}
+
+// ChecklistProgress returns the number of done and total checklist items in
+// an issue.
+func ChecklistProgress(issue *dgrl.Branch) (done, total int) {
+	items := Checklist(issue)
+	for _, item := range items {
+		if item.Done {
+			done++
+		}
+	}
+	return done, len(items)
+}
+
+// ToggleCheck toggles the nth (counting from 1) checklist item of an issue,
+// returning whether it is now done.
+func ToggleCheck(issue *dgrl.Branch, n int) (bool, error) {
+	items := Checklist(issue)
+	if n < 1 || n > len(items) {
+		return false, fmt.Errorf("no checklist item %d (%d items)", n, len(items))
+	}
+	item := items[n-1]
+	desc, _ := GetExact(issue, "description")
+	lines := strings.Split(desc, "\n")
+	mark := "x"
+	if item.Done {
+		mark = " "
+	}
+	line := lines[item.line]
+	m := checkItemRe.FindStringSubmatchIndex(line)
+	lines[item.line] = line[:m[4]] + mark + line[m[5]:]
+	if !set(issue, "description", strings.Join(lines, "\n"), true, true, false) {
+		return false, fmt.Errorf("error updating description of issue %s", issue.Key())
+	}
+	return !item.Done, nil
+}
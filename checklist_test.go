@@ -0,0 +1,74 @@
+package lit
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// checklistIssue returns an issue with a description.
+func checklistIssue(desc string) *dgrl.Branch {
+	issue := dgrl.NewBranch("test")
+	issue.Append(dgrl.NewLeaf("summary", ""))
+	issue.Append(dgrl.NewLongLeaf("description", desc))
+	return issue
+}
+
+func TestChecklist(t *testing.T) {
+	tests := []struct {
+		desc  string
+		items []CheckItem
+	}{
+		{"", []CheckItem{}},
+		{"no list\n- plain item", []CheckItem{}},
+		{"Steps:\n- [ ] write\n- [x] test\n  * [X] nested\n+ [ ]   spaced", []CheckItem{
+			{Text: "write", line: 1}, {Text: "test", Done: true, line: 2},
+			{Text: "nested", Done: true, line: 3}, {Text: "spaced", line: 4},
+		}},
+		{"-[ ] no space\n- [] empty\n- [y] other mark", []CheckItem{}},
+	}
+	for _, test := range tests {
+		issue := checklistIssue(test.desc)
+		if got := Checklist(issue); !reflect.DeepEqual(got, test.items) {
+			t.Errorf("%q: Checklist = %+v, want %+v", test.desc, got, test.items)
+		}
+		done, total := ChecklistProgress(issue)
+		wantDone := 0
+		for _, item := range test.items {
+			if item.Done {
+				wantDone++
+			}
+		}
+		if done != wantDone || total != len(test.items) {
+			t.Errorf("%q: progress %d/%d, want %d/%d", test.desc, done, total, wantDone, len(test.items))
+		}
+	}
+}
+
+func TestToggleCheck(t *testing.T) {
+	issue := checklistIssue("Steps:\n- [ ] write [ ] this\n  * [X] test")
+	tests := []struct {
+		n    int
+		done bool
+		desc string
+	}{
+		{1, true, "Steps:\n- [x] write [ ] this\n  * [X] test"},
+		{2, false, "Steps:\n- [x] write [ ] this\n  * [ ] test"},
+		{1, false, "Steps:\n- [ ] write [ ] this\n  * [ ] test"},
+	}
+	for _, test := range tests {
+		done, err := ToggleCheck(issue, test.n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if desc, _ := GetExact(issue, "description"); done != test.done || desc != test.desc {
+			t.Errorf("toggle %d: %v, %q, want %v, %q", test.n, done, desc, test.done, test.desc)
+		}
+	}
+	for _, n := range []int{0, 3} {
+		if _, err := ToggleCheck(issue, n); err == nil {
+			t.Errorf("toggled nonexistent item %d", n)
+		}
+	}
+}
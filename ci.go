@@ -0,0 +1,36 @@
+package lit
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// CIStatuses are the statuses of CI results.
+var CIStatuses = []string{"passed", "failed", "running", "canceled"}
+
+// ReportCI records a CI result for an issue, as a comment "CI <status>
+// [<url>]", and sets its ci-status and ci-url fields to the latest result.
+func (l *Lit) ReportCI(issue *dgrl.Branch, username, status, url string) error {
+	valid := false
+	for _, s := range CIStatuses {
+		valid = valid || s == status
+	}
+	if !valid {
+		return fmt.Errorf("invalid CI status '%s' (%s)", status, strings.Join(CIStatuses, ", "))
+	}
+	if _, err := l.comment(issue, username, strings.TrimSpace("CI "+status+" "+url)); err != nil {
+		return err
+	}
+	ok := SetExact(issue, "ci-status", status)
+	if url != "" {
+		ok = ok && SetExact(issue, "ci-url", url)
+	} else if _, has := GetExact(issue, "ci-url"); has {
+		ok = ok && Unset(issue, "ci-url")
+	}
+	if !ok {
+		return fmt.Errorf("error updating fields in issue %s", issue.Key())
+	}
+	return nil
+}
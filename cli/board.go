@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ianremmler/lit"
+)
+
+const minColWidth = 16
+
+func (st *state) boardCmd() {
+	key := "status"
+	if len(st.args) > 0 && st.args[0] == "--by" {
+		if len(st.args) < 2 {
+			st.usagef("board: you must specify a key to group by\n")
+		}
+		key = st.args[1]
+		st.args = st.args[2:]
+	}
+	if len(st.args) == 0 {
+		st.args = []string{"all"}
+	}
+	st.loadIssues()
+	groups := st.it.Group(st.specIds(), key)
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		// keep the column for issues without a value last
+		if names[i] == "" || names[j] == "" {
+			return names[j] == ""
+		}
+		return names[i] < names[j]
+	})
+	if len(names) == 0 {
+		return
+	}
+
+	width := (st.termWidth() - (len(names) - 1)) / len(names)
+	if width < minColWidth {
+		width = minColWidth
+	}
+	cell := func(text string) string {
+		if len(text) > width {
+			text = text[:width]
+		}
+		return fmt.Sprintf("%-*s", width, text)
+	}
+
+	row, rule := []string{}, []string{}
+	numRows := 0
+	for _, name := range names {
+		num := len(groups[name])
+		if num > numRows {
+			numRows = num
+		}
+		if name == "" {
+			name = "-"
+		}
+		row = append(row, cell(fmt.Sprintf("%s (%d)", name, num)))
+		rule = append(rule, strings.Repeat("-", width))
+	}
+	fmt.Fprintln(st.stdout, strings.TrimRight(strings.Join(row, " "), " "))
+	fmt.Fprintln(st.stdout, strings.Join(rule, " "))
+	for i := 0; i < numRows; i++ {
+		row = row[:0]
+		for _, name := range names {
+			text := ""
+			if i < len(groups[name]) {
+				issue := st.findIssue(groups[name][i])
+				summary, _ := lit.Get(issue, "summary")
+				text = st.it.ShortID(issue.Key()) + " " + summary
+			}
+			row = append(row, cell(text))
+		}
+		fmt.Fprintln(st.stdout, strings.TrimRight(strings.Join(row, " "), " "))
+	}
+}
+
+// termWidth returns the width of the terminal, or 80 if it can't be found.
+func (st *state) termWidth() int {
+	if cols, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && cols > 0 {
+		return cols
+	}
+	stty := exec.Command("stty", "size")
+	stty.Stdin = st.stdin
+	if out, err := stty.Output(); err == nil {
+		if fields := strings.Fields(string(out)); len(fields) == 2 {
+			if cols, err := strconv.Atoi(fields[1]); err == nil && cols > 0 {
+				return cols
+			}
+		}
+	}
+	return 80
+}
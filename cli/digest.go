@@ -0,0 +1,105 @@
+package cli
+
+import (
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/ianremmler/lit"
+)
+
+const digestText = `Issue digest since {{.Since}}
+
+Created ({{len .Created}}):
+{{range .Created}}  {{.Short}} {{.Summary}}
+{{end}}
+Closed ({{len .Closed}}):
+{{range .Closed}}  {{.Short}} {{.Summary}}
+{{end}}
+Most active:
+{{range .Active}}  {{.Short}} {{.Summary}} ({{.Comments}} comments)
+{{end}}`
+
+const digestHTML = `<html><body>
+<h1>Issue digest since {{.Since}}</h1>
+<h2>Created ({{len .Created}})</h2>
+<ul>{{range .Created}}<li><code>{{.Short}}</code> {{.Summary}}</li>{{end}}</ul>
+<h2>Closed ({{len .Closed}})</h2>
+<ul>{{range .Closed}}<li><code>{{.Short}}</code> {{.Summary}}</li>{{end}}</ul>
+<h2>Most active</h2>
+<ul>{{range .Active}}<li><code>{{.Short}}</code> {{.Summary}} ({{.Comments}} comments)</li>{{end}}</ul>
+</body></html>
+`
+
+type digestEntry struct {
+	Short, Summary string
+	Comments       int
+}
+
+type digestView struct {
+	Since                   string
+	Created, Closed, Active []digestEntry
+}
+
+func (st *state) digestCmd() {
+	since, isHTML, doEmail := "1w", false, false
+	for len(st.args) > 0 {
+		switch st.args[0] {
+		case "--since":
+			if len(st.args) < 2 {
+				st.usagef("digest: --since requires an age, e.g. 1w\n")
+			}
+			since = st.args[1]
+			st.args = st.args[1:]
+		case "--html":
+			isHTML = true
+		case "--email":
+			doEmail = true
+		default:
+			st.usagef("digest: %s is not a valid option\n", st.args[0])
+		}
+		st.args = st.args[1:]
+	}
+	age, err := lit.ParseAge(since)
+	st.checkErr(err)
+	st.loadIssues()
+
+	digest := st.it.Digest(st.it.Now().Add(-age), 10)
+	view := &digestView{Since: digest.Since.UTC().Format("2006-01-02 15:04 MST")}
+	entry := func(id string, comments int) digestEntry {
+		issue := st.findIssue(id)
+		summary, _ := lit.Get(issue, "summary")
+		return digestEntry{Short: st.it.ShortID(id), Summary: summary, Comments: comments}
+	}
+	for _, id := range digest.Created {
+		view.Created = append(view.Created, entry(id, 0))
+	}
+	for _, id := range digest.Closed {
+		view.Closed = append(view.Closed, entry(id, 0))
+	}
+	for _, act := range digest.Active {
+		view.Active = append(view.Active, entry(act.ID, act.Comments))
+	}
+
+	body := &strings.Builder{}
+	err = writeDigest(body, view, isHTML)
+	st.checkErr(err)
+	if !doEmail {
+		io.WriteString(st.stdout, body.String())
+		return
+	}
+	to, _ := st.it.Config("digest-to")
+	subject := "Issue digest since " + view.Since
+	err = st.it.SendMail(strings.Fields(strings.Replace(to, ",", " ", -1)), subject, body.String(), isHTML)
+	st.checkErr(err)
+}
+
+func writeDigest(w io.Writer, view *digestView, isHTML bool) error {
+	if isHTML {
+		tmpl := htmltemplate.Must(htmltemplate.New("digest").Parse(digestHTML))
+		return tmpl.Execute(w, view)
+	}
+	tmpl := template.Must(template.New("digest").Parse(digestText))
+	return tmpl.Execute(w, view)
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+func (st *state) draftCmd() {
+	if len(st.args) < 1 {
+		st.usagef("draft: you must specify an operation (new, edit, submit, list)\n")
+	}
+	op := st.args[0]
+	st.loadIssues()
+	if op == "list" {
+		names, err := st.it.Drafts(st.username)
+		st.checkErr(err)
+		for _, name := range names {
+			fmt.Fprintln(st.stdout, name)
+		}
+		return
+	}
+	if len(st.args) < 2 {
+		st.usagef("draft %s: you must specify a draft name\n", op)
+	}
+	name := st.args[1]
+	switch op {
+	case "new":
+		path, err := st.it.NewDraft(st.username, name)
+		st.checkErr(err)
+		st.editDraft(path)
+	case "edit":
+		path := st.it.DraftPath(st.username, name)
+		if _, err := os.Stat(path); err != nil {
+			st.fatalf("draft edit: draft %s not found\n", name)
+		}
+		st.editDraft(path)
+	case "submit":
+		issue, err := st.it.SubmitDraft(st.username, name)
+		st.checkErr(err)
+		fmt.Fprintln(st.stdout, issue.Key())
+		st.storeIssues()
+	default:
+		st.fatalf("draft: unknown operation %s\n", op)
+	}
+}
+
+// editDraft opens a draft in the editor.  Drafts are edited in place, so that
+// they may be left and picked up later.
+func (st *state) editDraft(path string) {
+	editor := getEditor()
+	if editor == "" {
+		st.fatalln("draft: VISUAL or EDITOR environment variable must be set")
+	}
+	ed := exec.Command(editor, path)
+	ed.Stdin, ed.Stdout, ed.Stderr = st.stdin, st.stdout, st.stderr
+	st.checkErr(ed.Run())
+}
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/ianremmler/dgrl"
+	"github.com/ianremmler/lit"
+)
+
+const preCommitHook = `#!/bin/sh
+# installed by lit hook install pre-commit
+exec lit hook run pre-commit
+`
+
+func (st *state) hookCmd() {
+	if len(st.args) < 2 || st.args[1] != "pre-commit" {
+		st.usagef("hook: you must specify an operation (install or run) and hook (pre-commit)\n")
+	}
+	switch st.args[0] {
+	case "install":
+		st.installPreCommit()
+	case "run":
+		st.runPreCommit()
+	default:
+		st.fatalf("hook: unknown operation %s\n", st.args[0])
+	}
+}
+
+// installPreCommit installs a git pre-commit hook that runs runPreCommit.
+func (st *state) installPreCommit() {
+	hooksDir, err := git("rev-parse", "--git-path", "hooks")
+	st.checkErr(err)
+	path := filepath.Join(hooksDir, "pre-commit")
+	if data, err := ioutil.ReadFile(path); err == nil && string(data) != preCommitHook {
+		st.fatalf("hook: %s exists; add 'lit hook run pre-commit' to it\n", path)
+	}
+	st.checkErr(os.MkdirAll(hooksDir, 0777))
+	st.checkErr(ioutil.WriteFile(path, []byte(preCommitHook), 0777))
+	st.debugf("installed %s\n", path)
+}
+
+// runPreCommit checks each tracker with staged changes, as staged, with fsck,
+// and the issues changed since HEAD with lint.  It exits unsuccessfully if
+// problems are found.
+func (st *state) runPreCommit() {
+	top, err := git("rev-parse", "--show-toplevel")
+	st.checkErr(err)
+	staged, err := git("-C", top, "diff", "--cached", "--name-only", "-z")
+	st.checkErr(err)
+	trackers := map[string]bool{}
+	for _, name := range strings.Split(staged, "\x00") {
+		parts := strings.Split(name, "/")
+		for i := range parts {
+			if parts[i] == ".lit" {
+				trackers[strings.Join(parts[:i+1], "/")] = true
+				break
+			}
+		}
+	}
+	problems := []*lit.Problem{}
+	for rel := range trackers {
+		problems = append(problems, st.checkStaged(top, rel)...)
+	}
+	st.reportProblems(problems, false, "")
+}
+
+// checkStaged checks the staged tracker at rel, relative to the top of the
+// work tree.
+func (st *state) checkStaged(top, rel string) []*lit.Problem {
+	tmp, err := ioutil.TempDir("", "lit-")
+	st.checkErr(err)
+	defer os.RemoveAll(tmp)
+	files, err := git("-C", top, "ls-files", "-z", "--", rel)
+	st.checkErr(err)
+	checkout := exec.Command("git", "-C", top, "checkout-index", "-z", "--stdin", "--prefix="+tmp+"/")
+	checkout.Stdin, checkout.Stderr = strings.NewReader(files), st.stderr
+	st.checkErr(checkout.Run())
+
+	// issues as committed, to find what changed
+	committed := map[string]string{}
+	if data, err := git("-C", top, "show", "HEAD:"+rel+"/issues"); err == nil {
+		if root, err := lit.DecodeIssues([]byte(data)); err == nil {
+			for _, k := range root.Kids() {
+				if issue, ok := k.(*dgrl.Branch); ok {
+					committed[issue.Key()] = issue.String()
+				}
+			}
+		}
+	}
+
+	st.it = lit.NewWithFS(lit.OSFS{}, filepath.Join(tmp, filepath.FromSlash(path.Dir(rel))))
+	st.it.DisableCache()
+	st.loadIssues()
+	problems, err := st.it.Check()
+	st.checkErr(err)
+	changed := []string{}
+	for _, id := range st.it.IssueIds() {
+		if committed[id] != st.it.Issue(id).String() {
+			changed = append(changed, id)
+		}
+	}
+	problems = append(problems, st.it.Lint(changed)...)
+	for _, p := range problems {
+		p.ID = rel + ": " + p.ID
+	}
+	return problems
+}
+
+// git runs a git command and returns its output, trimmed of a trailing
+// newline.
+func git(gitArgs ...string) (string, error) {
+	out := &bytes.Buffer{}
+	gitCmd := exec.Command("git", gitArgs...)
+	gitCmd.Stdout = out
+	err := gitCmd.Run()
+	return strings.TrimSuffix(out.String(), "\n"), err
+}
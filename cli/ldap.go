@@ -0,0 +1,216 @@
+package cli
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ianremmler/lit"
+)
+
+// ldapTimeout bounds a bind, from dialing to the directory's response.
+const ldapTimeout = 10 * time.Second
+
+// ldapProvider authenticates users by an LDAP simple bind as the user,
+// configured as "ldap-url", an ldaps:// (or, on a trusted network, ldap://)
+// URL of the directory, and "ldap-bind-dn", the user's DN with "{user}" in
+// place of their name, as in "uid={user},ou=people,dc=example,dc=com".  Users
+// send their name and password by HTTP basic authentication, and are
+// identified by their name.
+type ldapProvider struct {
+	addr   string
+	tls    bool
+	host   string
+	bindDN string
+}
+
+// newLDAPProvider returns the provider configured for a loaded tracker, or nil
+// if there is none.
+func newLDAPProvider(tracker *lit.Lit) (*ldapProvider, error) {
+	rawURL, _ := tracker.Config("ldap-url")
+	if rawURL == "" {
+		return nil, nil
+	}
+	bindDN, _ := tracker.Config("ldap-bind-dn")
+	if !strings.Contains(bindDN, "{user}") {
+		return nil, errors.New("ldap: ldap-bind-dn must contain {user}")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("ldap: %w", err)
+	}
+	p := &ldapProvider{host: u.Hostname(), bindDN: bindDN}
+	port := u.Port()
+	switch u.Scheme {
+	case "ldaps":
+		p.tls = true
+		if port == "" {
+			port = "636"
+		}
+	case "ldap":
+		if port == "" {
+			port = "389"
+		}
+	default:
+		return nil, fmt.Errorf("ldap: unsupported scheme %s", u.Scheme)
+	}
+	p.addr = net.JoinHostPort(p.host, port)
+	return p, nil
+}
+
+// identity binds to the directory as a user, returning their name if the
+// password is accepted.
+func (p *ldapProvider) identity(user, password string) (string, error) {
+	// an empty password would be an unauthenticated bind, which succeeds
+	if user == "" || password == "" {
+		return "", errors.New("ldap: missing user or password")
+	}
+	dialer := &net.Dialer{Timeout: ldapTimeout}
+	var conn net.Conn
+	var err error
+	if p.tls {
+		conn, err = tls.DialWithDialer(dialer, "tcp", p.addr, &tls.Config{ServerName: p.host})
+	} else {
+		conn, err = dialer.Dial("tcp", p.addr)
+	}
+	if err != nil {
+		return "", fmt.Errorf("ldap: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(ldapTimeout))
+	dn := strings.Replace(p.bindDN, "{user}", ldapEscape(user), -1)
+	if _, err := conn.Write(ldapBindRequest(1, dn, password)); err != nil {
+		return "", fmt.Errorf("ldap: %w", err)
+	}
+	code, err := readLDAPBindResponse(bufio.NewReader(conn))
+	if err != nil {
+		return "", fmt.Errorf("ldap: %w", err)
+	}
+	// unbind; the directory closes the connection
+	conn.Write(berTLV(0x30, append(berInt(2), berTLV(0x42, nil)...)))
+	if code != 0 {
+		return "", fmt.Errorf("ldap: bind as %s failed with result %d", user, code)
+	}
+	return user, nil
+}
+
+// ldapEscape escapes the characters of a name that are special in a DN, as
+// RFC 4514 requires.
+func ldapEscape(s string) string {
+	buf := &strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case strings.IndexByte(`,+"\<>;=`, c) >= 0,
+			(c == ' ' || c == '#') && i == 0, c == ' ' && i == len(s)-1:
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case c < 0x20 || c == 0x7f:
+			fmt.Fprintf(buf, `\%02x`, c)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return buf.String()
+}
+
+// ldapBindRequest returns an LDAPv3 simple bind request message.
+func ldapBindRequest(id int, dn, password string) []byte {
+	bind := append(berInt(3), berTLV(0x04, []byte(dn))...)
+	bind = append(bind, berTLV(0x80, []byte(password))...)
+	return berTLV(0x30, append(berInt(id), berTLV(0x60, bind)...))
+}
+
+// readLDAPBindResponse returns the result code of a bind response message.
+func readLDAPBindResponse(r *bufio.Reader) (int, error) {
+	tag, msg, err := readBER(r)
+	if err != nil {
+		return 0, err
+	}
+	if tag != 0x30 {
+		return 0, errors.New("malformed response")
+	}
+	mr := bufio.NewReader(strings.NewReader(string(msg)))
+	if tag, _, err = readBER(mr); err != nil || tag != 0x02 {
+		return 0, errors.New("malformed response id")
+	}
+	tag, op, err := readBER(mr)
+	if err != nil || tag != 0x61 {
+		return 0, errors.New("response is not a bind response")
+	}
+	tag, code, err := readBER(bufio.NewReader(strings.NewReader(string(op))))
+	if err != nil || tag != 0x0a || len(code) == 0 || len(code) > 4 {
+		return 0, errors.New("malformed bind result")
+	}
+	n := 0
+	for _, b := range code {
+		n = n<<8 | int(b)
+	}
+	return n, nil
+}
+
+// readBER reads a BER element with a definite length, returning its tag and
+// contents.
+func readBER(r *bufio.Reader) (byte, []byte, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	n := int(b)
+	if b&0x80 != 0 {
+		size := int(b & 0x7f)
+		if size == 0 || size > 3 {
+			return 0, nil, errors.New("unsupported BER length")
+		}
+		n = 0
+		for i := 0; i < size; i++ {
+			if b, err = r.ReadByte(); err != nil {
+				return 0, nil, err
+			}
+			n = n<<8 | int(b)
+		}
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return 0, nil, err
+	}
+	return tag, data, nil
+}
+
+// berTLV returns a BER element with a definite length.
+func berTLV(tag byte, data []byte) []byte {
+	out := []byte{tag}
+	switch n := len(data); {
+	case n < 0x80:
+		out = append(out, byte(n))
+	case n < 0x100:
+		out = append(out, 0x81, byte(n))
+	case n < 0x10000:
+		out = append(out, 0x82, byte(n>>8), byte(n))
+	default:
+		out = append(out, 0x83, byte(n>>16), byte(n>>8), byte(n))
+	}
+	return append(out, data...)
+}
+
+// berInt returns a BER integer element for a small non-negative n.
+func berInt(n int) []byte {
+	data := []byte{byte(n)}
+	for n >>= 8; n > 0; n >>= 8 {
+		data = append([]byte{byte(n)}, data...)
+	}
+	if data[0]&0x80 != 0 {
+		data = append([]byte{0}, data...)
+	}
+	return berTLV(0x02, data)
+}
@@ -0,0 +1,2679 @@
+package cli
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ianremmler/dgrl"
+	"github.com/ianremmler/lit"
+)
+
+const usage = `lit [-q | -v] [--errors json] [--porcelain] [--literal] [--no-cache]
+    [--tz <zone>] [--relative] [--remote <url>] <command> ...
+	-q: don't show warnings, only errors
+	-v: also show traces of what lit is doing, e.g. files loaded and timings
+	--errors json: write errors and warnings to stderr as JSON objects, one per
+	               line, with level, code, command, message, and id fields
+	--porcelain: stable tab-separated list output for scripts
+	--literal: match spec values as plain text, not regular expressions
+	--no-cache: parse the issue file rather than using the cache of it
+	            under .lit/cache, which is kept in sync with the file
+	--tz: show times in zone ("local" or e.g. "Europe/Paris", default:
+	      configured time-zone or UTC)
+	--relative: show times relative to now, e.g. "3 days ago" (default:
+	            set by configuring time-format as relative)
+	--remote: operate on the tracker served by 'lit serve --http' at url,
+	          sending the LIT_TOKEN environment variable as the token
+	          (only id, list, show, search, new, set, comment, close, and
+	          reopen, with changes made as the server's user, or the
+	          token's if the server accepts OAuth tokens)
+
+lit help                        Display usage information
+lit init [--ids <format>] [--format <format>]
+	Initialize new issue tracker, generating ids in format uuid (default),
+	uuid7 or ulid (which sort by time), or seq (1, 2, ...), configured as
+	id-format, and writing the issue file in format dgrl (default) or yaml,
+	configured as issue-format
+lit new [--template <name> [--answer <question>=<answer>]...] [<num>]
+	Create num new issues (default: 1), optionally with the fields of
+	.lit/templates/<name>, in which {{prompt "<question>"}} is replaced by
+	the given answer or else one asked for
+lit new --from-panic            Create an issue for a Go panic trace read from
+                                stdin, or comment on the issue with the same
+                                stack (by its panic-signature)
+lit [id] [--prefixed] [<sort>] [<page>] <spec>
+	Show ids of specified issues, prefixed by the project config (as in
+	backend-3f2a...) if --prefixed.  Prefixed ids are accepted anywhere
+lit list [--format <name>] [<sort>] [<page>] <spec>
+	List specified issues as a table, or as porcelain, json, csv,
+	markdown, org, or any format a program embedding lit registers
+lit show [--copy-url | --copy-id] [<sort>] [<page>] <spec>
+	Show specified issues, or copy their ids or URLs (from the url-template
+	config, with {id} in place of the id) to the clipboard
+lit open <id>                   Open issue in the web frontend (url-template)
+lit board [--by <key>] [<spec>]
+	Show issues in columns grouped by key (default: status)
+lit graph [--format dot] [<spec>]
+	Write the relations (parent, depends-on, blocks, duplicate-of, and
+	references) of specified issues (default: open) as a Graphviz graph
+lit digest [--since <age>] [--html] [--email]
+	Summarize issues created, closed, and commented on since age ago
+	(default: 1w), optionally mailing it to the configured digest-to
+lit search [--engine (lit | es)] [--closed] [--in <fields>] [<page>] <words>
+	Search issues for words, ranked by relevance, optionally including
+	closed issues or only searching comma separated fields.  The es engine
+	queries the Elasticsearch index at es-url, kept up to date by the es
+	notify backend
+lit search --engine es --reindex
+	Add all issues to the Elasticsearch index
+lit count [--by <key>] <spec>   Count specified issues, or issues per value of key
+lit stale [--days <num>] [<spec>]
+	List open issues not updated in num days (default: 30)
+lit metrics leadtime [<spec>]   Show lead (created to closed) and cycle (started,
+                                or first comment, to closed) time percentiles
+                                and a histogram of open issue ages (default: all)
+lit triage [<spec>]             Step through open issues without a priority,
+                                setting priority, tags, or assignee, or closing
+lit sla check [<spec>]          Report open issues exceeding configured update
+                                intervals ("sla-<priority>: <age>" in config)
+lit policy run [--dry-run]      Apply configured policies, commenting on, then
+                                closing, open issues not updated in a while
+                                ("policy-<name>: [tag=<tag>] [warn=<age>]
+                                [close=<age>]" in config), except those
+                                tagged no-autoclose
+lit set [--exact | --force] <key> (<val> | --from-file <file>) <spec>
+	Set value for key in specified issues, multi-line values included
+	key may abbreviate a single existing key, unless --exact is given
+	Reserved keys (created, updated, closed) require --force
+	Dates (due and the date-fields config) may be given as today,
+	tomorrow, a weekday, +2w, -3d, or 2025-12-01, also in filters
+lit unset <key> <spec>          Remove key and its value from specified issues
+lit assign (<user> | (--add|--remove) <user> | (--round-robin|--random) <users>) <spec>
+	Assign specified issues to a user, add or remove a co-assignee, or
+	distribute them among users, given as a comma separated list or the
+	name of a configured team.  If users are configured, only they may
+	be assigned
+lit check <id> [<n>...]         Toggle numbered checklist items ("- [ ] step"
+                                lines in the description), or list them
+lit vote [--retract] <spec>     Vote (or retract vote) for specified issues
+lit workflow [<spec>]           Show the workflow's states and the states each
+                                may move to, or the specified issues' states
+                                and next states; workflow-states, with the
+                                initial state first, workflow-<state>, and
+                                workflow-closed configure it, and set status
+                                then only makes those moves (unless --force)
+lit ack <spec>                  Record that you acknowledged specified issues,
+                                as "<user> acknowledged at <time>" in acks
+lit review (request <id> <users> | (approve|reject) <id> [<comment>])
+	Ask users to review an issue, or approve or reject it.  If the
+	close-approvals config is set, issues need that many approvals to close
+lit pin <id> [<rank>]           Pin issue at rank (default: last) atop lists
+lit unpin <id>                  Unpin issue
+lit tag (add|del) <tag> <spec>  Add or delete tag in specified issues
+lit (add|remove) <key> <val> <spec>
+	Add or remove val in the space separated set of values for key in
+	specified issues.  Fields listed in the multi-value config, like tags
+	and assigned, match and count their values individually and whole
+lit comment <id> [<text>]       Add issue comment (default: edit text)
+lit desc <id> [--set-file <file> | --append <text>]
+	Show, replace from a file, or append to an issue's description
+lit draft (new <name> | edit <name> | submit <name> | list)
+	Compose issues privately under .lit/drafts/<user>/, across sessions if
+	needed, then submit them as new issues
+lit merge <dup-id> --into <id>  Merge a duplicate issue into another and close it
+lit edit [--force] <spec>       Edit specified issues (--force to change
+                                ids or created and closed stamps); issues
+                                that don't parse are saved under
+                                .lit/recovery/<user>/ and the rest applied
+lit close [--as <resolution>] [--reason <text>] <spec>
+	Close specified issues, optionally recording a resolution (from the
+	resolutions config, default: fixed, wontfix, duplicate), shown by its
+	first letter in the list closed column, and a reason
+lit reopen <spec>               Reopen specified issues, clearing any resolution
+                                and counting reopenings in reopen-count
+lit attach (add [--recursive | --zip] [-m <desc>] <id> <files> [<desc>] |
+            show [--open] <id> <file> | list <id> | du [<spec>])
+	Add files (or directories' contents, or zipped directories), show
+	(or open in a viewer), or list issue attachments, or summarize their
+	storage by issue (default: all), failing if any issue's exceeds the
+	attach-quota config (e.g. 10M)
+lit alias (add <name> <id> | del <name> | list)
+	Add, delete, or list issue aliases, usable anywhere an id is
+lit release (start <version> | add <spec> | list [<version>] |
+             due <version> <date> | ship <version>)
+	Start a release, add issues to the current release, list a release's
+	issues, set a release's deadline (a date as for due fields), or ship a
+	release whose issues are all closed, setting their fixed-in,
+	snapshotting the tracker as the version, and printing a changelog
+lit snapshot (create <name> | list | diff <from> [<to>])
+	Capture the tracker state in .lit/snapshots/<name>, list snapshots, or
+	list issues opened, closed, reopened, changed, or removed between two
+	snapshots, or a snapshot and the current state
+lit security (new [<summary>] | publish <id> | advisory <id>)
+	Create a confidential security issue with cvss and affects fields,
+	declassify it and print its advisory, or just print the advisory.
+	Confidential issues are left out of notifications
+lit estimate sum [<spec>]       Total the estimates of specified issues (default:
+                                open), where issues without an estimate count
+                                their children's (those with them as parent)
+lit plan [--milestone <version>] [--capacity <user>=<hours>,...]
+	Compare the estimated open work assigned to each user in a milestone
+	(default: the current release) to their capacity
+lit ci report <id> --status <status> [--url <url>]
+	Record a CI result (passed, failed, running, or canceled) for an issue,
+	setting ci-status, shown in the list v column (+ passed, ! failed)
+lit workload [--milestone <version>]
+	Summarize the open issues and estimated work of each assignee
+lit critical-path [--milestone <version>]
+	List the longest chain, by estimates of open issues, of dependencies
+	(depends-on and blocks) leading to a milestone's (default: the current
+	release's) issues; its open issues gate the release
+lit export ics [<spec>]         Export due dates, and release deadlines, as
+                                iCalendar (default: open)
+lit export events [--since <time>] [<spec>]
+	Export creations, comments, attachments, and closings as JSON lines,
+	since a time or an age like 2w (default: all issues, all time)
+lit export (git-bug|fossil) [<spec>]
+	Export issues as git-bug JSON, or as a script of fossil ticket add
+	commands (default: open)
+lit import (org|md|flit) <file> Create issues from outline headings and checklists,
+                                or add the issues from a flit issues file
+lit import (git-bug|fossil) <file>
+	Add the bugs printed by git bug show --format json, or the tickets
+	printed by fossil ticket show 0 -q, skipping those already imported
+lit mirror [<url>] [--every <interval>] [--follow]
+	Make this tracker (initialized if need be) a read-only copy of the one
+	served at url (default: the mirror-of config), refreshing it once,
+	every interval like 5m, or with --follow, whenever the server's
+	/events stream reports a change (reconnecting every interval, default
+	30s, when offline); new, set, comment, close, and reopen are then sent
+	to url, as with --remote, and other changes are refused
+lit sync [--prefer (lit | forge)]
+	Sync issues, labels (as tags), and comments both ways with the Gitea or
+	GitLab project configured by sync-forge, sync-url, sync-repo, and
+	sync-token (or LIT_SYNC_TOKEN), reporting issues changed on both sides
+	as conflicts unless a side is preferred
+lit fsck [--prune]              Check for orphan attachments, relations to missing
+                                issues, and malformed comment stamps, optionally
+                                removing orphans and dangling relations
+lit gc                          Compact the issue file, trimming stray whitespace,
+                                and remove orphan attachments and stale cache
+                                entries, reporting the space reclaimed
+lit convert --to <format>       Rewrite the issue file in format dgrl or yaml,
+                                setting issue-format
+lit lint [--fix] [<spec>]       Check issues (default: all) for missing or long
+                                summaries (summary-max), missing descriptions,
+                                stray whitespace, tags not in known-tags, values
+                                not in allowed-<key>, unknown users, and bad
+                                stamps, optionally fixing whitespace; changes
+                                warn of long summaries, and empty ones are
+                                taken from the description's first line
+                                unless the auto-summary config is off
+lit hook (install | run) pre-commit
+	Install a git pre-commit hook, or run it, rejecting commits of tracker
+	changes that fail fsck, or lint for the changed issues
+lit serve (--stdio | --http <addr> [--project <name>=<dir>...]
+          [(--tls <cert-file> <key-file> | --autocert <host>[,<host>...])
+          [--hsts <seconds>]])
+	Serve JSON-RPC requests on stdin/stdout, or POSTed to /rpc on addr,
+	requiring the LIT_TOKEN environment variable as a bearer token if set.
+	Confidential issues are served only on stdin/stdout, to the local user.
+	Over HTTP, Sentry and Rollbar webhooks POSTed to /webhook/sentry and
+	/webhook/rollbar (with the token as a token parameter) add issues by
+	error-fingerprint, counting repeats in occurrences.  With --project,
+	the tracker in each dir is served under /<name>/ instead, using the
+	LIT_TOKEN_<NAME> token if set.  Attachments may be fetched, in ranges,
+	with GET /attachments/<id>/<name>, and changes followed as server-sent
+	events from GET /events.  With --tls, HTTPS is served using the PEM
+	certificate and key files, or with --autocert, certificates for the
+	hosts from Let's Encrypt (answering its challenges on port 80, with the
+	acme-email config as the account contact), with an HSTS header (max
+	age default one year, 0 to omit).  If a tracker configures
+	oauth-userinfo, the userinfo endpoint of an OAuth2 or OpenID Connect
+	provider, its tokens are accepted too, and changes are made by the user
+	whose email-<user> config, or name in users, matches the token's
+	oauth-claim (default email).  If a tracker configures ldap-url, an
+	ldaps:// URL of an LDAP directory, and ldap-bind-dn, a DN with {user}
+	in place of the user's name, HTTP basic authentication is accepted
+	too, checked by binding to the directory as that DN, and changes are
+	made by the user of that name
+
+Executable .lit/hooks/pre-set and .lit/hooks/pre-close, if present, receive
+each proposed change as JSON on stdin and reject it by exiting unsuccessfully.
+
+List headers and field names in show output are translated by the catalog
+in .lit/locale/<lang> (e.g. "- summary: Zusammenfassung"), where lang is the
+language config or taken from LC_ALL, LC_MESSAGES, or LANG.
+
+Other commands run lit-<command> from the PATH, if found, with LIT_DIR and
+LIT_USER set in its environment.
+
+sort: (sortby|rsortby) <key>
+	Sort (reverse if rsortby) based on key
+
+page: [--limit <num>] [--offset <num> | --page <num>]
+	Show at most num issues, skipping offset issues or limit*(page-1)
+
+spec: open | closed | all | mine | <ids> |
+      (with | without | less | greater) <key> [<val>]
+	Specifies which issues to operate on ('mine' being those assigned to you)
+	Use 'comment' key to filter by comment contents and times
+	Use 'attach' key to filter by attachment names and counts
+	Use 'ack' key to filter by who acknowledged issues
+	Values of multi-value fields, like tags and assigned, match whole, so
+	'with tags bu' doesn't match bug (use bu.*), and users match by name
+	with or without @host, so 'with assigned bob' matches bob@host
+	Values of 'affects' and 'fixed-in' compare as versions, e.g. 1.10 > 1.9`
+
+const (
+	// id, closed?, priority, attached, CI status, assigned, tags, summary
+	listFmt = "%-*.*s %-1.1s %-1.1s %-1.1s %-1.1s %-8.8s %-15.15s %s"
+	// id, closed, priority, attachments, assigned, tags, summary
+	porcelainFmt = "%s\t%s\t%s\t%d\t%s\t%s\t%s"
+)
+
+func (st *state) run() {
+	if userEnv := os.Getenv("LIT_USER"); userEnv != "" {
+		st.username = userEnv
+	} else {
+		if user, err := user.Current(); err == nil {
+			if host, err := os.Hostname(); err == nil {
+				st.username = fmt.Sprintf("%s@%s", user.Username, host)
+			}
+		}
+	}
+
+globalOpts:
+	for len(st.args) > 0 {
+		switch st.args[0] {
+		case "-q", "--quiet":
+			st.verbosity = quiet
+		case "-v", "--verbose":
+			st.verbosity = verbose
+		case "--porcelain":
+			st.porcelain = true
+		case "--literal":
+			st.matchOpts.Literal = true
+		case "--no-cache":
+			st.it.DisableCache()
+		case "--relative":
+			st.relTime = true
+		case "--errors":
+			if len(st.args) < 2 || st.args[1] != "json" {
+				st.usagef("--errors requires a format (json)\n")
+			}
+			st.logger.SetPrefix("")
+			st.logger.SetOutput(newJSONErrors(st, st.stderr))
+			st.args = st.args[1:]
+		case "--remote":
+			if len(st.args) < 2 {
+				st.usagef("--remote requires a server URL\n")
+			}
+			st.remote = st.args[1]
+			st.args = st.args[1:]
+		case "--tz":
+			if len(st.args) < 2 {
+				st.usagef("--tz requires a time zone\n")
+			}
+			st.timeZone = st.args[1]
+			st.args = st.args[1:]
+		default:
+			break globalOpts
+		}
+		st.args = st.args[1:]
+	}
+
+	// append args piped in from stdin, unless stdin carries requests to serve
+	// or a panic trace
+	isServe := len(st.args) > 0 && st.args[0] == "serve"
+	isPanic := len(st.args) > 1 && st.args[0] == "new" && st.args[1] == "--from-panic"
+	if st.stdinIsPipe() && !isServe && !isPanic {
+		if piped, err := ioutil.ReadAll(st.stdin); err == nil {
+			st.args = append(st.args, strings.Fields(string(piped))...)
+		}
+	}
+	if len(st.args) > 0 {
+		st.cmd = st.args[0]
+		st.args = st.args[1:]
+	}
+	if st.remote == "" && mirrorWrites[st.cmd] {
+		st.remote = st.mirrorRemote()
+	}
+	if st.remote != "" {
+		st.remoteCmd()
+		return
+	}
+	switch st.cmd {
+	case "-h", "-help", "--help", "help":
+		st.usageCmd()
+	case "init":
+		st.initCmd()
+	case "new":
+		st.newCmd()
+	case "id":
+		st.idCmd()
+	case "list":
+		st.listCmd()
+	case "show":
+		st.showCmd()
+	case "board":
+		st.boardCmd()
+	case "open":
+		// otherwise, it's the open issues spec
+		if len(st.args) == 1 && st.it.Load() == nil && st.findIssue(st.args[0]) != nil {
+			st.openCmd()
+			return
+		}
+		st.cmd, st.args = "id", append([]string{st.cmd}, st.args...)
+		st.idCmd()
+	case "graph":
+		st.graphCmd()
+	case "search":
+		st.searchCmd()
+	case "count":
+		st.countCmd()
+	case "digest":
+		st.digestCmd()
+	case "stale":
+		st.staleCmd()
+	case "metrics":
+		st.metricsCmd()
+	case "triage":
+		st.triageCmd()
+	case "sla":
+		st.slaCmd()
+	case "policy":
+		st.policyCmd()
+	case "set":
+		st.setCmd()
+	case "unset":
+		st.unsetCmd()
+	case "assign":
+		st.assignCmd()
+	case "vote":
+		st.voteCmd()
+	case "ack":
+		st.ackCmd()
+	case "workflow":
+		st.workflowCmd()
+	case "check":
+		st.checkCmd()
+	case "review":
+		st.reviewCmd()
+	case "pin", "unpin":
+		st.pinCmd()
+	case "tag":
+		st.tagCmd()
+	case "add", "remove":
+		st.valuesCmd()
+	case "comment":
+		st.commentCmd()
+	case "desc":
+		st.descCmd()
+	case "draft":
+		st.draftCmd()
+	case "attach":
+		st.attachCmd()
+	case "alias":
+		st.aliasCmd()
+	case "release":
+		st.releaseCmd()
+	case "snapshot":
+		st.snapshotCmd()
+	case "security":
+		st.securityCmd()
+	case "estimate":
+		st.estimateCmd()
+	case "plan":
+		st.planCmd()
+	case "ci":
+		st.ciCmd()
+	case "workload":
+		st.workloadCmd()
+	case "critical-path":
+		st.criticalPathCmd()
+	case "export":
+		st.exportCmd()
+	case "import":
+		st.importCmd()
+	case "sync":
+		st.syncCmd()
+	case "mirror":
+		st.mirrorCmd()
+	case "fsck":
+		st.fsckCmd()
+	case "gc":
+		st.gcCmd()
+	case "convert":
+		st.convertCmd()
+	case "lint":
+		st.lintCmd()
+	case "hook":
+		st.hookCmd()
+	case "serve":
+		st.serveCmd()
+	case "merge":
+		st.mergeCmd()
+	case "edit":
+		st.editCmd()
+	case "close", "reopen":
+		st.closeCmd()
+	default:
+		if st.isSpec(st.cmd) {
+			st.cmd, st.args = "id", append([]string{st.cmd}, st.args...)
+			st.idCmd()
+			return
+		}
+		st.pluginCmd()
+	}
+}
+
+var idRe = regexp.MustCompile(`^[0-9a-fA-F-]+$`)
+
+// isFilter returns whether arg starts a filter, rather than naming issues, in
+// an issue spec.
+func isFilter(arg string) bool {
+	switch arg {
+	case "all", "open", "closed", "mine", "with", "without", "less", "greater":
+		return true
+	}
+	return false
+}
+
+// isSpec returns whether an unrecognized command is really the start of an
+// id command's arguments.
+func (st *state) isSpec(arg string) bool {
+	switch arg {
+	case "sortby", "rsortby", "--limit", "--offset", "--page":
+		return true
+	}
+	if isFilter(arg) {
+		return true
+	}
+	if idRe.MatchString(arg) {
+		return true
+	}
+	if err := st.it.Load(); err == nil {
+		return st.it.Issue(arg) != nil
+	}
+	return false
+}
+
+// pluginCmd runs lit-<cmd> from the PATH, git style, passing the remaining
+// arguments.  The tracker location and user are passed in the environment
+// as LIT_DIR and LIT_USER.
+func (st *state) pluginCmd() {
+	plugin, err := exec.LookPath("lit-" + st.cmd)
+	if err != nil {
+		st.fatalf("%s is not a lit command or issue id\n", st.cmd)
+	}
+	env := append(os.Environ(), "LIT_USER="+st.username)
+	if dir, err := st.it.TrackerDir(); err == nil {
+		env = append(env, "LIT_DIR="+dir)
+	}
+	if st.porcelain {
+		env = append(env, "LIT_PORCELAIN=1")
+	}
+	st.debugf("running plugin %s\n", plugin)
+	ext := exec.Command(plugin, st.args...)
+	ext.Env = env
+	ext.Stdin, ext.Stdout, ext.Stderr = st.stdin, st.stdout, st.stderr
+	if err := ext.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			exit(exitErr.ExitCode())
+		}
+		st.checkErr(err)
+	}
+}
+
+func (st *state) usageCmd() {
+	fmt.Fprintln(st.stdout, usage)
+}
+
+func (st *state) initCmd() {
+	idFormat, issueFormat := "", ""
+	for len(st.args) > 1 && strings.HasPrefix(st.args[0], "--") {
+		switch st.args[0] {
+		case "--ids":
+			idFormat = st.args[1]
+			if _, ok := lit.IDGenerators[idFormat]; !ok {
+				st.fatalf("init: unknown id format %s\n", idFormat)
+			}
+		case "--format":
+			issueFormat = st.args[1]
+			if !lit.IssueFormats[issueFormat] {
+				st.fatalf("init: unknown issue format %s\n", issueFormat)
+			}
+		default:
+			st.usagef("init: unknown option %s\n", st.args[0])
+		}
+		st.args = st.args[2:]
+	}
+	err := st.it.Init()
+	st.checkErr(err)
+	if idFormat != "" || issueFormat != "" {
+		st.loadIssues()
+		if idFormat != "" {
+			st.it.SetConfig("id-format", idFormat)
+		}
+		if issueFormat != "" {
+			st.checkErr(st.it.SetIssueFormat(issueFormat))
+		}
+		st.storeIssues()
+	}
+}
+
+func (st *state) convertCmd() {
+	if len(st.args) < 2 || st.args[0] != "--to" {
+		st.usagef("convert: you must specify --to <format>\n")
+	}
+	st.loadIssues()
+	st.checkErr(st.it.SetIssueFormat(st.args[1]))
+	st.storeIssues()
+}
+
+func (st *state) newCmd() {
+	if len(st.args) > 0 && st.args[0] == "--from-panic" {
+		st.newFromPanic()
+		return
+	}
+	tmplName, answers := "", map[string]string{}
+	for len(st.args) > 1 && strings.HasPrefix(st.args[0], "--") {
+		switch st.args[0] {
+		case "--template":
+			tmplName = st.args[1]
+		case "--answer":
+			qa := strings.SplitN(st.args[1], "=", 2)
+			if len(qa) < 2 {
+				st.fatalf("new: invalid answer %s\n", st.args[1])
+			}
+			answers[qa[0]] = qa[1]
+		default:
+			st.usagef("new: unknown option %s\n", st.args[0])
+		}
+		st.args = st.args[2:]
+	}
+	numIssues := 1
+	if len(st.args) > 0 {
+		num, err := strconv.ParseUint(st.args[0], 10, 16)
+		st.checkErr(err)
+		numIssues = int(num)
+	}
+	st.loadIssues()
+	var issues []*dgrl.Branch
+	if tmplName != "" {
+		var err error
+		issues, err = st.it.NewFromTemplate(st.username, tmplName, numIssues, func(question string) (string, error) {
+			if ans, ok := answers[question]; ok {
+				return ans, nil
+			}
+			return st.prompt(question + ": "), nil
+		})
+		st.checkErr(err)
+	} else {
+		issues = st.it.NewIssues(st.username, numIssues)
+	}
+	for _, issue := range issues {
+		fmt.Fprintln(st.stdout, issue.Key())
+	}
+	st.storeIssues()
+}
+
+// newFromPanic adds an issue for a Go panic trace read from stdin, or
+// comments on the existing issue for the same stack.
+func (st *state) newFromPanic() {
+	trace, err := ioutil.ReadAll(st.stdin)
+	st.checkErr(err)
+	st.loadIssues()
+	issue, isNew, err := st.it.NewFromPanic(st.username, string(trace))
+	st.checkErr(err)
+	if !isNew {
+		st.recordEvent(issue, "commented on", "Occurred again")
+		st.debugf("issue %s has the same stack\n", issue.Key())
+	}
+	fmt.Fprintln(st.stdout, issue.Key())
+	st.storeIssues()
+}
+
+func (st *state) ciCmd() {
+	if len(st.args) < 2 || st.args[0] != "report" {
+		st.usagef("ci: you must specify an operation (report) and issue\n")
+	}
+	id, status, url := st.args[1], "", ""
+	for st.args = st.args[2:]; len(st.args) > 1; st.args = st.args[2:] {
+		switch st.args[0] {
+		case "--status":
+			status = st.args[1]
+		case "--url":
+			url = st.args[1]
+		default:
+			st.usagef("ci: unknown option %s\n", st.args[0])
+		}
+	}
+	if status == "" {
+		st.usagef("ci: you must specify a --status\n")
+	}
+	st.loadIssues()
+	issue := st.findIssue(id)
+	if issue == nil {
+		st.fatalf("ci: %s\n", &lit.NotFoundError{ID: id})
+	}
+	st.checkErr(st.it.ReportCI(issue, st.username, status, url))
+	st.recordEvent(issue, "reported CI "+status+" for", url)
+	st.storeIssues()
+}
+
+func (st *state) idCmd() {
+	prefixed := false
+	if len(st.args) > 0 && st.args[0] == "--prefixed" {
+		prefixed, st.args = true, st.args[1:]
+	}
+	st.loadIssues()
+	doSort, key, doAscend := st.dispOpts()
+	offset, limit := st.pageOpts()
+	ids := st.specIds()
+	if doSort {
+		st.it.Sort(ids, key, doAscend)
+	}
+	ids = lit.Page(ids, offset, limit)
+	for _, id := range ids {
+		issue := st.findIssue(id)
+		if issue == nil {
+			continue
+		}
+		ref := issue.Key()
+		if prefixed {
+			ref = st.it.PrefixedID(issue)
+		}
+		fmt.Fprintln(st.stdout, ref)
+	}
+}
+
+// formatter returns the named formatter: table, porcelain, or one of
+// lit.Formatters.
+func (st *state) formatter(name string) (lit.Formatter, bool) {
+	switch name {
+	case "table":
+		return lit.FormatterFunc(func(issues []*dgrl.Branch) ([]byte, error) {
+			lines := []string{st.listHeader()}
+			for _, issue := range issues {
+				lines = append(lines, st.listInfo(issue))
+			}
+			return []byte(strings.Join(lines, "\n") + "\n"), nil
+		}), true
+	case "porcelain":
+		return lit.FormatterFunc(func(issues []*dgrl.Branch) ([]byte, error) {
+			out := ""
+			for _, issue := range issues {
+				out += st.porcelainInfo(issue) + "\n"
+			}
+			return []byte(out), nil
+		}), true
+	}
+	formatter, ok := lit.Formatters[name]
+	return formatter, ok
+}
+
+func (st *state) listCmd() {
+	format := "table"
+	if st.porcelain {
+		format = "porcelain"
+	}
+	if len(st.args) > 1 && st.args[0] == "--format" {
+		format, st.args = st.args[1], st.args[2:]
+	}
+	formatter, ok := st.formatter(format)
+	if !ok {
+		names := append(lit.FormatterNames(), "porcelain", "table")
+		sort.Strings(names)
+		st.fatalf("list: unknown format %s (%s)\n", format, strings.Join(names, ", "))
+	}
+	st.loadIssues()
+	doSort, key, doAscend := st.dispOpts()
+	offset, limit := st.pageOpts()
+	ids := st.specIds()
+	if doSort {
+		st.it.Sort(ids, key, doAscend)
+	}
+	st.it.Pinned(ids)
+	ids = lit.Page(ids, offset, limit)
+	issues := []*dgrl.Branch{}
+	for _, id := range ids {
+		if issue := st.findIssue(id); issue != nil {
+			issues = append(issues, issue)
+		}
+	}
+	out, err := formatter.Format(issues)
+	st.checkErr(err)
+	_, err = st.stdout.Write(out)
+	st.checkErr(err)
+}
+
+func (st *state) showCmd() {
+	copyWhat := ""
+	if len(st.args) > 0 && (st.args[0] == "--copy-url" || st.args[0] == "--copy-id") {
+		copyWhat, st.args = st.args[0], st.args[1:]
+	}
+	st.loadIssues()
+	doSort, key, doAscend := st.dispOpts()
+	offset, limit := st.pageOpts()
+	// Only issues named by id follow duplicate redirects; those found by a
+	// filter are shown as they are.
+	named := len(st.args) > 0 && !isFilter(st.args[0])
+	ids := st.specIds()
+	if doSort {
+		st.it.Sort(ids, key, doAscend)
+	}
+	ids = lit.Page(ids, offset, limit)
+	if copyWhat != "" {
+		st.copyRefs(ids, copyWhat == "--copy-url")
+		return
+	}
+	shown := map[string]bool{}
+	for _, id := range ids {
+		issue := st.findIssue(id)
+		if issue == nil {
+			st.warnf("show: %s\n", &lit.NotFoundError{ID: id})
+			continue
+		}
+		if dupOf, _ := lit.Get(issue, "duplicate-of"); named && dupOf != "" {
+			if canonical := st.findIssue(dupOf); canonical != nil {
+				st.warnf("show: issue %s is a duplicate of %s\n", issue.Key(), canonical.Key())
+				issue = canonical
+			}
+		}
+		if shown[issue.Key()] {
+			continue
+		}
+		shown[issue.Key()] = true
+		fmt.Fprintln(st.stdout, st.displayIssue(issue))
+		st.printAttachments(issue)
+		st.printBacklinks(issue)
+	}
+}
+
+// copyRefs places references to the given issues, ids or URLs, on the
+// clipboard, one per line.
+func (st *state) copyRefs(ids []string, asURL bool) {
+	refs := []string{}
+	for _, id := range ids {
+		issue := st.findIssue(id)
+		if issue == nil {
+			st.warnf("show: %s\n", &lit.NotFoundError{ID: id})
+			continue
+		}
+		ref := issue.Key()
+		if asURL {
+			url, err := st.it.IssueURL(issue)
+			st.checkErr(err)
+			ref = url
+		}
+		refs = append(refs, ref)
+	}
+	if len(refs) == 0 {
+		st.fatalln("show: nothing to copy")
+	}
+	st.checkErr(st.copyToClipboard(strings.Join(refs, "\n")))
+	for _, ref := range refs {
+		st.debugf("copied %s\n", ref)
+	}
+}
+
+func (st *state) openCmd() {
+	if len(st.args) < 1 {
+		st.usagef("open: you must specify an issue\n")
+	}
+	st.loadIssues()
+	issue := st.findIssue(st.args[0])
+	if issue == nil {
+		st.fatalf("open: %s\n", &lit.NotFoundError{ID: st.args[0]})
+	}
+	url, err := st.it.IssueURL(issue)
+	st.checkErr(err)
+	st.checkErr(st.openFile(url))
+}
+
+func (st *state) graphCmd() {
+	if len(st.args) > 0 && st.args[0] == "--format" {
+		if len(st.args) < 2 || st.args[1] != "dot" {
+			st.fatalln("graph: the only supported format is dot")
+		}
+		st.args = st.args[2:]
+	}
+	if len(st.args) == 0 {
+		st.args = []string{"open"}
+	}
+	st.loadIssues()
+	st.checkErr(st.it.WriteDot(st.stdout, st.specIds()))
+}
+
+func (st *state) searchCmd() {
+	engine := "lit"
+	if len(st.args) > 1 && st.args[0] == "--engine" {
+		engine, st.args = st.args[1], st.args[2:]
+	}
+	switch engine {
+	case "lit":
+		opts := st.searchOpts()
+		st.loadIssues()
+		st.printSearchResults(st.it.Search(strings.Join(st.args, " "), opts))
+	case "es":
+		if len(st.args) > 0 && st.args[0] == "--reindex" {
+			st.esReindex()
+			return
+		}
+		opts := st.searchOpts()
+		st.loadIssues()
+		results, err := st.it.ESSearch(strings.Join(st.args, " "), opts)
+		st.checkErr(err)
+		st.printSearchResults(results)
+	default:
+		st.fatalf("search: unknown engine %s (lit, es)\n", engine)
+	}
+}
+
+// esReindex adds all issues to the Elasticsearch index.
+func (st *state) esReindex() {
+	st.loadIssues()
+	for _, id := range st.it.IssueIds() {
+		if err := st.it.ESIndex(st.it.Issue(id)); err != nil {
+			st.fatalf("search: issue %s: %s\n", id, err)
+		}
+	}
+}
+
+func (st *state) searchOpts() lit.SearchOptions {
+	opts := lit.SearchOptions{}
+	for len(st.args) > 0 {
+		if st.args[0] == "--closed" {
+			opts.IncludeClosed = true
+			st.args = st.args[1:]
+			continue
+		}
+		if st.args[0] == "--in" && len(st.args) > 1 {
+			opts.Fields = strings.Split(st.args[1], ",")
+			st.args = st.args[2:]
+			continue
+		}
+		break
+	}
+	opts.Offset, opts.Limit = st.pageOpts()
+	if len(st.args) == 0 {
+		st.usagef("search: you must specify words to search for\n")
+	}
+	return opts
+}
+
+func (st *state) printSearchResults(results []lit.SearchResult) {
+	for _, res := range results {
+		if st.porcelain {
+			fmt.Fprintf(st.stdout, "%s\t%g\t%s\t%s\n", res.ID, res.Score, res.Field, res.Snippet)
+			continue
+		}
+		fmt.Fprintf(st.stdout, "%-*s %-11.11s %s\n", st.it.ShortLen(), st.it.ShortID(res.ID), res.Field, res.Snippet)
+	}
+}
+
+func (st *state) countCmd() {
+	key := ""
+	if len(st.args) > 0 && st.args[0] == "--by" {
+		if len(st.args) < 2 {
+			st.usagef("count: you must specify a key to count by\n")
+		}
+		key = st.args[1]
+		st.args = st.args[2:]
+	}
+	st.loadIssues()
+	ids := []string{}
+	for _, id := range st.specIds() {
+		if issue := st.findIssue(id); issue != nil {
+			ids = append(ids, issue.Key())
+		}
+	}
+	if key == "" {
+		fmt.Fprintln(st.stdout, len(ids))
+		return
+	}
+	counts := st.it.Count(ids, key)
+	vals := make([]string, 0, len(counts))
+	for val := range counts {
+		vals = append(vals, val)
+	}
+	sort.Slice(vals, func(i, j int) bool {
+		if counts[vals[i]] != counts[vals[j]] {
+			return counts[vals[i]] > counts[vals[j]]
+		}
+		return vals[i] < vals[j]
+	})
+	for _, val := range vals {
+		count := counts[val]
+		if val == "" {
+			val = "-"
+		}
+		fmt.Fprintf(st.stdout, "%6d %s\n", count, val)
+	}
+}
+
+func (st *state) staleCmd() {
+	days := 30
+	if len(st.args) > 0 && st.args[0] == "--days" {
+		if len(st.args) < 2 {
+			st.usagef("stale: --days requires a number\n")
+		}
+		num, err := strconv.ParseUint(st.args[1], 10, 16)
+		st.checkErr(err)
+		days = int(num)
+		st.args = st.args[2:]
+	}
+	if len(st.args) == 0 {
+		st.args = []string{"open"}
+	}
+	st.loadIssues()
+	age := time.Duration(days) * 24 * time.Hour
+	stale := st.it.Stale(st.specIds(), age, st.it.Now())
+	if len(stale) == 0 {
+		return
+	}
+	fmt.Fprintln(st.stdout, st.listHeader())
+	for _, id := range stale {
+		fmt.Fprintln(st.stdout, st.listInfo(st.findIssue(id)))
+	}
+}
+
+func (st *state) slaCmd() {
+	if len(st.args) < 1 || st.args[0] != "check" {
+		st.usagef("sla: you must specify an operation (check)\n")
+	}
+	st.args = st.args[1:]
+	if len(st.args) == 0 {
+		st.args = []string{"open"}
+	}
+	st.loadIssues()
+	violations, err := st.it.SLAViolations(st.specIds(), st.it.Now())
+	st.checkErr(err)
+	for _, v := range violations {
+		fmt.Fprintf(st.stdout, "%s priority %s not updated in %s (limit %s)\n", v.ID, v.Priority,
+			fmtAge(v.Age), fmtAge(v.Limit))
+	}
+	if len(violations) > 0 {
+		exit(1)
+	}
+}
+
+// parseSince parses a time, or an age like "2w" meaning that long ago.
+func (st *state) parseSince(val string) time.Time {
+	if age, err := lit.ParseAge(val); err == nil {
+		return st.it.Now().Add(-age)
+	}
+	t, _, err := lit.ParseStamp(val)
+	if err != nil {
+		st.fatalf("%s: invalid time or age %s\n", st.cmd, val)
+	}
+	return t
+}
+
+func (st *state) policyCmd() {
+	if len(st.args) < 1 || st.args[0] != "run" {
+		st.usagef("policy: you must specify an operation (run)\n")
+	}
+	dryRun := len(st.args) > 1 && st.args[1] == "--dry-run"
+	st.loadIssues()
+	actions, err := st.it.RunPolicies(st.it.Now(), st.username, dryRun)
+	st.checkErr(err)
+	for _, a := range actions {
+		fmt.Fprintf(st.stdout, "%s %s by policy %s\n", a.ID, a.Action, a.Policy)
+		if a.Action == "closed" && !dryRun {
+			st.recordEvent(st.findIssue(a.ID), "closed", "by policy "+a.Policy)
+		}
+	}
+	if len(actions) > 0 && !dryRun {
+		st.storeIssues()
+	}
+}
+
+// fmtAge formats a duration in days, or hours if less than a day.
+func fmtAge(age time.Duration) string {
+	if age < 24*time.Hour {
+		return fmt.Sprintf("%dh", int(age.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(age.Hours()/24))
+}
+
+// fmtStamp renders a stamp for display, in the zone and format given by the
+// --tz and --relative options or the time-zone and time-format config.
+func (st *state) fmtStamp(stamp string) string {
+	zone, relative := st.timeZone, st.relTime
+	if zone == "" {
+		zone, _ = st.it.Config("time-zone")
+	}
+	if format, _ := st.it.Config("time-format"); format == "relative" {
+		relative = true
+	}
+	if zone == "" && !relative {
+		return stamp
+	}
+	loc := time.UTC
+	switch zone {
+	case "", "UTC":
+	case "local":
+		loc = time.Local
+	default:
+		var err error
+		if loc, err = time.LoadLocation(zone); err != nil {
+			st.fatalf("invalid time zone %s\n", zone)
+		}
+	}
+	return lit.FormatStamp(stamp, loc, relative, st.it.Now())
+}
+
+// displayIssue returns a copy of issue with its stamps formatted for display.
+// Only the created, updated, and closed stamps, date fields, and comment
+// headers are stamps; other values are shown as they are, even if they begin
+// with a date.
+func (st *state) displayIssue(issue *dgrl.Branch) *dgrl.Branch {
+	disp := dgrl.NewBranch(issue.Key())
+	for _, k := range issue.Kids() {
+		switch node := k.(type) {
+		case *dgrl.Leaf:
+			if node.Key() != "" && node.Type() == dgrl.LeafType {
+				val := node.Value()
+				if lit.IsReserved(node.Key()) || st.it.IsDate(node.Key()) {
+					val = st.fmtStamp(val)
+				}
+				k = dgrl.NewLeaf(st.tr(node.Key()), val)
+			} else if node.Key() != "" {
+				k = dgrl.NewLongLeaf(st.tr(node.Key()), node.Value())
+			}
+		case *dgrl.Branch:
+			comment := dgrl.NewBranch(st.fmtStamp(node.Key()))
+			for _, kk := range node.Kids() {
+				comment.Append(kk)
+			}
+			k = comment
+		}
+		disp.Append(k)
+	}
+	return disp
+}
+
+func (st *state) setCmd() {
+	doExact, doForce := false, false
+	for len(st.args) > 0 && strings.HasPrefix(st.args[0], "--") {
+		switch st.args[0] {
+		case "--exact":
+			doExact = true
+		case "--force":
+			doExact, doForce = true, true
+		default:
+			st.usagef("set: unknown option %s\n", st.args[0])
+		}
+		st.args = st.args[1:]
+	}
+	if len(st.args) < 2 {
+		st.usagef("set: you must specify a key and value\n")
+	}
+	key, val := st.args[0], st.args[1]
+	st.args = st.args[2:]
+	if val == "--from-file" {
+		if len(st.args) < 1 {
+			st.usagef("set: --from-file requires a file\n")
+		}
+		data, err := ioutil.ReadFile(st.args[0])
+		st.checkErr(err)
+		val = strings.TrimRight(string(data), "\n")
+		st.args = st.args[1:]
+	}
+	st.loadIssues()
+	_, err := st.it.Workflow()
+	st.checkErr(err)
+	for _, id := range st.specIds() {
+		issue := st.findIssue(id)
+		if issue == nil {
+			st.warnf("set: %s\n", &lit.NotFoundError{ID: id})
+			continue
+		}
+		fieldKey := key
+		if !doExact {
+			var err error
+			if fieldKey, err = lit.ResolveKey(issue, key); err != nil {
+				st.warnf("set: issue %s: %s\n", id, err)
+				continue
+			}
+		}
+		if lit.IsReserved(fieldKey) && !doForce {
+			st.warnf("set: %s (use --force)\n", &lit.ReservedError{ID: id, Key: fieldKey})
+			continue
+		}
+		if err := st.it.SetField(issue, st.username, fieldKey, val, doForce); err != nil {
+			st.warnf("set: %s\n", err)
+		}
+	}
+	st.storeIssues()
+}
+
+func (st *state) unsetCmd() {
+	if len(st.args) < 1 {
+		st.usagef("unset: you must specify a key\n")
+	}
+	key := st.args[0]
+	st.args = st.args[1:]
+	st.loadIssues()
+	stamp := st.it.Stamp(st.username)
+	for _, id := range st.specIds() {
+		issue := st.findIssue(id)
+		if issue == nil {
+			st.warnf("unset: %s\n", &lit.NotFoundError{ID: id})
+			continue
+		}
+		fieldKey, err := lit.ResolveKey(issue, key)
+		if err != nil {
+			st.warnf("unset: issue %s: %s\n", id, err)
+			continue
+		}
+		if lit.IsReserved(fieldKey) {
+			st.warnf("unset: %s\n", &lit.ReservedError{ID: id, Key: fieldKey})
+			continue
+		}
+		if !lit.Unset(issue, fieldKey) {
+			st.warnf("unset: %s\n", &lit.NotFoundError{ID: id, Key: key})
+			continue
+		}
+		if !lit.Touch(issue, stamp) {
+			st.warnf("unset: error setting update time for issue %s\n", id)
+		}
+		st.recordEvent(issue, "unset "+key+" in", "")
+	}
+	st.storeIssues()
+}
+
+func (st *state) assignCmd() {
+	if len(st.args) < 1 {
+		st.usagef("assign: you must specify a user\n")
+	}
+	mode := st.args[0]
+	st.loadIssues()
+	users := []string{}
+	switch mode {
+	case "--round-robin", "--random":
+		if len(st.args) < 2 {
+			st.usagef("assign: %s requires a list of users or a team\n", mode)
+		}
+		users = st.it.Team(st.args[1])
+		st.args = st.args[2:]
+	case "--add", "--remove":
+		if len(st.args) < 2 {
+			st.usagef("assign: %s requires a user\n", mode)
+		}
+		users = []string{st.args[1]}
+		st.args = st.args[2:]
+	default:
+		users = []string{mode}
+		st.args = st.args[1:]
+	}
+	if len(users) == 0 {
+		st.fatalln("assign: no users to assign to")
+	}
+	for _, user := range users {
+		if mode != "--remove" && !st.it.ValidUser(user) {
+			st.fatalf("assign: %s is not a configured user\n", user)
+		}
+	}
+
+	stamp := st.it.Stamp(st.username)
+	for _, id := range st.specIds() {
+		issue := st.findIssue(id)
+		if issue == nil {
+			st.warnf("assign: %s\n", &lit.NotFoundError{ID: id})
+			continue
+		}
+		user := users[0]
+		switch mode {
+		case "--round-robin":
+			user = st.it.Rotate(users)
+		case "--random":
+			user = lit.RandomUser(users)
+		}
+		assigned := user
+		if mode == "--add" || mode == "--remove" {
+			assigned, _ = lit.Get(issue, "assigned")
+			assigned = lit.ModifyValueStr(assigned, user, mode == "--add")
+		}
+		if err := st.it.RunHook("pre-set", lit.NewChange(issue, st.username, "assigned", assigned)); err != nil {
+			st.warnf("assign: issue %s: %s\n", id, err)
+			continue
+		}
+		ok := lit.Set(issue, "assigned", assigned)
+		ok = ok && lit.Touch(issue, stamp)
+		if !ok {
+			st.warnf("assign: error updating fields in issue %s\n", id)
+			continue
+		}
+		if mode == "--remove" {
+			st.recordEvent(issue, "unassigned "+user+" from", "")
+		} else {
+			st.recordEvent(issue, "assigned "+user+" to", "")
+		}
+		fmt.Fprintf(st.stdout, "%s %s\n", issue.Key(), assigned)
+	}
+	st.storeIssues()
+}
+
+func (st *state) checkCmd() {
+	if len(st.args) < 1 {
+		st.usagef("check: you must specify an issue\n")
+	}
+	st.loadIssues()
+	issue := st.findIssue(st.args[0])
+	if issue == nil {
+		st.fatalf("check: %s\n", &lit.NotFoundError{ID: st.args[0]})
+	}
+	if len(st.args) == 1 {
+		for i, item := range lit.Checklist(issue) {
+			mark := " "
+			if item.Done {
+				mark = "x"
+			}
+			fmt.Fprintf(st.stdout, "%2d [%s] %s\n", i+1, mark, item.Text)
+		}
+		return
+	}
+	for _, arg := range st.args[1:] {
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			st.warnf("check: invalid item number %s\n", arg)
+			continue
+		}
+		done, err := lit.ToggleCheck(issue, n)
+		if err != nil {
+			st.warnf("check: issue %s: %s\n", st.args[0], err)
+			continue
+		}
+		action := "unchecked"
+		if done {
+			action = "checked"
+		}
+		st.recordEvent(issue, action+" item "+arg+" of", "")
+	}
+	if !lit.Touch(issue, st.it.Stamp(st.username)) {
+		st.warnf("check: error setting update time for issue %s\n", st.args[0])
+	}
+	st.storeIssues()
+}
+
+func (st *state) voteCmd() {
+	doVote := true
+	if len(st.args) > 0 && st.args[0] == "--retract" {
+		doVote = false
+		st.args = st.args[1:]
+	}
+	st.loadIssues()
+	stamp := st.it.Stamp(st.username)
+	for _, id := range st.specIds() {
+		issue := st.findIssue(id)
+		if issue == nil {
+			st.warnf("vote: %s\n", &lit.NotFoundError{ID: id})
+			continue
+		}
+		if !lit.Vote(issue, st.username, doVote) {
+			if doVote {
+				st.warnf("vote: already voted for issue %s\n", id)
+			} else {
+				st.warnf("vote: no vote to retract for issue %s\n", id)
+			}
+			continue
+		}
+		if !lit.Touch(issue, stamp) {
+			st.warnf("vote: error setting update time for issue %s\n", id)
+		}
+	}
+	st.storeIssues()
+}
+
+func (st *state) workflowCmd() {
+	st.loadIssues()
+	wf, err := st.it.Workflow()
+	st.checkErr(err)
+	if wf == nil {
+		st.fatalln("workflow: no workflow is configured (workflow-states)")
+	}
+	if len(st.args) == 0 {
+		for _, state := range wf.States {
+			closed := ""
+			if wf.Closed[state] {
+				closed = " (closed)"
+			}
+			fmt.Fprintf(st.stdout, "%s%s -> %s\n", state, closed, strings.Join(wf.Next[state], " "))
+		}
+		return
+	}
+	for _, id := range st.specIds() {
+		issue := st.findIssue(id)
+		if issue == nil {
+			st.warnf("workflow: %s\n", &lit.NotFoundError{ID: id})
+			continue
+		}
+		fmt.Fprintf(st.stdout, "%-*s %s -> %s\n", st.it.ShortLen(), st.it.ShortID(issue.Key()), wf.State(issue), strings.Join(wf.NextStates(issue), " "))
+	}
+}
+
+func (st *state) ackCmd() {
+	if len(st.args) < 1 {
+		st.usagef("ack: you must specify issues\n")
+	}
+	st.loadIssues()
+	for _, id := range st.specIds() {
+		issue := st.findIssue(id)
+		if issue == nil {
+			st.warnf("ack: %s\n", &lit.NotFoundError{ID: id})
+			continue
+		}
+		acked, err := st.it.Acknowledge(issue, st.username)
+		if err != nil {
+			st.warnf("ack: %s\n", err)
+			continue
+		}
+		if !acked {
+			st.warnf("ack: already acknowledged issue %s\n", id)
+			continue
+		}
+		st.recordEvent(issue, "acknowledged", "")
+	}
+	st.storeIssues()
+}
+
+func (st *state) reviewCmd() {
+	if len(st.args) < 2 {
+		st.usagef("review: you must specify an operation (request, approve, reject) and issue\n")
+	}
+	op, id := st.args[0], st.args[1]
+	st.args = st.args[2:]
+	st.loadIssues()
+	issue := st.findIssue(id)
+	if issue == nil {
+		st.fatalf("review: %s\n", &lit.NotFoundError{ID: id})
+	}
+	switch op {
+	case "request":
+		if len(st.args) < 1 {
+			st.usagef("review: you must specify reviewers\n")
+		}
+		for _, reviewer := range st.args {
+			if !lit.RequestReview(issue, reviewer) {
+				st.warnf("review: %s is already a reviewer of issue %s\n", reviewer, id)
+				continue
+			}
+			st.recordEvent(issue, "requested review of", reviewer)
+		}
+	case "approve", "reject":
+		comment := strings.Join(st.args, " ")
+		if !st.it.Review(issue, st.username, op == "approve", comment) {
+			st.fatalf("review: error updating fields for issue %s\n", id)
+		}
+		st.recordEvent(issue, op+"d", comment)
+	default:
+		st.fatalf("review: unknown operation %s\n", op)
+	}
+	if !lit.Touch(issue, st.it.Stamp(st.username)) {
+		st.warnf("review: error setting update time for issue %s\n", id)
+	}
+	st.storeIssues()
+}
+
+func (st *state) pinCmd() {
+	if len(st.args) < 1 {
+		st.usagef("%s: you must specify an issue\n", st.cmd)
+	}
+	id := st.args[0]
+	st.loadIssues()
+	issue := st.findIssue(id)
+	if issue == nil {
+		st.fatalf("%s: %s\n", st.cmd, &lit.NotFoundError{ID: id})
+	}
+	if st.cmd == "unpin" {
+		if !st.it.Unpin(issue) {
+			st.fatalf("unpin: issue %s is not pinned\n", id)
+		}
+		st.storeIssues()
+		return
+	}
+	rank := 0
+	if len(st.args) > 1 {
+		r, err := strconv.Atoi(st.args[1])
+		st.checkErr(err)
+		rank = r
+	}
+	if !st.it.Pin(issue, rank) {
+		st.fatalf("pin: error pinning issue %s\n", id)
+	}
+	st.storeIssues()
+}
+
+func (st *state) tagCmd() {
+	if len(st.args) < 2 {
+		st.usagef("tag: you must specify an operation and tag\n")
+	}
+	op, tag := st.args[0], st.args[1]
+	if op != "add" && op != "del" {
+		st.usagef("tag: %s is not a valid operation\n", op)
+	}
+	st.args = st.args[2:]
+	doAdd := (op == "add")
+
+	st.loadIssues()
+	stamp := st.it.Stamp(st.username)
+	for _, id := range st.specIds() {
+		issue := st.findIssue(id)
+		if issue == nil {
+			st.warnf("tag: %s\n", &lit.NotFoundError{ID: id})
+			continue
+		}
+		ok := lit.ModifyTag(issue, tag, doAdd)
+		ok = ok && lit.Touch(issue, stamp)
+		if !ok {
+			st.warnf("tag: error updating fields in issue %s\n", id)
+			continue
+		}
+		if doAdd {
+			st.recordEvent(issue, "added tag "+tag+" to", "")
+		} else {
+			st.recordEvent(issue, "removed tag "+tag+" from", "")
+		}
+	}
+	st.storeIssues()
+}
+
+func (st *state) valuesCmd() {
+	if len(st.args) < 2 {
+		st.usagef("%s: you must specify a key and value\n", st.cmd)
+	}
+	key, val := st.args[0], st.args[1]
+	st.args = st.args[2:]
+	doAdd := (st.cmd == "add")
+
+	st.loadIssues()
+	stamp := st.it.Stamp(st.username)
+	for _, id := range st.specIds() {
+		issue := st.findIssue(id)
+		if issue == nil {
+			st.warnf("%s: %s\n", st.cmd, &lit.NotFoundError{ID: id})
+			continue
+		}
+		if fieldKey, _ := lit.ResolveKey(issue, key); fieldKey == "assigned" && doAdd && !st.it.ValidUser(val) {
+			st.fatalf("add: %s is not a configured user\n", val)
+		}
+		ok := lit.ModifyValues(issue, key, val, doAdd)
+		ok = ok && lit.Touch(issue, stamp)
+		if !ok {
+			st.warnf("%s: error updating fields in issue %s\n", st.cmd, id)
+			continue
+		}
+		if doAdd {
+			st.recordEvent(issue, "added "+val+" to "+key+" in", "")
+		} else {
+			st.recordEvent(issue, "removed "+val+" from "+key+" in", "")
+		}
+	}
+	st.storeIssues()
+}
+
+func (st *state) commentCmd() {
+	if len(st.args) < 1 {
+		st.usagef("comment: you must specify an issue\n")
+	}
+	id := st.args[0]
+	st.loadIssues()
+	issue := st.findIssue(id)
+	if issue == nil {
+		st.fatalf("comment: %s\n", &lit.NotFoundError{ID: id})
+	}
+	comment := ""
+	if len(st.args) > 1 {
+		comment = st.args[1]
+	} else {
+		comment = st.editComment()
+	}
+	if _, err := st.it.Comment(issue, st.username, comment); err != nil {
+		st.warnf("comment: %s\n", err)
+	}
+	st.storeIssues()
+}
+
+func (st *state) descCmd() {
+	if len(st.args) < 1 {
+		st.usagef("desc: you must specify an issue\n")
+	}
+	id := st.args[0]
+	st.loadIssues()
+	issue := st.findIssue(id)
+	if issue == nil {
+		st.fatalf("desc: %s\n", &lit.NotFoundError{ID: id})
+	}
+	desc, _ := lit.Get(issue, "description")
+	if len(st.args) < 2 {
+		fmt.Fprintln(st.stdout, desc)
+		return
+	}
+	if len(st.args) < 3 {
+		st.usagef("desc: %s requires an argument\n", st.args[1])
+	}
+	switch st.args[1] {
+	case "--set-file":
+		data, err := ioutil.ReadFile(st.args[2])
+		st.checkErr(err)
+		desc = strings.TrimRight(string(data), "\n")
+	case "--append":
+		if desc != "" {
+			desc += "\n"
+		}
+		desc += st.args[2]
+	default:
+		st.usagef("desc: unknown option %s\n", st.args[1])
+	}
+	if err := st.it.RunHook("pre-set", lit.NewChange(issue, st.username, "description", desc)); err != nil {
+		st.fatalf("desc: issue %s: %s\n", id, err)
+	}
+	if !lit.SetLong(issue, "description", desc) || !lit.Touch(issue, st.it.Stamp(st.username)) {
+		st.fatalf("desc: error updating fields in issue %s\n", id)
+	}
+	st.recordEvent(issue, "updated description of", desc)
+	st.storeIssues()
+}
+
+func (st *state) editComment() string {
+	editor := getEditor()
+	if editor == "" {
+		st.fatalf("%s: VISUAL or EDITOR environment variable must be set\n", st.cmd)
+	}
+	// create temp file
+	tempFile, err := ioutil.TempFile("", "lit-")
+	st.checkErr(err)
+	filename := tempFile.Name()
+
+	// get original file state
+	origStat, err := os.Stat(filename)
+	st.checkErr(err)
+
+	// launch editor
+	ed := exec.Command(editor, filename)
+	ed.Stdin, ed.Stdout, ed.Stderr = st.stdin, st.stdout, st.stderr
+	err = ed.Run()
+	st.checkErr(err)
+
+	// get updated file state, compare to original
+	newStat, err := os.Stat(filename)
+	st.checkErr(err)
+	if newStat.ModTime() == origStat.ModTime() {
+		st.fatalf("%s: file unchanged", st.cmd)
+	}
+
+	// read comment from file
+	commentData, err := ioutil.ReadFile(filename)
+	st.checkErr(err)
+	return string(commentData)
+}
+
+func (st *state) attachCmd() {
+	if len(st.args) < 1 {
+		st.usagef("attach: you must specify an operation\n")
+	}
+	op := st.args[0]
+	switch op {
+	case "add":
+		st.addAttach()
+	case "list":
+		st.listAttach()
+	case "show":
+		st.showAttach()
+	case "du":
+		st.duAttach()
+	default:
+		st.usagef("attach: %s is not a valid operation\n", op)
+	}
+}
+
+// duAttach summarizes the attachment storage of issues, exiting with status 1
+// if any are over the attachment quota.
+func (st *state) duAttach() {
+	st.args = st.args[1:]
+	if len(st.args) == 0 {
+		st.args = []string{"all"}
+	}
+	st.loadIssues()
+	usage, err := st.it.AttachUsage(st.specIds())
+	st.checkErr(err)
+	quota, _ := st.it.AttachQuota()
+	total, numFiles, numOver := int64(0), 0, 0
+	for _, u := range usage {
+		flag := ""
+		if u.Over {
+			flag = " (over quota)"
+			numOver++
+		}
+		summary, _ := lit.Get(st.findIssue(u.ID), "summary")
+		fmt.Fprintf(st.stdout, "%-*s %8s %4d %s%s\n", st.it.ShortLen(), st.it.ShortID(u.ID), lit.FormatSize(u.Bytes), u.Files, summary, flag)
+		total += u.Bytes
+		numFiles += u.Files
+	}
+	fmt.Fprintf(st.stdout, "%-8s %8s %4d\n", "total", lit.FormatSize(total), numFiles)
+	if numOver > 0 {
+		st.warnf("attach: %d issue(s) over the attachment quota of %s\n", numOver, lit.FormatSize(quota))
+		exit(1)
+	}
+}
+
+func (st *state) addAttach() {
+	doRecurse, doZip, comment, hasComment := false, false, "", false
+	params := []string{}
+	for i := 1; i < len(st.args); i++ {
+		switch st.args[i] {
+		case "--recursive", "-r":
+			doRecurse = true
+		case "--zip":
+			doZip = true
+		case "-m":
+			if i+1 >= len(st.args) {
+				st.usagef("attach: -m requires a description\n")
+			}
+			i++
+			comment, hasComment = st.args[i], true
+		default:
+			params = append(params, st.args[i])
+		}
+	}
+	if len(params) < 2 {
+		st.usagef("attach: you must specify an issue and file\n")
+	}
+	id := params[0]
+	st.loadIssues()
+	issue := st.findIssue(id)
+	if issue == nil {
+		st.fatalf("attach: %s\n", &lit.NotFoundError{ID: id})
+	}
+
+	// for compatibility, a trailing argument that isn't a file is the description
+	paths := params[1:]
+	if last := paths[len(paths)-1]; !hasComment && len(paths) > 1 {
+		if _, err := os.Stat(last); os.IsNotExist(err) {
+			comment, hasComment = last, true
+			paths = paths[:len(paths)-1]
+		}
+	}
+
+	srcs := []string{}
+	for _, src := range paths {
+		info, err := os.Stat(src)
+		st.checkErr(err)
+		switch {
+		case !info.IsDir():
+			srcs = append(srcs, src)
+		case doZip:
+			zipFile, err := zipDir(src)
+			st.checkErr(err)
+			defer os.RemoveAll(filepath.Dir(zipFile))
+			srcs = append(srcs, zipFile)
+		case doRecurse:
+			err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+				if err == nil && info.Mode().IsRegular() {
+					srcs = append(srcs, path)
+				}
+				return err
+			})
+			st.checkErr(err)
+		default:
+			st.fatalf("attach: %s is a directory (use --recursive or --zip)\n", src)
+		}
+	}
+
+	if !hasComment {
+		comment = st.editComment()
+	}
+
+	stamp, err := st.it.AttachFiles(issue, srcs, st.username, comment)
+	st.checkErr(err)
+	if !lit.Touch(issue, stamp) {
+		st.warnf("attach: error setting update time for issue %s\n", id)
+	}
+	names := []string{}
+	for _, src := range srcs {
+		names = append(names, filepath.Base(src))
+	}
+	st.recordEvent(issue, "attached "+strings.Join(names, ", ")+" to", comment)
+	st.storeIssues()
+}
+
+// zipDir writes the contents of a directory to a zip file, named for the
+// directory, in a new temporary directory.
+func zipDir(dir string) (string, error) {
+	tempDir, err := ioutil.TempDir("", "lit-")
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Clean(dir)
+	zipName := filepath.Join(tempDir, filepath.Base(dir)+".zip")
+	zipFile, err := os.Create(zipName)
+	if err != nil {
+		return "", err
+	}
+	defer zipFile.Close()
+	zw := zip.NewWriter(zipFile)
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		w, err := zw.Create(filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(w, file)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return zipName, zw.Close()
+}
+
+func (st *state) listAttach() {
+	if len(st.args) < 2 {
+		st.usagef("attach: you must specify an issue\n")
+	}
+	id := st.args[1]
+	st.loadIssues()
+	issue := st.findIssue(id)
+	if issue == nil {
+		st.fatalf("attach: %s\n", &lit.NotFoundError{ID: id})
+	}
+	for _, filename := range st.it.AttachmentNames(issue) {
+		fmt.Fprintln(st.stdout, filename)
+	}
+}
+
+func (st *state) showAttach() {
+	doOpen := false
+	if len(st.args) > 1 && st.args[1] == "--open" {
+		doOpen = true
+		st.args = append(st.args[:1], st.args[2:]...)
+	}
+	if len(st.args) < 3 {
+		st.usagef("attach: you must specify an issue and file\n")
+	}
+	id := st.args[1]
+	st.loadIssues()
+	issue := st.findIssue(id)
+	if issue == nil {
+		st.fatalf("attach: %s\n", &lit.NotFoundError{ID: id})
+	}
+	attachment, err := st.it.GetAttachment(issue, st.args[2])
+	st.checkErr(err)
+	defer attachment.Close()
+	if doOpen {
+		err = st.openFile(filepath.Join(st.it.IssueDir(issue), st.args[2]))
+		st.checkErr(err)
+		return
+	}
+	_, err = io.Copy(st.stdout, attachment)
+	st.checkErr(err)
+}
+
+// copyToClipboard places text on the system clipboard using the platform's
+// clipboard utility.
+func (st *state) copyToClipboard(text string) error {
+	var copier *exec.Cmd
+	switch {
+	case runtime.GOOS == "darwin":
+		copier = exec.Command("pbcopy")
+	case runtime.GOOS == "windows":
+		copier = exec.Command("clip")
+	case os.Getenv("WAYLAND_DISPLAY") != "":
+		copier = exec.Command("wl-copy")
+	default:
+		copier = exec.Command("xclip", "-selection", "clipboard")
+	}
+	copier.Stdin = strings.NewReader(text)
+	copier.Stderr = st.stderr
+	return copier.Run()
+}
+
+// openFile opens a file in the desktop's default application.
+func (st *state) openFile(filename string) error {
+	opener := exec.Command("xdg-open", filename)
+	switch runtime.GOOS {
+	case "darwin":
+		opener = exec.Command("open", filename)
+	case "windows":
+		opener = exec.Command("cmd", "/c", "start", "", filename)
+	}
+	opener.Stdout, opener.Stderr = st.stdout, st.stderr
+	return opener.Run()
+}
+
+// printAttachments lists an issue's attachments with their size, type, and
+// when they were added.
+func (st *state) printAttachments(issue *dgrl.Branch) {
+	atts := st.it.Attachments(issue)
+	if len(atts) == 0 {
+		return
+	}
+	fmt.Fprintln(st.stdout, st.tr("attachments")+":")
+	for _, att := range atts {
+		fmt.Fprintf(st.stdout, "  %-24s %8d %-24s %s\n", att.Name, att.Size, att.Type, st.fmtStamp(att.Added))
+	}
+}
+
+func (st *state) printBacklinks(issue *dgrl.Branch) {
+	backlinks := st.it.Backlinks(issue)
+	switch len(backlinks) {
+	case 0:
+		return
+	case 1:
+		fmt.Fprint(st.stdout, "referenced by 1 issue:")
+	default:
+		fmt.Fprintf(st.stdout, "referenced by %d issues:", len(backlinks))
+	}
+	for _, id := range backlinks {
+		fmt.Fprintf(st.stdout, " %s", st.it.ShortID(id))
+	}
+	fmt.Fprintln(st.stdout)
+}
+
+func (st *state) aliasCmd() {
+	if len(st.args) < 1 {
+		st.usagef("alias: you must specify an operation\n")
+	}
+	op := st.args[0]
+	st.loadIssues()
+	switch op {
+	case "add":
+		if len(st.args) < 3 {
+			st.usagef("alias: you must specify an alias and issue\n")
+		}
+		err := st.it.AddAlias(st.args[1], st.args[2])
+		st.checkErr(err)
+		st.storeIssues()
+	case "del":
+		if len(st.args) < 2 {
+			st.usagef("alias: you must specify an alias\n")
+		}
+		err := st.it.DelAlias(st.args[1])
+		st.checkErr(err)
+		st.storeIssues()
+	case "list":
+		for _, alias := range st.it.AliasNames() {
+			id, _ := st.it.Alias(alias)
+			fmt.Fprintf(st.stdout, "%s %s\n", alias, id)
+		}
+	default:
+		st.usagef("alias: %s is not a valid operation\n", op)
+	}
+}
+
+func (st *state) snapshotCmd() {
+	if len(st.args) < 1 {
+		st.usagef("snapshot: you must specify an operation (create, list, diff)\n")
+	}
+	op := st.args[0]
+	st.args = st.args[1:]
+	st.loadIssues()
+	switch op {
+	case "create":
+		if len(st.args) < 1 {
+			st.usagef("snapshot: you must specify a name\n")
+		}
+		st.checkErr(st.it.CreateSnapshot(st.args[0]))
+	case "list":
+		names, err := st.it.Snapshots()
+		st.checkErr(err)
+		for _, name := range names {
+			fmt.Fprintln(st.stdout, name)
+		}
+	case "diff":
+		if len(st.args) < 1 {
+			st.usagef("snapshot: you must specify a snapshot to compare\n")
+		}
+		to := ""
+		if len(st.args) > 1 {
+			to = st.args[1]
+		}
+		diff, err := st.it.DiffSnapshots(st.args[0], to)
+		st.checkErr(err)
+		st.printSnapshotDiff(diff)
+	default:
+		st.fatalf("snapshot: unknown operation %s\n", op)
+	}
+}
+
+func (st *state) printSnapshotDiff(diff *lit.SnapshotDiff) {
+	for _, group := range []struct {
+		name string
+		ids  []string
+	}{
+		{"opened", diff.Opened}, {"closed", diff.Closed}, {"reopened", diff.Reopened},
+		{"changed", diff.Changed}, {"removed", diff.Removed},
+	} {
+		for _, id := range group.ids {
+			summary := ""
+			if issue := st.it.Issue(id); issue != nil {
+				summary, _ = lit.Get(issue, "summary")
+			}
+			if st.porcelain {
+				fmt.Fprintf(st.stdout, "%s\t%s\t%s\n", group.name, id, summary)
+				continue
+			}
+			fmt.Fprintf(st.stdout, "%-8s %-*s %s\n", group.name, st.it.ShortLen(), st.it.ShortID(id), summary)
+		}
+	}
+}
+
+func (st *state) releaseCmd() {
+	if len(st.args) < 1 {
+		st.usagef("release: you must specify an operation\n")
+	}
+	op := st.args[0]
+	st.args = st.args[1:]
+	st.loadIssues()
+	switch op {
+	case "start":
+		if len(st.args) < 1 {
+			st.usagef("release: you must specify a version\n")
+		}
+		st.checkErr(st.it.StartRelease(st.args[0]))
+		st.storeIssues()
+	case "add":
+		version, ok := st.it.CurrentRelease()
+		if !ok {
+			st.fatalln("release: no release started")
+		}
+		stamp := st.it.Stamp(st.username)
+		for _, id := range st.specIds() {
+			issue := st.findIssue(id)
+			if issue == nil {
+				st.warnf("release: %s\n", &lit.NotFoundError{ID: id})
+				continue
+			}
+			if err := st.it.AddToRelease(issue, version); err != nil {
+				st.warnf("release: %s\n", err)
+				continue
+			}
+			if !lit.Touch(issue, stamp) {
+				st.warnf("release: error setting update time for issue %s\n", id)
+			}
+			st.recordEvent(issue, "added to release "+version, "")
+		}
+		st.storeIssues()
+	case "list":
+		version, ok := st.it.CurrentRelease()
+		if len(st.args) > 0 {
+			version, ok = st.args[0], true
+		}
+		if !ok {
+			st.fatalln("release: no release started")
+		}
+		fmt.Fprintln(st.stdout, st.listHeader())
+		for _, id := range st.it.ReleaseIssues(version) {
+			fmt.Fprintln(st.stdout, st.listInfo(st.findIssue(id)))
+		}
+	case "due":
+		if len(st.args) < 2 {
+			st.usagef("release: you must specify a version and date\n")
+		}
+		st.checkErr(st.it.SetReleaseDue(st.args[0], st.args[1]))
+		st.storeIssues()
+	case "ship":
+		if len(st.args) < 1 {
+			st.usagef("release: you must specify a version\n")
+		}
+		version := st.args[0]
+		ids, err := st.it.ShipRelease(version, st.username)
+		st.checkErr(err)
+		for _, id := range ids {
+			st.recordEvent(st.findIssue(id), "shipped in "+version, "")
+		}
+		st.storeIssues()
+		if err := st.it.CreateSnapshot(version); err != nil {
+			st.warnf("release: %s\n", err)
+		}
+		st.checkErr(st.it.WriteChangelog(st.stdout, version, ids))
+	default:
+		st.usagef("release: %s is not a valid operation\n", op)
+	}
+}
+
+func (st *state) securityCmd() {
+	if len(st.args) < 1 {
+		st.usagef("security: you must specify an operation\n")
+	}
+	op := st.args[0]
+	st.args = st.args[1:]
+	st.loadIssues()
+	if op == "new" {
+		issue := st.it.NewAdvisory(st.username, strings.Join(st.args, " "))
+		fmt.Fprintln(st.stdout, issue.Key())
+		st.storeIssues()
+		return
+	}
+	if len(st.args) < 1 {
+		st.usagef("security: you must specify an issue\n")
+	}
+	issue := st.findIssue(st.args[0])
+	if issue == nil {
+		st.fatalf("security: %s\n", &lit.NotFoundError{ID: st.args[0]})
+	}
+	switch op {
+	case "publish":
+		st.checkErr(st.it.Publish(issue, st.username))
+		st.recordEvent(issue, "published advisory for", "")
+		st.storeIssues()
+	case "advisory":
+	default:
+		st.usagef("security: %s is not a valid operation\n", op)
+	}
+	st.checkErr(st.it.WriteAdvisory(st.stdout, issue))
+}
+
+func (st *state) exportCmd() {
+	if len(st.args) < 1 {
+		st.usagef("export: you must specify a format\n")
+	}
+	format := st.args[0]
+	st.args = st.args[1:]
+	since := time.Time{}
+	if format == "events" && len(st.args) > 0 && st.args[0] == "--since" {
+		if len(st.args) < 2 {
+			st.usagef("export: --since requires a time or age\n")
+		}
+		since = st.parseSince(st.args[1])
+		st.args = st.args[2:]
+	}
+	if len(st.args) == 0 {
+		st.args = []string{"open"}
+		if format == "events" {
+			st.args = []string{"all"}
+		}
+	}
+	st.loadIssues()
+	switch format {
+	case "ics":
+		err := st.it.WriteICS(st.stdout, st.specIds())
+		st.checkErr(err)
+	case "events":
+		enc := json.NewEncoder(st.stdout)
+		for _, ev := range st.it.Events(st.specIds(), since) {
+			st.checkErr(enc.Encode(ev))
+		}
+	case "git-bug":
+		st.checkErr(st.it.ExportGitBug(st.stdout, st.specIds()))
+	case "fossil":
+		st.checkErr(st.it.ExportFossil(st.stdout, st.specIds()))
+	default:
+		st.usagef("export: %s is not a valid format\n", format)
+	}
+}
+
+func (st *state) importCmd() {
+	if len(st.args) < 2 {
+		st.usagef("import: you must specify a format and file\n")
+	}
+	format, filename := st.args[0], st.args[1]
+	file, err := os.Open(filename)
+	st.checkErr(err)
+	defer file.Close()
+	st.loadIssues()
+	var issues []*dgrl.Branch
+	switch format {
+	case "flit":
+		issues, err = st.it.ImportFlit(file)
+	case "git-bug":
+		issues, err = st.it.ImportGitBug(file, st.username)
+	case "fossil":
+		issues, err = st.it.ImportFossil(file, st.username)
+	default:
+		issues, err = st.it.ImportOutline(file, format, st.username)
+	}
+	st.checkErr(err)
+	for _, issue := range issues {
+		fmt.Fprintln(st.stdout, issue.Key())
+	}
+	st.storeIssues()
+}
+
+func (st *state) syncCmd() {
+	prefer := ""
+	if len(st.args) > 1 && st.args[0] == "--prefer" {
+		prefer = st.args[1]
+		if prefer != "lit" && prefer != "forge" {
+			st.fatalf("sync: invalid preference %s (lit, forge)\n", prefer)
+		}
+	}
+	st.loadIssues()
+	forge, err := st.it.Forge()
+	st.checkErr(err)
+	report, err := st.it.Sync(forge, st.username, prefer)
+	for _, id := range report.Pulled {
+		st.recordEvent(st.findIssue(id), "synced from forge", "")
+	}
+	st.storeIssues() // even on error, to keep the forge numbers of new issues
+	st.checkErr(err)
+	for _, group := range []struct {
+		name string
+		ids  []string
+	}{{"pulled", report.Pulled}, {"pushed", report.Pushed}, {"conflict", report.Conflicts}} {
+		for _, id := range group.ids {
+			summary, _ := lit.Get(st.findIssue(id), "summary")
+			fmt.Fprintf(st.stdout, "%-8s %-*s %s\n", group.name, st.it.ShortLen(), st.it.ShortID(id), summary)
+		}
+	}
+	if len(report.Conflicts) > 0 {
+		st.fatalln("sync: issues changed on both sides; edit one side or use --prefer lit or --prefer forge")
+	}
+}
+
+func (st *state) fsckCmd() {
+	doPrune := len(st.args) > 0 && st.args[0] == "--prune"
+	st.loadIssues()
+	problems, err := st.it.Check()
+	st.checkErr(err)
+	st.reportProblems(problems, doPrune, "pruned")
+}
+
+func (st *state) gcCmd() {
+	st.loadIssues()
+	stats, err := st.it.GC()
+	st.checkErr(err)
+	fmt.Fprintf(st.stdout, "issue file:         %d bytes reclaimed, %d fields trimmed\n", stats.IssueBytes, stats.Fields)
+	fmt.Fprintf(st.stdout, "orphan attachments: %d bytes reclaimed, %d removed\n", stats.OrphanBytes, stats.Orphans)
+	fmt.Fprintf(st.stdout, "cache:              %d bytes reclaimed, %d entries removed\n", stats.CacheBytes, stats.CacheEntries)
+}
+
+func (st *state) lintCmd() {
+	doFix := len(st.args) > 0 && st.args[0] == "--fix"
+	if doFix {
+		st.args = st.args[1:]
+	}
+	if len(st.args) == 0 {
+		st.args = []string{"all"}
+	}
+	st.loadIssues()
+	st.reportProblems(st.it.Lint(st.specIds()), doFix, "fixed")
+}
+
+// reportProblems prints problems, fixing those it can if doFix is set, and
+// exits unsuccessfully if any remain.
+func (st *state) reportProblems(problems []*lit.Problem, doFix bool, fixed string) {
+	numFixed := 0
+	for _, p := range problems {
+		if doFix && p.CanPrune() {
+			if err := p.Prune(); err != nil {
+				st.warnf("%s: %s\n", st.cmd, err)
+				continue
+			}
+			fmt.Fprintf(st.stdout, "%s (%s)\n", p, fixed)
+			numFixed++
+			continue
+		}
+		fmt.Fprintln(st.stdout, p)
+	}
+	if numFixed > 0 {
+		st.storeIssues()
+	}
+	if len(problems) > numFixed {
+		exit(1)
+	}
+}
+
+func (st *state) mergeCmd() {
+	if len(st.args) < 3 || st.args[1] != "--into" {
+		st.usagef("merge: you must specify a duplicate and an issue to merge --into\n")
+	}
+	st.loadIssues()
+	dup := st.findIssue(st.args[0])
+	if dup == nil {
+		st.fatalf("merge: %s\n", &lit.NotFoundError{ID: st.args[0]})
+	}
+	canonical := st.findIssue(st.args[2])
+	if canonical == nil {
+		st.fatalf("merge: %s\n", &lit.NotFoundError{ID: st.args[2]})
+	}
+	_, err := st.it.Merge(dup, canonical, st.username)
+	st.checkErr(err)
+	st.recordEvent(canonical, "merged "+dup.Key()+" into", "")
+	st.storeIssues()
+}
+
+func (st *state) editCmd() {
+	doForce := len(st.args) > 0 && st.args[0] == "--force"
+	if doForce {
+		st.args = st.args[1:]
+	}
+	editor := getEditor()
+	if editor == "" {
+		st.fatalln("edit: VISUAL or EDITOR environment variable must be set")
+	}
+
+	st.loadIssues()
+
+	// create temp file
+	tempFile, err := ioutil.TempFile("", "lit-")
+	st.checkErr(err)
+	filename := tempFile.Name()
+
+	// load issue content into temp file
+	ids := st.specIds()
+	toEdit := dgrl.NewRoot()
+	for _, id := range ids {
+		issue := st.findIssue(id)
+		if issue == nil {
+			st.warnf("edit: %s\n", &lit.NotFoundError{ID: id})
+			continue
+		}
+		toEdit.Append(issue)
+	}
+	err = toEdit.Write(tempFile)
+	st.checkErr(err)
+	tempFile.Close()
+
+	// get original file state
+	origStat, err := os.Stat(filename)
+	st.checkErr(err)
+
+	// launch editor
+	ed := exec.Command(editor, filename)
+	ed.Stdin, ed.Stdout, ed.Stderr = st.stdin, st.stdout, st.stderr
+	err = ed.Run()
+	st.checkErr(err)
+
+	// get updated file state, compare to original
+	newStat, err := os.Stat(filename)
+	st.checkErr(err)
+	if newStat.ModTime() == origStat.ModTime() {
+		st.fatalln("edit: file unchanged")
+	}
+
+	// parse issues from temp file, keeping what's rejected
+	tempFile, err = os.Open(filename)
+	st.checkErr(err)
+	edIssues, badBlocks, err := lit.ParseIssues(tempFile)
+	tempFile.Close()
+	st.checkErr(err)
+	rejected := ""
+	for _, bad := range badBlocks {
+		st.warnf("edit: %s: %v\n", filename, bad)
+		rejected += bad.Text
+	}
+
+	// update issues if we find a match
+	didUpdate := false
+	stamp := st.it.Stamp(st.username)
+	for _, id := range ids {
+		issue := st.findIssue(id)
+		if issue == nil {
+			// already printed error, so don't repeat here
+			continue
+		}
+		for _, ed := range edIssues {
+			if strings.HasPrefix(ed.Key(), id) {
+				if key := changedReserved(issue, ed); key != "" && !doForce {
+					st.warnf("edit: %s (use --force)\n", &lit.ReservedError{ID: id, Key: key})
+					rejected += st.issueText(ed)
+					break
+				}
+				*issue = *ed
+				if !lit.Touch(issue, stamp) {
+					st.warnf("edit: error setting update time for issue %s\n", id)
+					continue
+				}
+				st.recordEvent(issue, "edited", "")
+				didUpdate = true
+				break
+			}
+		}
+	}
+	if rejected != "" {
+		path, err := st.it.SaveRecovery(st.username, rejected)
+		st.checkErr(err)
+		st.warnf("edit: rejected text saved to %s\n", path)
+	}
+	if !didUpdate {
+		st.fatalln("edit: did not update anything")
+	}
+
+	st.storeIssues()
+}
+
+// issueText returns an issue in issues file form.
+func (st *state) issueText(issue *dgrl.Branch) string {
+	root := dgrl.NewRoot()
+	root.Append(issue)
+	buf := &bytes.Buffer{}
+	st.checkErr(root.Write(buf))
+	return buf.String()
+}
+
+func (st *state) closeCmd() {
+	resolution, reason := "", ""
+	for st.cmd == "close" && len(st.args) > 1 && (st.args[0] == "--as" || st.args[0] == "--reason") {
+		if st.args[0] == "--as" {
+			resolution = st.args[1]
+		} else {
+			reason = st.args[1]
+		}
+		st.args = st.args[2:]
+	}
+	st.loadIssues()
+	if resolution != "" && !st.isResolution(resolution) {
+		st.fatalf("close: invalid resolution %s (%s)\n", resolution, strings.Join(st.it.Resolutions(), ", "))
+	}
+	for _, id := range st.specIds() {
+		issue := st.findIssue(id)
+		if issue == nil {
+			st.warnf("%s: %s\n", st.cmd, &lit.NotFoundError{ID: id})
+			continue
+		}
+		var err error
+		if st.cmd == "close" {
+			err = st.it.Close(issue, st.username, resolution, reason)
+		} else {
+			err = st.it.Reopen(issue, st.username)
+		}
+		if err != nil {
+			st.warnf("%s: %s\n", st.cmd, err)
+		}
+	}
+	st.storeIssues()
+}
+
+func (st *state) isResolution(resolution string) bool {
+	for _, res := range st.it.Resolutions() {
+		if res == resolution {
+			return true
+		}
+	}
+	return false
+}
+
+func (st *state) listInfo(issue *dgrl.Branch) string {
+	status := " "
+	closed, _ := lit.Get(issue, "closed")
+	if len(closed) > 0 {
+		status = "*"
+		if res, _ := lit.GetExact(issue, "resolution"); res != "" {
+			status = res // truncated to its first letter
+		}
+	}
+	tags, _ := lit.Get(issue, "tags")
+	priority, _ := lit.Get(issue, "priority")
+	attached := " "
+	if numAttach := len(st.it.Attachments(issue)); numAttach > 0 {
+		attached = "*"
+		if numAttach < 10 {
+			attached = strconv.Itoa(numAttach)
+		}
+	}
+	assigned, _ := lit.Get(issue, "assigned")
+	summary, _ := lit.Get(issue, "summary")
+	if done, total := lit.ChecklistProgress(issue); total > 0 {
+		summary = strings.TrimSpace(fmt.Sprintf("%s [%d/%d]", summary, done, total))
+	}
+	n := st.it.ShortLen()
+	return fmt.Sprintf(listFmt, n, n, issue.Key(), status, priority, attached, ciMark(issue), assigned, tags, summary)
+}
+
+// ciMark shows the last CI status of an issue: + passed, ! failed, ~ other.
+func ciMark(issue *dgrl.Branch) string {
+	switch status, _ := lit.GetExact(issue, "ci-status"); status {
+	case "":
+		return " "
+	case "passed":
+		return "+"
+	case "failed":
+		return "!"
+	}
+	return "~"
+}
+
+func (st *state) porcelainInfo(issue *dgrl.Branch) string {
+	field := func(key string) string {
+		val, _ := lit.Get(issue, key)
+		return strings.NewReplacer("\t", " ", "\n", " ").Replace(val)
+	}
+	return fmt.Sprintf(porcelainFmt, issue.Key(), field("closed"), field("priority"),
+		len(st.it.Attachments(issue)), field("assigned"), field("tags"), field("summary"))
+}
+
+func keyval(kv []string) (string, string) {
+	key, val := "", ""
+	if len(kv) > 0 {
+		key = kv[0]
+	}
+	if len(kv) > 1 {
+		val = kv[1]
+	}
+	return key, val
+}
+
+func (st *state) matchIds(kv []string, doesMatch bool) ([]string, error) {
+	key, val := keyval(kv)
+	return st.it.MatchWith(key, val, doesMatch, st.matchOpts)
+}
+
+func (st *state) compareIds(kv []string, isLess bool) []string {
+	key, val := keyval(kv)
+	return st.it.Compare(key, val, isLess)
+}
+
+func (st *state) dispOpts() (bool, string, bool) {
+	switch {
+	case len(st.args) == 0:
+		return false, "", true
+	case st.args[0] == "sortby" || st.args[0] == "rsortby":
+		if len(st.args) < 2 {
+			st.fatalf("%s: sort requested, but no key given to sort by\n", st.cmd)
+		}
+		doSort := true
+		doAscend := (st.args[0] == "sortby")
+		key := st.args[1]
+		st.args = st.args[2:]
+		return doSort, key, doAscend
+	}
+	return false, "", true
+}
+
+func (st *state) pageOpts() (int, int) {
+	offset, limit, page := 0, 0, 0
+	for len(st.args) > 0 {
+		opt := st.args[0]
+		if opt != "--limit" && opt != "--offset" && opt != "--page" {
+			break
+		}
+		if len(st.args) < 2 {
+			st.usagef("%s: %s requires a number\n", st.cmd, opt)
+		}
+		num, err := strconv.ParseUint(st.args[1], 10, 32)
+		st.checkErr(err)
+		switch opt {
+		case "--limit":
+			limit = int(num)
+		case "--offset":
+			offset = int(num)
+		case "--page":
+			page = int(num)
+		}
+		st.args = st.args[2:]
+	}
+	if page > 0 {
+		if limit == 0 {
+			st.usagef("%s: --page requires --limit\n", st.cmd)
+		}
+		offset = (page - 1) * limit
+	}
+	return offset, limit
+}
+
+func (st *state) specIds() []string {
+	ids, err := st.specIdsErr()
+	st.checkErr(err)
+	return ids
+}
+
+func (st *state) specIdsErr() ([]string, error) {
+	filt := ""
+	if len(st.args) > 0 {
+		filt = st.args[0]
+	}
+	switch filt {
+	case "all":
+		return st.it.IssueIds(), nil
+	case "mine":
+		me := regexp.QuoteMeta(st.username)
+		if at := strings.Index(st.username, "@"); at > 0 {
+			me += "|" + regexp.QuoteMeta(st.username[:at])
+		}
+		return st.it.MatchWith("assigned", me, true, lit.MatchOptions{})
+	case "open":
+		return st.matchIds([]string{"closed", ""}, false)
+	case "closed":
+		return st.matchIds([]string{"closed", ""}, true)
+	case "with":
+		return st.matchIds(st.args[1:], true)
+	case "without":
+		return st.matchIds(st.args[1:], false)
+	case "less":
+		return st.compareIds(st.args[1:], true), nil
+	case "greater":
+		return st.compareIds(st.args[1:], false), nil
+	}
+	return st.args, nil
+}
+
+// language returns the language for display text, configured as "language",
+// or from the LC_ALL, LC_MESSAGES, or LANG environment variables.
+func (st *state) language() string {
+	if lang, ok := st.it.Config("language"); ok {
+		return strings.TrimSpace(lang)
+	}
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if lang := os.Getenv(env); lang != "" {
+			return lang
+		}
+	}
+	return ""
+}
+
+// tr returns the display text for a field name or message from the catalog.
+func (st *state) tr(msg string) string {
+	if text, ok := st.catalog[msg]; ok && text != "" {
+		return text
+	}
+	return msg
+}
+
+func (st *state) listHeader() string {
+	n := st.it.ShortLen()
+	return fmt.Sprintf(listFmt, n, n, st.tr("id"), st.tr("c"), st.tr("p"), st.tr("a"), st.tr("v"), st.tr("assigned"), st.tr("tags"), st.tr("summary"))
+}
+
+func (st *state) loadIssues() {
+	start := time.Now()
+	err := st.it.Load()
+	st.checkErr(err)
+	st.catalog, err = st.it.Catalog(st.language())
+	st.checkErr(err)
+	if st.verbosity >= verbose {
+		dir, _ := st.it.TrackerDir()
+		st.debugf("loaded %d issues from %s in %v\n", len(st.it.IssueIds()), dir, time.Since(start))
+	}
+}
+
+// recordEvent notes a change to an issue, to be sent to the configured
+// notifiers once the change is stored.
+func (st *state) recordEvent(issue *dgrl.Branch, action, detail string) {
+	st.it.Record(&lit.Event{Issue: issue, Action: action, User: st.username, Detail: detail})
+}
+
+func (st *state) storeIssues() {
+	if url, ok := st.it.MirrorOf(); ok && st.cmd != "mirror" {
+		st.fatalf("%s: this tracker is a read-only mirror of %s\n", st.cmd, url)
+	}
+	start := time.Now()
+	warned := map[*dgrl.Branch]bool{}
+	for _, ev := range st.it.Recorded() {
+		if n, long := st.it.LongSummary(ev.Issue); long && !warned[ev.Issue] {
+			st.warnf("%s: issue %.8s summary is %d characters, over %d\n", st.cmd, ev.Issue.Key(), n, st.it.SummaryMax())
+			warned[ev.Issue] = true
+		}
+	}
+	err := st.it.Store()
+	st.checkErr(err)
+	st.debugf("stored issues in %v\n", time.Since(start))
+	for _, ev := range st.it.Recorded() {
+		st.debugf("notifying: %s\n", ev.Summary())
+	}
+	for _, err := range st.it.SendEvents() {
+		st.warnf("%s: notify: %s\n", st.cmd, err)
+	}
+}
+
+func (st *state) checkErr(err error) {
+	if err != nil {
+		str := ""
+		if st.cmd != "" {
+			str += st.cmd + ": "
+		}
+		st.fatalf("%s%s\n", str, err)
+	}
+}
+
+// findIssue returns the issue for an id, aborting if the id is ambiguous.
+func (st *state) findIssue(id string) *dgrl.Branch {
+	issue, err := st.it.Lookup(id)
+	if _, ok := err.(*lit.AmbiguousError); ok {
+		st.checkErr(err)
+	}
+	return issue
+}
+
+// changedReserved returns the first reserved field that differs between the
+// original and edited issue, or an empty string if there is none.
+func changedReserved(orig, ed *dgrl.Branch) string {
+	if orig.Key() != ed.Key() {
+		return "id"
+	}
+	for _, key := range []string{"created", "closed"} {
+		origVal, _ := lit.GetExact(orig, key)
+		edVal, _ := lit.GetExact(ed, key)
+		if origVal != edVal {
+			return key
+		}
+	}
+	return ""
+}
+
+func getEditor() string {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	return editor
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/ianremmler/lit"
+)
+
+// Verbosity levels, set by the -q and -v options.
+const (
+	quiet = iota - 1
+	normal
+	verbose
+)
+
+// warnf logs a diagnostic that doesn't stop the command, unless quiet.
+func (st *state) warnf(format string, v ...interface{}) {
+	if st.verbosity >= normal {
+		st.logAt("warning", format, v...)
+	}
+}
+
+// debugf logs a trace of what lit is doing, if verbose.
+func (st *state) debugf(format string, v ...interface{}) {
+	if st.verbosity >= verbose {
+		st.logAt("debug", "debug: "+format, v...)
+	}
+}
+
+func (st *state) logAt(level, format string, v ...interface{}) {
+	st.logLevel, st.logErr = level, firstErr(v)
+	st.logger.Printf(format, v...)
+	st.logLevel, st.logErr = "error", nil
+}
+
+// jsonErrors writes each log message as a JSON object, for --errors json.
+type jsonErrors struct {
+	st  *state
+	enc *json.Encoder
+}
+
+type jsonError struct {
+	Level   string `json:"level"`
+	Code    string `json:"code"`
+	Command string `json:"command,omitempty"`
+	Message string `json:"message"`
+	ID      string `json:"id,omitempty"`
+}
+
+func newJSONErrors(st *state, w io.Writer) *jsonErrors {
+	return &jsonErrors{st: st, enc: json.NewEncoder(w)}
+}
+
+func (j *jsonErrors) Write(p []byte) (int, error) {
+	st := j.st
+	msg := strings.TrimSpace(string(p))
+	msg = strings.TrimPrefix(strings.TrimPrefix(msg, "debug: "), st.cmd+": ")
+	jerr := &jsonError{Level: st.logLevel, Command: st.cmd, Message: msg}
+	jerr.Code, jerr.ID = st.errCode()
+	if err := j.enc.Encode(jerr); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// errCode classifies the error being logged for programs consuming --errors
+// json, returning its code and the id of the issue it concerns, if any.
+func (st *state) errCode() (string, string) {
+	var (
+		ambiguous    *lit.AmbiguousError
+		ambiguousKey *lit.AmbiguousKeyError
+		notFound     *lit.NotFoundError
+		reserved     *lit.ReservedError
+		hook         *lit.HookError
+		usage        usageError
+	)
+	switch {
+	case st.logLevel == "debug":
+		return "debug", ""
+	case errors.As(st.logErr, &ambiguous):
+		return "ambiguous", ambiguous.ID
+	case errors.As(st.logErr, &ambiguousKey):
+		return "ambiguous", ""
+	case errors.As(st.logErr, &notFound):
+		return "not-found", notFound.ID
+	case errors.As(st.logErr, &reserved):
+		return "reserved", reserved.ID
+	case errors.As(st.logErr, &hook):
+		return "rejected", ""
+	case errors.As(st.logErr, &usage):
+		return "usage", ""
+	}
+	return "failed", ""
+}
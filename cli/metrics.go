@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ianremmler/lit"
+)
+
+var percentiles = []float64{0.5, 0.75, 0.9, 0.95}
+
+// metricsCmd reports lead and cycle time percentiles for closed issues and
+// a histogram of the ages of open issues.
+func (st *state) metricsCmd() {
+	if len(st.args) < 1 || st.args[0] != "leadtime" {
+		st.usagef("metrics: you must specify a metric (leadtime)\n")
+	}
+	st.args = st.args[1:]
+	if len(st.args) == 0 {
+		st.args = []string{"all"}
+	}
+	st.loadIssues()
+	ids := st.specIds()
+	st.printPercentiles("lead time", st.it.LeadTimes(ids))
+	st.printPercentiles("cycle time", st.it.CycleTimes(ids))
+
+	buckets := st.it.AgeBuckets(ids, st.it.Now())
+	most := 0
+	for _, b := range buckets {
+		if b.Count > most {
+			most = b.Count
+		}
+	}
+	fmt.Fprintln(st.stdout, "open issue age:")
+	for _, b := range buckets {
+		bar := 0
+		if most > 0 {
+			bar = (b.Count*40 + most - 1) / most
+		}
+		fmt.Fprintf(st.stdout, "  %-6s %5d %s\n", b.Label, b.Count, strings.Repeat("#", bar))
+	}
+}
+
+func (st *state) printPercentiles(name string, durations []time.Duration) {
+	fmt.Fprintf(st.stdout, "%-10s %5d", name, len(durations))
+	for _, p := range percentiles {
+		fmt.Fprintf(st.stdout, "  p%d %5s", int(p*100), fmtAge(lit.Percentile(durations, p)))
+	}
+	fmt.Fprintln(st.stdout)
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ianremmler/lit"
+)
+
+// defaultOAuthClaim is the userinfo claim identifying a user by default.
+const defaultOAuthClaim = "email"
+
+// oauthProvider authenticates bearer tokens issued by an OAuth2 or OpenID
+// Connect provider, configured as "oauth-userinfo", the URL of its userinfo
+// endpoint.  The user's identity is the "oauth-claim" claim of the userinfo,
+// by default their email address.
+type oauthProvider struct {
+	userinfo string
+	claim    string
+	client   *http.Client
+}
+
+// newOAuthProvider returns the provider configured for a loaded tracker, or
+// nil if there is none.
+func newOAuthProvider(tracker *lit.Lit) *oauthProvider {
+	userinfo, _ := tracker.Config("oauth-userinfo")
+	if userinfo == "" {
+		return nil
+	}
+	claim, _ := tracker.Config("oauth-claim")
+	if claim == "" {
+		claim = defaultOAuthClaim
+	}
+	return &oauthProvider{
+		userinfo: userinfo,
+		claim:    claim,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// identity asks the provider who a token was issued to.
+func (o *oauthProvider) identity(token string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, o.userinfo, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("oauth: %s", resp.Status)
+	}
+	claims := map[string]interface{}{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&claims); err != nil {
+		return "", fmt.Errorf("oauth: %w", err)
+	}
+	id, _ := claims[o.claim].(string)
+	if id == "" {
+		return "", fmt.Errorf("oauth: userinfo has no %s", o.claim)
+	}
+	return id, nil
+}
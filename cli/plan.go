@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ianremmler/lit"
+)
+
+func (st *state) estimateCmd() {
+	if len(st.args) < 1 || st.args[0] != "sum" {
+		st.usagef("estimate: you must specify an operation (sum)\n")
+	}
+	st.args = st.args[1:]
+	if len(st.args) == 0 {
+		st.args = []string{"open"}
+	}
+	st.loadIssues()
+	fmt.Fprintln(st.stdout, fmtHours(st.it.EstimateSum(st.specIds())))
+}
+
+// planCmd compares the estimated work assigned to each user in a milestone,
+// or the current release, to their capacity.  It exits unsuccessfully if
+// anyone is over capacity.
+func (st *state) planCmd() {
+	st.loadIssues()
+	milestone, _ := st.it.CurrentRelease()
+	capacity := map[string]time.Duration{}
+	for len(st.args) > 1 {
+		switch st.args[0] {
+		case "--milestone":
+			milestone = st.args[1]
+		case "--capacity":
+			for _, userCap := range strings.Split(st.args[1], ",") {
+				parts := strings.SplitN(userCap, "=", 2)
+				if len(parts) < 2 {
+					st.fatalf("plan: invalid capacity %s\n", userCap)
+				}
+				dur, err := lit.ParseEstimate(parts[1])
+				st.checkErr(err)
+				capacity[parts[0]] = dur
+			}
+		default:
+			st.usagef("plan: unknown option %s\n", st.args[0])
+		}
+		st.args = st.args[2:]
+	}
+	if milestone == "" {
+		st.usagef("plan: you must specify a milestone\n")
+	}
+
+	ids := []string{}
+	for _, id := range st.it.ReleaseIssues(milestone) {
+		closed, _ := lit.Get(st.findIssue(id), "closed")
+		if closed == "" {
+			ids = append(ids, id)
+		}
+	}
+	planned := st.it.EstimateByUser(ids)
+	users := []string{}
+	for user := range planned {
+		users = append(users, user)
+	}
+	for user := range capacity {
+		if _, ok := planned[user]; !ok {
+			users = append(users, user)
+		}
+	}
+	sort.Strings(users)
+
+	isOver := false
+	fmt.Fprintf(st.stdout, "%-16s %8s %8s\n", "user", "planned", "capacity")
+	for _, user := range users {
+		name, capStr, status := user, "-", ""
+		if name == "" {
+			name = "(unassigned)"
+		}
+		if userCap, ok := capacity[user]; ok {
+			capStr = fmtHours(userCap)
+			if planned[user] > userCap {
+				status, isOver = " over", true
+			}
+		}
+		fmt.Fprintf(st.stdout, "%-16s %8s %8s%s\n", name, fmtHours(planned[user]), capStr, status)
+	}
+	if isOver {
+		exit(1)
+	}
+}
+
+// workloadCmd summarizes the open issues and estimated work of each assignee,
+// optionally only in a milestone.
+func (st *state) workloadCmd() {
+	st.loadIssues()
+	ids := st.it.IssueIds()
+	if len(st.args) > 1 && st.args[0] == "--milestone" {
+		ids = st.it.ReleaseIssues(st.args[1])
+	}
+	open := []string{}
+	counts := map[string]int{}
+	for _, id := range ids {
+		issue := st.findIssue(id)
+		if closed, _ := lit.Get(issue, "closed"); closed != "" {
+			continue
+		}
+		open = append(open, id)
+		assigned, _ := lit.Get(issue, "assigned")
+		users := strings.Fields(assigned)
+		if len(users) == 0 {
+			users = []string{""}
+		}
+		for _, user := range users {
+			counts[user]++
+		}
+	}
+	estimates := st.it.EstimateByUser(open)
+	users := []string{}
+	for user := range counts {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+	fmt.Fprintf(st.stdout, "%-16s %6s %8s\n", "user", "issues", "estimate")
+	for _, user := range users {
+		name := user
+		if name == "" {
+			name = "(unassigned)"
+		}
+		fmt.Fprintf(st.stdout, "%-16s %6d %8s\n", name, counts[user], fmtHours(estimates[user]))
+	}
+}
+
+// criticalPathCmd lists the longest dependency chain leading to the issues of
+// a milestone, or the current release.
+func (st *state) criticalPathCmd() {
+	st.loadIssues()
+	milestone, _ := st.it.CurrentRelease()
+	if len(st.args) > 1 && st.args[0] == "--milestone" {
+		milestone = st.args[1]
+	}
+	if milestone == "" {
+		st.usagef("critical-path: you must specify a milestone\n")
+	}
+	path, total, err := st.it.CriticalPath(st.it.ReleaseIssues(milestone))
+	st.checkErr(err)
+	fmt.Fprintln(st.stdout, st.listHeader())
+	for _, id := range path {
+		fmt.Fprintln(st.stdout, st.listInfo(st.findIssue(id)))
+	}
+	fmt.Fprintf(st.stdout, "total estimate of open issues: %s\n", fmtHours(total))
+}
+
+// fmtHours formats a duration in hours.
+func fmtHours(dur time.Duration) string {
+	return fmt.Sprintf("%gh", dur.Hours())
+}
@@ -0,0 +1,234 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ianremmler/dgrl"
+	"github.com/ianremmler/lit"
+	"github.com/ianremmler/lit/client"
+)
+
+// remoteCmd runs a command against the server given by --remote, sending
+// LIT_TOKEN as the bearer token.
+func (st *state) remoteCmd() {
+	c := client.New(strings.TrimRight(st.remote, "/"), os.Getenv("LIT_TOKEN"))
+	switch st.cmd {
+	case "id":
+		ids, err := c.IdsWith(st.listOpts(), st.args...)
+		st.checkErr(err)
+		for _, id := range ids {
+			fmt.Fprintln(st.stdout, id)
+		}
+	case "list", "show":
+		issues, err := c.ListWith(st.listOpts(), st.args...)
+		st.checkErr(err)
+		if st.cmd == "list" && !st.porcelain {
+			fmt.Fprintln(st.stdout, st.listHeader())
+		}
+		for _, data := range issues {
+			issue := lit.FromData(data)
+			switch {
+			case st.cmd == "show":
+				fmt.Fprintln(st.stdout, st.displayIssue(issue))
+			case st.porcelain:
+				fmt.Fprintln(st.stdout, st.porcelainInfo(issue))
+			default:
+				fmt.Fprintln(st.stdout, st.listInfo(issue))
+			}
+		}
+	case "search":
+		opts := st.searchOpts()
+		results, err := c.Search(strings.Join(st.args, " "), opts)
+		st.checkErr(err)
+		st.printSearchResults(results)
+	case "new":
+		num := 1
+		if len(st.args) > 0 {
+			n, err := strconv.ParseUint(st.args[0], 10, 16)
+			st.checkErr(err)
+			num = int(n)
+		}
+		ids, err := c.New(num)
+		st.checkErr(err)
+		for _, id := range ids {
+			fmt.Fprintln(st.stdout, id)
+		}
+	case "set":
+		doExact, doForce := false, false
+		for len(st.args) > 0 && strings.HasPrefix(st.args[0], "--") {
+			switch st.args[0] {
+			case "--exact":
+				doExact = true
+			case "--force":
+				doExact, doForce = true, true
+			default:
+				st.usagef("set: unknown option %s\n", st.args[0])
+			}
+			st.args = st.args[1:]
+		}
+		if len(st.args) < 2 {
+			st.usagef("set: you must specify a key and value\n")
+		}
+		var err error
+		if doExact {
+			_, err = c.SetExact(st.args[0], st.args[1], doForce, st.args[2:]...)
+		} else {
+			_, err = c.Set(st.args[0], st.args[1], st.args[2:]...)
+		}
+		st.checkErr(err)
+	case "comment":
+		if len(st.args) < 1 {
+			st.usagef("comment: you must specify an issue\n")
+		}
+		text := ""
+		if len(st.args) > 1 {
+			text = st.args[1]
+		} else {
+			text = st.editComment()
+		}
+		_, err := c.Comment(st.args[0], text)
+		st.checkErr(err)
+	case "close":
+		_, err := c.Close(st.args...)
+		st.checkErr(err)
+	case "reopen":
+		_, err := c.Reopen(st.args...)
+		st.checkErr(err)
+	default:
+		if st.isSpec(st.cmd) {
+			st.cmd, st.args = "id", append([]string{st.cmd}, st.args...)
+			st.remoteCmd()
+			return
+		}
+		st.fatalf("%s: not available with --remote\n", st.cmd)
+	}
+}
+
+// listOpts takes the sort and paging options of an id, list, or show command
+// for the server to apply.
+func (st *state) listOpts() client.ListOptions {
+	doSort, key, doAscend := st.dispOpts()
+	offset, limit := st.pageOpts()
+	opts := client.ListOptions{Offset: offset, Limit: limit}
+	if doSort {
+		opts.SortBy, opts.Descending = key, !doAscend
+	}
+	return opts
+}
+
+// mirrorWrites are the commands sent to the mirrored tracker when run in a
+// mirror.
+var mirrorWrites = map[string]bool{"new": true, "set": true, "comment": true, "close": true, "reopen": true}
+
+// mirrorRemote returns the URL of the tracker mirrored by the current one, if
+// any.
+func (st *state) mirrorRemote() string {
+	if err := st.it.Load(); err != nil {
+		return ""
+	}
+	url, _ := st.it.MirrorOf()
+	if url != "" {
+		st.debugf("sending %s to mirrored tracker %s\n", st.cmd, url)
+	}
+	return url
+}
+
+// followRetry is how long a following mirror waits before reconnecting to
+// the event stream, unless given an interval.
+const followRetry = 30 * time.Second
+
+// mirrorCmd refreshes the tracker's copy of a remote tracker, once, every
+// interval, or whenever the remote's event stream says it changed.
+func (st *state) mirrorCmd() {
+	url, every, follow := "", time.Duration(0), false
+	for len(st.args) > 0 {
+		switch {
+		case st.args[0] == "--follow":
+			follow, st.args = true, st.args[1:]
+		case st.args[0] == "--every" && len(st.args) > 1:
+			d, err := lit.ParseAge(st.args[1])
+			if err != nil || d <= 0 {
+				st.fatalf("mirror: invalid interval %s\n", st.args[1])
+			}
+			every, st.args = d, st.args[2:]
+		case !strings.HasPrefix(st.args[0], "-"):
+			url, st.args = st.args[0], st.args[1:]
+		default:
+			st.usagef("mirror: unknown option %s\n", st.args[0])
+		}
+	}
+	if err := st.it.Load(); err != nil {
+		st.checkErr(st.it.Init())
+	}
+	st.loadIssues()
+	current, isMirror := st.it.MirrorOf()
+	switch {
+	case url == "" && !isMirror:
+		st.usagef("mirror: you must specify the URL of a tracker to mirror\n")
+	case url == "":
+		url = current
+	case !isMirror && len(st.it.IssueIds()) > 0:
+		st.fatalln("mirror: this tracker has issues of its own, so can't be a mirror")
+	}
+	st.it.SetConfig("mirror-of", url)
+	c := client.New(strings.TrimRight(url, "/"), os.Getenv("LIT_TOKEN"))
+	if follow {
+		st.followMirror(c, url, every)
+		return
+	}
+	for {
+		num, err := st.refreshMirror(c)
+		if every == 0 {
+			st.checkErr(err)
+			fmt.Fprintf(st.stdout, "mirrored %d issues from %s\n", num, url)
+			return
+		}
+		if err != nil {
+			// likely offline, so keep the last copy and try again later
+			st.warnf("mirror: %s\n", err)
+		} else {
+			st.debugf("mirrored %d issues from %s\n", num, url)
+		}
+		time.Sleep(every)
+	}
+}
+
+// followMirror refreshes the mirror whenever the remote changes, reconnecting
+// to its event stream after retry (or followRetry) if it is lost.
+func (st *state) followMirror(c *client.Client, url string, retry time.Duration) {
+	if retry == 0 {
+		retry = followRetry
+	}
+	for {
+		err := c.Follow(context.Background(), func(version string) error {
+			num, err := st.refreshMirror(c)
+			if err != nil {
+				st.warnf("mirror: %s\n", err)
+			} else {
+				st.debugf("mirrored %d issues from %s at version %s\n", num, url, version)
+			}
+			return nil
+		})
+		// likely offline, so keep the last copy and reconnect later
+		st.warnf("mirror: %s\n", err)
+		time.Sleep(retry)
+	}
+}
+
+func (st *state) refreshMirror(c *client.Client) (int, error) {
+	data, err := c.List("all")
+	if err != nil {
+		return 0, err
+	}
+	issues := []*dgrl.Branch{}
+	for _, d := range data {
+		issues = append(issues, lit.FromData(d))
+	}
+	st.it.ReplaceIssues(issues)
+	return len(issues), st.it.Store()
+}
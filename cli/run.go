@@ -0,0 +1,149 @@
+// Package cli implements the lit command line, so that it can be embedded in
+// other programs and its commands run from tests.
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/ianremmler/lit"
+)
+
+// state is what a command works with, so that each call of Run has its own.
+type state struct {
+	stdin       io.Reader
+	stdout      io.Writer
+	stderr      io.Writer
+	stdinReader *bufio.Reader
+	logger      *log.Logger
+	verbosity   int
+	logLevel    string // of the message being logged, for --errors json
+	logErr      error  // error in the message being logged, for --errors json
+
+	args      []string
+	it        *lit.Lit
+	username  string
+	cmd       string
+	porcelain bool
+	timeZone  string
+	relTime   bool
+	remote    string
+	matchOpts lit.MatchOptions
+	catalog   map[string]string
+
+	serveMu    sync.Mutex // serializes HTTP requests, which share it
+	serveLocal bool       // serving the local user, over stdio
+}
+
+// exitStatus is panicked by exit, to unwind to Run.
+type exitStatus int
+
+// Run runs the lit command line with the given arguments, not including the
+// program name, and standard streams, returning the exit status.  A stdin
+// other than a terminal, such as a pipe or any reader that isn't a file, is
+// read for more arguments, as lit does when piped to.  Calls may overlap.
+func Run(cmdArgs []string, in io.Reader, out, errOut io.Writer) int {
+	return runIn("", cmdArgs, in, out, errOut)
+}
+
+// runIn is Run for the tracker found from dir, or from the working directory
+// if dir is empty.
+func runIn(dir string, cmdArgs []string, in io.Reader, out, errOut io.Writer) (status int) {
+	st := newState(dir, cmdArgs, in, out, errOut)
+	defer func() {
+		if r := recover(); r != nil {
+			code, ok := r.(exitStatus)
+			if !ok {
+				panic(r)
+			}
+			status = int(code)
+		}
+	}()
+	st.run()
+	return 0
+}
+
+// newState returns the state for running a command, with nil streams
+// replaced by empty ones.
+func newState(dir string, cmdArgs []string, in io.Reader, out, errOut io.Writer) *state {
+	if in == nil {
+		in = strings.NewReader("")
+	}
+	if out == nil {
+		out = ioutil.Discard
+	}
+	if errOut == nil {
+		errOut = ioutil.Discard
+	}
+	return &state{
+		stdin:       in,
+		stdout:      out,
+		stderr:      errOut,
+		stdinReader: bufio.NewReader(in),
+		logger:      log.New(errOut, "lit: ", 0),
+		verbosity:   normal,
+		logLevel:    "error",
+		args:        append([]string{}, cmdArgs...),
+		it:          lit.NewWithFS(lit.OSFS{}, dir),
+		username:    "?",
+		cmd:         "id",
+	}
+}
+
+// stdinIsPipe reports whether stdin is a pipe, or a reader given to Run that
+// isn't a file.
+func (st *state) stdinIsPipe() bool {
+	file, ok := st.stdin.(*os.File)
+	if !ok {
+		return true
+	}
+	stat, err := file.Stat()
+	return err == nil && stat.Mode()&os.ModeNamedPipe != 0
+}
+
+// exit ends the running command with a status.
+func exit(status int) {
+	panic(exitStatus(status))
+}
+
+// fatalf logs an error and exits with status 1.
+func (st *state) fatalf(format string, v ...interface{}) {
+	st.logErr = firstErr(v)
+	st.logger.Output(2, fmt.Sprintf(format, v...))
+	exit(1)
+}
+
+// fatalln logs an error and exits with status 1.
+func (st *state) fatalln(v ...interface{}) {
+	st.logErr = firstErr(v)
+	st.logger.Output(2, fmt.Sprintln(v...))
+	exit(1)
+}
+
+// usageError is a command used wrongly.
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+// usagef logs a usage error and exits with status 1.
+func (st *state) usagef(format string, v ...interface{}) {
+	st.fatalf("%s", usageError(fmt.Sprintf(format, v...)))
+}
+
+// firstErr returns the first error among the arguments of a log message.
+func firstErr(v []interface{}) error {
+	for _, arg := range v {
+		if err, ok := arg.(error); ok {
+			return err
+		}
+	}
+	return nil
+}
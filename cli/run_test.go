@@ -0,0 +1,509 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ianremmler/lit"
+	"github.com/ianremmler/lit/client"
+)
+
+// runLit runs the command line for the tracker in dir, returning its trimmed
+// output and exit status.
+func runLit(t *testing.T, dir string, args ...string) (string, string, int) {
+	t.Helper()
+	out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+	status := runIn(dir, args, nil, out, errOut)
+	return strings.TrimSpace(out.String()), strings.TrimSpace(errOut.String()), status
+}
+
+// mustRun runs the command line, failing the test if it doesn't succeed.
+func mustRun(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, errOut, status := runLit(t, dir, args...)
+	if status != 0 {
+		t.Fatalf("lit %s: status %d: %s", strings.Join(args, " "), status, errOut)
+	}
+	return out
+}
+
+// newTracker initializes a tracker in a new temporary directory.
+func newTracker(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	mustRun(t, dir, "init")
+	return dir
+}
+
+func TestCommands(t *testing.T) {
+	t.Setenv("LIT_USER", "tester")
+	dir := newTracker(t)
+	id := mustRun(t, dir, "new")
+	mustRun(t, dir, "set", "summary", "crash on start", id)
+	mustRun(t, dir, "set", "priority", "1", id)
+	if got := mustRun(t, dir, "with", "summary", "crash"); got != id {
+		t.Errorf("with summary crash = %q, want %q", got, id)
+	}
+	if got := mustRun(t, dir, "with", "summary", "hang"); got != "" {
+		t.Errorf("with summary hang = %q, want none", got)
+	}
+	mustRun(t, dir, "comment", id, "looks bad")
+	if got := mustRun(t, dir, "show", id); !strings.Contains(got, "looks bad") {
+		t.Errorf("show lacks comment:\n%s", got)
+	}
+	mustRun(t, dir, "close", id)
+	if got := mustRun(t, dir, "list", "open"); strings.Contains(got, "crash on start") {
+		t.Errorf("closed issue listed as open:\n%s", got)
+	}
+	got := mustRun(t, dir, "--porcelain", "list", "all")
+	fields := strings.Split(got, "\t")
+	if len(fields) != 7 || fields[0] != id || fields[1] == "" || fields[6] != "crash on start" {
+		t.Errorf("list --porcelain all = %q", got)
+	}
+	if _, errOut, _ := runLit(t, dir, "set", "closed", "x", id); !strings.Contains(errOut, "closed is reserved") {
+		t.Errorf("set closed error = %q, want refusal", errOut)
+	}
+}
+
+func TestNoTracker(t *testing.T) {
+	_, errOut, status := runLit(t, t.TempDir(), "list", "all")
+	if status == 0 || !strings.Contains(errOut, "not found") {
+		t.Errorf("list without tracker: status %d, %q", status, errOut)
+	}
+}
+
+func TestConcurrentRuns(t *testing.T) {
+	t.Setenv("LIT_USER", "tester")
+	dirs := []string{newTracker(t), newTracker(t), newTracker(t), newTracker(t)}
+	wg := sync.WaitGroup{}
+	for i, dir := range dirs {
+		wg.Add(1)
+		go func(i int, dir string) {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+				id := &bytes.Buffer{}
+				if runIn(dir, []string{"new"}, nil, id, errOut) != 0 {
+					t.Errorf("new: %s", errOut)
+					return
+				}
+				summary := fmt.Sprintf("tracker %d issue %d", i, j)
+				args := []string{"set", "summary", summary, strings.TrimSpace(id.String())}
+				if runIn(dir, args, nil, out, errOut) != 0 {
+					t.Errorf("set: %s", errOut)
+					return
+				}
+			}
+		}(i, dir)
+	}
+	wg.Wait()
+	for i, dir := range dirs {
+		got := mustRun(t, dir, "--porcelain", "list", "all")
+		if n := len(strings.Split(got, "\n")); n != 5 {
+			t.Errorf("tracker %d has %d issues, want 5", i, n)
+		}
+		if strings.Count(got, fmt.Sprintf("tracker %d ", i)) != 5 {
+			t.Errorf("tracker %d has issues of other trackers:\n%s", i, got)
+		}
+	}
+}
+
+// rpcCall posts a JSON-RPC request to a server and decodes the response.
+func rpcCall(t *testing.T, url, method string, params interface{}) *rpcResponse {
+	t.Helper()
+	data, err := json.Marshal(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := json.Marshal(&rpcRequest{Version: "2.0", ID: json.RawMessage("1"), Method: method, Params: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpResp, err := http.Post(url+"/rpc", "application/json", bytes.NewReader(req))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer httpResp.Body.Close()
+	resp := &rpcResponse{}
+	if err := json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
+		t.Fatalf("%s: %s", method, err)
+	}
+	return resp
+}
+
+func TestServe(t *testing.T) {
+	t.Setenv("LIT_USER", "tester")
+	dir := newTracker(t)
+	id := mustRun(t, dir, "new")
+	mustRun(t, dir, "set", "summary", "served", id)
+	secret := mustRun(t, dir, "new")
+	mustRun(t, dir, "set", "--force", "confidential", "true", secret)
+
+	st := newState(dir, nil, nil, nil, nil)
+	st.username = "tester"
+	proj := &project{st: st, tracker: lit.NewWithFS(lit.OSFS{}, dir)}
+	server := httptest.NewServer(serveMux(map[string]*project{"": proj}))
+	defer server.Close()
+
+	resp := rpcCall(t, server.URL, "ids", &rpcParams{Spec: []string{"all"}})
+	if resp.Error != nil {
+		t.Fatalf("ids: %s", resp.Error.Message)
+	}
+	if got := fmt.Sprint(resp.Result); got != "["+id+"]" {
+		t.Errorf("ids = %s, want only %s", got, id)
+	}
+	resp = rpcCall(t, server.URL, "set", &rpcParams{Key: "priority", Val: "2", Spec: []string{id}})
+	if resp.Error != nil {
+		t.Fatalf("set: %s", resp.Error.Message)
+	}
+	if got := mustRun(t, dir, "with", "priority", "2"); got != id {
+		t.Errorf("with priority 2 = %q, want %q", got, id)
+	}
+	resp = rpcCall(t, server.URL, "nosuch", &rpcParams{})
+	if resp.Error == nil || resp.Error.Code != rpcMethodNotFound {
+		t.Errorf("unknown method: %+v", resp.Error)
+	}
+	resp = rpcCall(t, server.URL, "list", &rpcParams{Spec: []string{secret}})
+	if resp.Error != nil || fmt.Sprint(resp.Result) != "[]" {
+		t.Errorf("list of confidential issue over http: %+v, %+v", resp.Result, resp.Error)
+	}
+
+	// over stdio, the caller is the local user, who can read the issue file
+	in := `{"jsonrpc": "2.0", "id": 1, "method": "ids", "params": {"spec": ["all"]}}` + "\n" +
+		`{"jsonrpc": "2.0", "id": 2, "method": "list", "params": {"spec": ["` + secret + `"]}}` + "\n"
+	out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+	if status := runIn(dir, []string{"serve", "--stdio"}, strings.NewReader(in), out, errOut); status != 0 {
+		t.Fatalf("serve --stdio: status %d: %s", status, errOut)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], secret) || !strings.Contains(lines[0], id) ||
+		!strings.Contains(lines[1], `"id":"`+secret+`"`) {
+		t.Errorf("confidential issue not served over stdio:\n%s", out)
+	}
+}
+
+// triage is a tool written against client.Tracker: it prioritizes and closes
+// the issues matching summary.
+func triage(tracker client.Tracker, summary string) error {
+	if err := tracker.Load(); err != nil {
+		return err
+	}
+	ids, err := tracker.Match("summary", summary, true)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		issue, err := tracker.Lookup(id)
+		if err != nil {
+			return err
+		}
+		if err := tracker.SetField(issue, "tester", "priority", "1", false); err != nil {
+			return err
+		}
+		if _, err := tracker.Comment(issue, "tester", "triaged"); err != nil {
+			return err
+		}
+		if err := tracker.Close(issue, "tester", "", ""); err != nil {
+			return err
+		}
+	}
+	return tracker.Store()
+}
+
+func TestTrackers(t *testing.T) {
+	t.Setenv("LIT_USER", "tester")
+	for _, remote := range []bool{false, true} {
+		dir := newTracker(t)
+		id := mustRun(t, dir, "new")
+		mustRun(t, dir, "set", "summary", "triage me", id)
+		var tracker client.Tracker
+		var err error
+		if remote {
+			st := newState(dir, nil, nil, nil, nil)
+			proj := &project{st: st, tracker: lit.NewWithFS(lit.OSFS{}, dir)}
+			server := httptest.NewServer(serveMux(map[string]*project{"": proj}))
+			defer server.Close()
+			tracker, err = client.NewRemote(server.URL, "")
+		} else {
+			tracker, err = client.NewLocal(dir)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := triage(tracker, "triage"); err != nil {
+			t.Fatalf("remote %v: %s", remote, err)
+		}
+		if got := mustRun(t, dir, "with", "priority", "1"); got != id {
+			t.Errorf("remote %v: with priority 1 = %q, want %q", remote, got, id)
+		}
+		if got := mustRun(t, dir, "list", "open"); strings.Contains(got, "triage me") {
+			t.Errorf("remote %v: issue still open:\n%s", remote, got)
+		}
+		if got := mustRun(t, dir, "show", id); !strings.Contains(got, "triaged") {
+			t.Errorf("remote %v: comment missing:\n%s", remote, got)
+		}
+	}
+}
+
+func TestJSONErrors(t *testing.T) {
+	t.Setenv("LIT_USER", "tester")
+	dir := newTracker(t)
+	id := mustRun(t, dir, "new")
+	tests := []struct {
+		args     []string
+		code, id string
+	}{
+		{[]string{"show", "0123abcd"}, "not-found", "0123abcd"},
+		{[]string{"set", "created", "x", id}, "reserved", id},
+		{[]string{"unset", "nosuch", id}, "not-found", id},
+		{[]string{"set"}, "usage", ""},
+		{[]string{"tag", "frob", "x"}, "usage", ""},
+	}
+	for _, test := range tests {
+		_, errOut, _ := runLit(t, dir, append([]string{"--errors", "json"}, test.args...)...)
+		jerr := &jsonError{}
+		if err := json.Unmarshal([]byte(errOut), jerr); err != nil {
+			t.Errorf("%v: %s: %q", test.args, err, errOut)
+			continue
+		}
+		if jerr.Code != test.code || jerr.ID != test.id {
+			t.Errorf("%v: code %q, id %q, want %q, %q", test.args, jerr.Code, jerr.ID, test.code, test.id)
+		}
+	}
+}
+
+func TestOAuth(t *testing.T) {
+	t.Setenv("LIT_USER", "server")
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Authorization") {
+		case "Bearer alice-token":
+			fmt.Fprint(w, `{"email": "alice@example.com"}`)
+		case "Bearer mallory-token":
+			fmt.Fprint(w, `{"email": "mallory@example.com"}`)
+		default:
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+		}
+	}))
+	defer provider.Close()
+	dir := newTracker(t)
+	id := mustRun(t, dir, "new")
+	tracker := lit.NewWithFS(lit.OSFS{}, dir)
+	if err := tracker.Load(); err != nil {
+		t.Fatal(err)
+	}
+	tracker.SetConfig("oauth-userinfo", provider.URL)
+	tracker.SetConfig("users", "alice bob")
+	tracker.SetConfig("email-alice", "alice@example.com")
+	if err := tracker.Store(); err != nil {
+		t.Fatal(err)
+	}
+
+	st := newState(dir, nil, nil, nil, nil)
+	st.username = "server"
+	proj := &project{st: st, tracker: tracker, oauth: newOAuthProvider(tracker)}
+	server := httptest.NewServer(serveMux(map[string]*project{"": proj}))
+	defer server.Close()
+
+	for token, want := range map[string]string{"alice-token": "", "mallory-token": "Forbidden", "bad": "Unauthorized"} {
+		_, err := client.New(server.URL, token).Comment(id, "from "+token)
+		if got := fmt.Sprint(err); want == "" && err != nil || want != "" && !strings.Contains(got, want) {
+			t.Errorf("comment with %s: %v, want %q", token, err, want)
+		}
+	}
+	got := mustRun(t, dir, "show", id)
+	if !strings.Contains(got, "Z alice\n~~\nfrom alice-token") {
+		t.Errorf("comment not made by alice:\n%s", got)
+	}
+	if strings.Contains(got, "mallory") {
+		t.Errorf("comment made by unknown user:\n%s", got)
+	}
+}
+
+// fakeDirectory serves LDAP simple binds, accepting only password for dn.
+func fakeDirectory(t *testing.T, dn, password string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, msg, err := readBER(bufio.NewReader(conn))
+				if err != nil {
+					return
+				}
+				mr := bufio.NewReader(bytes.NewReader(msg))
+				_, id, _ := readBER(mr)
+				_, bind, _ := readBER(mr)
+				br := bufio.NewReader(bytes.NewReader(bind))
+				readBER(br) // version
+				_, gotDN, _ := readBER(br)
+				_, gotPassword, _ := readBER(br)
+				code := byte(49) // invalidCredentials
+				if string(gotDN) == dn && string(gotPassword) == password {
+					code = 0
+				}
+				result := append(berTLV(0x0a, []byte{code}), berTLV(0x04, nil)...)
+				result = append(result, berTLV(0x04, nil)...)
+				conn.Write(berTLV(0x30, append(berTLV(0x02, id), berTLV(0x61, result)...)))
+			}()
+		}
+	}()
+	return "ldap://" + ln.Addr().String()
+}
+
+func TestLDAP(t *testing.T) {
+	t.Setenv("LIT_USER", "server")
+	dir := newTracker(t)
+	id := mustRun(t, dir, "new")
+	tracker := lit.NewWithFS(lit.OSFS{}, dir)
+	if err := tracker.Load(); err != nil {
+		t.Fatal(err)
+	}
+	tracker.SetConfig("ldap-url", fakeDirectory(t, `uid=alice\,x,ou=people,dc=example`, "secret"))
+	tracker.SetConfig("ldap-bind-dn", "uid={user},ou=people,dc=example")
+	tracker.SetConfig("users", "alice,x bob")
+	if err := tracker.Store(); err != nil {
+		t.Fatal(err)
+	}
+	ldap, err := newLDAPProvider(tracker)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st := newState(dir, nil, nil, nil, nil)
+	st.username = "server"
+	proj := &project{st: st, tracker: tracker, ldap: ldap}
+	server := httptest.NewServer(serveMux(map[string]*project{"": proj}))
+	defer server.Close()
+
+	tests := []struct {
+		user, password string
+		status         int
+	}{
+		{"alice,x", "secret", http.StatusOK},
+		{"alice,x", "wrong", http.StatusUnauthorized},
+		{"alice,x", "", http.StatusUnauthorized},
+		{"", "", http.StatusUnauthorized},
+	}
+	for _, test := range tests {
+		body := fmt.Sprintf(`{"jsonrpc": "2.0", "id": 1, "method": "comment", "params": {"id": %q, "text": "from %s"}}`,
+			id, test.password)
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/rpc", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.user != "" {
+			req.SetBasicAuth(test.user, test.password)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != test.status {
+			t.Errorf("%q, %q: status %d, want %d", test.user, test.password, resp.StatusCode, test.status)
+		}
+	}
+	got := mustRun(t, dir, "show", id)
+	if !strings.Contains(got, "Z alice,x\n~~\nfrom secret") {
+		t.Errorf("comment not made by alice,x:\n%s", got)
+	}
+	if strings.Contains(got, "from wrong") {
+		t.Errorf("comment made with wrong password:\n%s", got)
+	}
+}
+
+func TestDisplayStamps(t *testing.T) {
+	t.Setenv("LIT_USER", "tester")
+	dir := newTracker(t)
+	id := mustRun(t, dir, "new")
+	mustRun(t, dir, "set", "summary", "2026-01-01 release notes", id)
+	got := mustRun(t, dir, "--tz", "Asia/Tokyo", "show", id)
+	if !strings.Contains(got, "- summary: 2026-01-01 release notes\n") {
+		t.Errorf("summary reformatted as a stamp:\n%s", got)
+	}
+	if !strings.Contains(got, "+09:00 tester") {
+		t.Errorf("created stamp not shown in zone:\n%s", got)
+	}
+}
+
+func TestFollow(t *testing.T) {
+	t.Setenv("LIT_USER", "tester")
+	dir := newTracker(t)
+	st := newState(dir, nil, nil, nil, nil)
+	proj := &project{st: st, tracker: lit.NewWithFS(lit.OSFS{}, dir)}
+	server := httptest.NewServer(&eventsHandler{proj, 10 * time.Millisecond})
+	defer server.Close()
+	// the handler serves the stream at any path, as /events does
+	c := client.New(server.URL, "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	versions := []string{}
+	err := c.Follow(ctx, func(version string) error {
+		versions = append(versions, version)
+		if len(versions) == 1 {
+			mustRun(t, dir, "new")
+			return nil
+		}
+		cancel()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 || versions[0] == versions[1] {
+		t.Errorf("versions = %q, want two different ones", versions)
+	}
+}
+
+func TestRemoteOptions(t *testing.T) {
+	t.Setenv("LIT_USER", "tester")
+	dir := newTracker(t)
+	ids := map[string]string{}
+	for _, pri := range []string{"3", "1", "2"} {
+		ids[pri] = mustRun(t, dir, "new")
+		mustRun(t, dir, "set", "priority", pri, ids[pri])
+	}
+	st := newState(dir, nil, nil, nil, nil)
+	proj := &project{st: st, tracker: lit.NewWithFS(lit.OSFS{}, dir)}
+	server := httptest.NewServer(serveMux(map[string]*project{"": proj}))
+	defer server.Close()
+	remote := func(args ...string) (string, string, int) {
+		return runLit(t, t.TempDir(), append([]string{"--remote", server.URL}, args...)...)
+	}
+
+	if got, errOut, _ := remote("id", "rsortby", "priority", "--limit", "2", "all"); got != ids["3"]+"\n"+ids["2"] {
+		t.Errorf("id rsortby priority --limit 2 all = %q (%s)", got, errOut)
+	}
+	if got, errOut, _ := remote("with", "priority", "1"); got != ids["1"] {
+		t.Errorf("with priority 1 = %q (%s), want %q", got, errOut, ids["1"])
+	}
+	if _, errOut, status := remote("set", "created", "2020-01-01T00:00:00Z x", ids["1"]); status == 0 {
+		t.Errorf("set created without --force succeeded: %s", errOut)
+	}
+	if _, errOut, status := remote("set", "--force", "created", "2020-01-01T00:00:00Z x", ids["1"]); status != 0 {
+		t.Fatalf("set --force created: %s", errOut)
+	}
+	if got := mustRun(t, dir, "show", ids["1"]); !strings.Contains(got, "- created: 2020-01-01T00:00:00Z x") {
+		t.Errorf("forced set not made:\n%s", got)
+	}
+}
@@ -0,0 +1,686 @@
+package cli
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ianremmler/dgrl"
+	"github.com/ianremmler/lit"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// rpcRequest is a JSON-RPC 2.0 request.
+type rpcRequest struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// rpcResponse is a JSON-RPC 2.0 response.
+type rpcResponse struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcServerError    = -32000
+)
+
+// rpcParams holds the parameters accepted by the rpc methods.  Each method
+// uses the subset it needs.
+type rpcParams struct {
+	Spec []string `json:"spec"`
+	ID   string   `json:"id"`
+	Key  string   `json:"key"`
+	Val  string   `json:"val"`
+	Text string   `json:"text"`
+	Num  int      `json:"num"`
+
+	Resolution string `json:"resolution"`
+	Reason     string `json:"reason"`
+
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+
+	Sort       string `json:"sort"`
+	Descending bool   `json:"descending"`
+	Exact      bool   `json:"exact"`
+	Force      bool   `json:"force"`
+
+	Query  string   `json:"query"`
+	Fields []string `json:"fields"`
+	Closed bool     `json:"closed"`
+}
+
+type rpcMethod func(st *state, p *rpcParams) (interface{}, error)
+
+var rpcMethods = map[string]rpcMethod{
+	"ids":     (*state).rpcIds,
+	"list":    (*state).rpcList,
+	"new":     (*state).rpcNew,
+	"set":     (*state).rpcSet,
+	"next":    (*state).rpcNext,
+	"comment": (*state).rpcComment,
+	"search":  (*state).rpcSearch,
+	"close":   (*state).rpcClose,
+	"reopen":  (*state).rpcReopen,
+}
+
+func (st *state) serveCmd() {
+	switch {
+	case len(st.args) > 0 && st.args[0] == "--stdio":
+		// the caller runs as the local user, who can read the issue file
+		st.serveLocal = true
+		st.checkErr(st.serveRPC(st.stdin, st.stdout))
+	case len(st.args) > 1 && st.args[0] == "--http":
+		addr := st.args[1]
+		projects := map[string]*project{}
+		certFile, keyFile, hstsAge := "", "", defaultHSTSAge
+		hosts := []string{}
+		for st.args = st.args[2:]; len(st.args) > 0; {
+			switch {
+			case len(st.args) > 1 && st.args[0] == "--project":
+				name, proj := st.newProject(st.args[1])
+				projects[name] = proj
+				st.args = st.args[2:]
+			case len(st.args) > 2 && st.args[0] == "--tls":
+				certFile, keyFile = st.args[1], st.args[2]
+				st.args = st.args[3:]
+			case len(st.args) > 1 && st.args[0] == "--autocert":
+				hosts = strings.Split(st.args[1], ",")
+				st.args = st.args[2:]
+			case len(st.args) > 1 && st.args[0] == "--hsts":
+				age, err := strconv.Atoi(st.args[1])
+				if err != nil || age < 0 {
+					st.fatalf("serve: invalid HSTS max age %s\n", st.args[1])
+				}
+				hstsAge = age
+				st.args = st.args[2:]
+			default:
+				st.fatalf("serve: invalid option %s\n", st.args[0])
+			}
+		}
+		if len(projects) == 0 {
+			st.loadIssues()
+			ldap, err := newLDAPProvider(st.it)
+			st.checkErr(err)
+			projects[""] = &project{st: st, tracker: st.it, token: os.Getenv("LIT_TOKEN"),
+				oauth: newOAuthProvider(st.it), ldap: ldap}
+		}
+		if certFile != "" && len(hosts) > 0 {
+			st.usagef("serve: --tls and --autocert are exclusive\n")
+		}
+		mux := serveMux(projects)
+		if certFile == "" && len(hosts) == 0 {
+			st.checkErr(http.ListenAndServe(addr, mux))
+			return
+		}
+		server := &http.Server{
+			Addr:      addr,
+			Handler:   hsts(mux, hstsAge),
+			TLSConfig: &tls.Config{MinVersion: tls.VersionTLS12},
+		}
+		if len(hosts) > 0 {
+			server.TLSConfig = st.autocertConfig(hosts)
+		}
+		st.checkErr(server.ListenAndServeTLS(certFile, keyFile))
+	default:
+		st.usagef("serve: you must specify a transport (--stdio or --http <addr>)\n")
+	}
+}
+
+// serveMux routes requests to the projects' handlers, under /<name> for a
+// named project.
+func serveMux(projects map[string]*project) *http.ServeMux {
+	mux := http.NewServeMux()
+	for name, proj := range projects {
+		prefix := ""
+		if name != "" {
+			prefix = "/" + name
+		}
+		mux.Handle(prefix+"/rpc", &rpcHandler{proj})
+		mux.Handle(prefix+"/attachments/", http.StripPrefix(prefix+"/attachments/", &attachHandler{proj}))
+		mux.Handle(prefix+"/events", &eventsHandler{proj, eventsPoll})
+		mux.Handle(prefix+"/webhook/sentry", &webhookHandler{proj, parseSentry})
+		mux.Handle(prefix+"/webhook/rollbar", &webhookHandler{proj, parseRollbar})
+	}
+	return mux
+}
+
+// autocertConfig returns a TLS config using certificates for hosts obtained
+// from Let's Encrypt, cached in the user's cache directory.  The ACME account
+// email is configured as acme-email.  The http-01 challenges are answered on
+// port 80, which otherwise redirects to HTTPS.
+func (st *state) autocertConfig(hosts []string) *tls.Config {
+	cacheDir, err := os.UserCacheDir()
+	st.checkErr(err)
+	email := ""
+	if err := st.it.Load(); err == nil {
+		email, _ = st.it.Config("acme-email")
+	}
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		Cache:      autocert.DirCache(filepath.Join(cacheDir, "lit", "autocert")),
+		HostPolicy: autocert.HostWhitelist(hosts...),
+		Email:      email,
+	}
+	go func() {
+		if err := http.ListenAndServe(":http", m.HTTPHandler(nil)); err != nil {
+			st.warnf("serve: %s\n", err)
+		}
+	}()
+	config := m.TLSConfig()
+	config.MinVersion = tls.VersionTLS12
+	return config
+}
+
+// defaultHSTSAge is how long, in seconds, browsers are told to use only HTTPS.
+const defaultHSTSAge = 365 * 24 * 60 * 60
+
+// hsts adds a Strict-Transport-Security header to responses, unless maxAge is
+// 0.
+func hsts(h http.Handler, maxAge int) http.Handler {
+	if maxAge == 0 {
+		return h
+	}
+	val := fmt.Sprintf("max-age=%d", maxAge)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Strict-Transport-Security", val)
+		h.ServeHTTP(w, r)
+	})
+}
+
+// project is a tracker served over HTTP.  If token is set, requests must
+// carry it, or, if oauth is set, a token from the OAuth provider, in which
+// case changes are made by the user it was issued to, or, if ldap is set, the
+// name and password of a user of the directory, who makes the changes.
+type project struct {
+	st      *state
+	tracker *lit.Lit
+	token   string
+	oauth   *oauthProvider
+	ldap    *ldapProvider
+}
+
+// newProject parses a "<name>=<dir>" project spec.  Its token is taken from
+// the LIT_TOKEN_<NAME> environment variable, with dashes as underscores,
+// falling back to LIT_TOKEN.
+func (st *state) newProject(spec string) (string, *project) {
+	parts := strings.SplitN(spec, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || strings.Contains(parts[0], "/") {
+		st.fatalf("serve: invalid project %s (want <name>=<dir>)\n", spec)
+	}
+	name, dir := parts[0], parts[1]
+	tracker := lit.NewWithFS(lit.OSFS{}, dir)
+	if err := tracker.Load(); err != nil {
+		st.fatalf("serve: project %s: %s\n", name, err)
+	}
+	env := "LIT_TOKEN_" + strings.ToUpper(strings.Replace(name, "-", "_", -1))
+	token, ok := os.LookupEnv(env)
+	if !ok {
+		token = os.Getenv("LIT_TOKEN")
+	}
+	ldap, err := newLDAPProvider(tracker)
+	if err != nil {
+		st.fatalf("serve: project %s: %s\n", name, err)
+	}
+	return name, &project{st: st, tracker: tracker, token: token, oauth: newOAuthProvider(tracker), ldap: ldap}
+}
+
+// use makes the project's tracker the one commands work with.  serveMu must
+// be held.
+func (p *project) use() {
+	p.st.it = p.tracker
+}
+
+// authenticate reports whether a request carries the project token, if any,
+// or a token from the OAuth provider, as a bearer token, or the basic
+// authentication of a directory user.  For the latter two, the identity the
+// provider gives is returned.
+func (p *project) authenticate(r *http.Request) (string, bool) {
+	if p.token == "" && p.oauth == nil && p.ldap == nil {
+		return "", true
+	}
+	auth := r.Header.Get("Authorization")
+	if p.token != "" && subtle.ConstantTimeCompare([]byte(auth), []byte("Bearer "+p.token)) == 1 {
+		return "", true
+	}
+	if user, password, ok := r.BasicAuth(); ok && p.ldap != nil {
+		identity, err := p.ldap.identity(user, password)
+		return identity, err == nil
+	}
+	if p.oauth == nil || !strings.HasPrefix(auth, "Bearer ") {
+		return "", false
+	}
+	identity, err := p.oauth.identity(strings.TrimPrefix(auth, "Bearer "))
+	return identity, err == nil
+}
+
+// signIn makes the user with the given identity, if any, the one changes are
+// made by, returning a function restoring the server's user.  serveMu must be
+// held.
+func (p *project) signIn(identity string) (func(), error) {
+	user := p.st.username
+	if identity == "" {
+		return func() {}, nil
+	}
+	if err := p.tracker.Load(); err != nil {
+		return nil, err
+	}
+	name, ok := p.tracker.CanonicalUser(identity)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a user of this tracker", identity)
+	}
+	p.st.username = name
+	return func() { p.st.username = user }, nil
+}
+
+// rpcHandler serves JSON-RPC requests, one per POST, over HTTP.
+type rpcHandler struct {
+	*project
+}
+
+func (h *rpcHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	identity, ok := h.authenticate(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(r.Body, 1<<24))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	st := h.st
+	st.serveMu.Lock()
+	h.use()
+	signOut, err := h.signIn(identity)
+	if err != nil {
+		st.serveMu.Unlock()
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	resp := st.handleRPC(data)
+	signOut()
+	st.serveMu.Unlock()
+	if resp == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		st.warnf("serve: %s\n", err)
+	}
+}
+
+// attachHandler serves attachments, GET at <id>/<name>, with support for
+// Range requests.
+type attachHandler struct {
+	*project
+}
+
+func (h *attachHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if _, ok := h.authenticate(r); !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	parts := strings.SplitN(r.URL.Path, "/", 2)
+	if len(parts) != 2 {
+		http.NotFound(w, r)
+		return
+	}
+	st := h.st
+	st.serveMu.Lock()
+	h.use()
+	reader, att, err := st.openAttachment(parts[0], parts[1])
+	st.serveMu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	defer reader.Close()
+	if att.Type != "" {
+		w.Header().Set("Content-Type", att.Type)
+	}
+	added, _, _ := lit.ParseStamp(att.Added)
+	http.ServeContent(w, r, att.Name, added, reader)
+}
+
+// eventsPoll is how often the events handler checks for changes.
+const eventsPoll = time.Second
+
+// eventsHandler serves a server-sent event stream, GET, with a "changed"
+// event, carrying the tracker's version, when connecting and whenever the
+// issues change thereafter, whether through the server or not.
+type eventsHandler struct {
+	*project
+	poll time.Duration
+}
+
+func (h *eventsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if _, ok := h.authenticate(r); !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	ticker := time.NewTicker(h.poll)
+	defer ticker.Stop()
+	last, idle := "", time.Duration(0)
+	for {
+		version, err := h.tracker.Version()
+		switch {
+		case err == nil && version != last:
+			fmt.Fprintf(w, "event: changed\ndata: %s\n\n", version)
+			last, idle = version, 0
+		case idle >= 30*time.Second:
+			// keep the connection from looking idle to proxies
+			fmt.Fprint(w, ": ping\n\n")
+			idle = 0
+		}
+		flusher.Flush()
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			idle += h.poll
+		}
+	}
+}
+
+func (st *state) openAttachment(id, name string) (lit.AttachmentReader, *lit.Attachment, error) {
+	if err := st.it.Load(); err != nil {
+		return nil, nil, err
+	}
+	issue, err := st.rpcLookup(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return st.it.OpenAttachment(issue, name)
+}
+
+// serveRPC reads newline-delimited JSON-RPC requests from r and writes one
+// response per line to w.
+func (st *state) serveRPC(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<24)
+	enc := json.NewEncoder(w)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		resp := st.handleRPC(scanner.Bytes())
+		if resp == nil {
+			continue
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func (st *state) handleRPC(data []byte) *rpcResponse {
+	req := &rpcRequest{}
+	if err := json.Unmarshal(data, req); err != nil {
+		return rpcFail(nil, rpcParseError, err)
+	}
+	if req.Version != "2.0" || req.Method == "" {
+		return rpcFail(req.ID, rpcInvalidRequest, errors.New("invalid request"))
+	}
+	method, ok := rpcMethods[req.Method]
+	if !ok {
+		return rpcFail(req.ID, rpcMethodNotFound, fmt.Errorf("unknown method '%s'", req.Method))
+	}
+	params := &rpcParams{}
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, params); err != nil {
+			return rpcFail(req.ID, rpcInvalidParams, err)
+		}
+	}
+	// reload for each request, since the file may be changed by other commands
+	if err := st.it.Load(); err != nil {
+		return rpcFail(req.ID, rpcServerError, err)
+	}
+	result, err := method(st, params)
+	if req.ID == nil {
+		return nil // notification
+	}
+	if err != nil {
+		return rpcFail(req.ID, rpcServerError, err)
+	}
+	return &rpcResponse{Version: "2.0", ID: req.ID, Result: result}
+}
+
+func rpcFail(id json.RawMessage, code int, err error) *rpcResponse {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &rpcResponse{Version: "2.0", ID: id, Error: &rpcError{Code: code, Message: err.Error()}}
+}
+
+// rpcSpecIds returns the ids of the issues matching a spec.  Confidential
+// issues are served only to the local user.
+func (st *state) rpcSpecIds(p *rpcParams) ([]string, error) {
+	st.args = p.Spec
+	ids, err := st.specIdsErr()
+	if st.serveLocal {
+		return ids, err
+	}
+	return st.it.Public(ids), err
+}
+
+// rpcLookup returns the issue for an id, unless it is confidential and the
+// caller isn't the local user.
+func (st *state) rpcLookup(id string) (*dgrl.Branch, error) {
+	issue, err := st.it.Lookup(id)
+	if err == nil && !st.serveLocal && lit.IsConfidential(issue) {
+		return nil, &lit.NotFoundError{ID: id}
+	}
+	return issue, err
+}
+
+// rpcPage returns the ids of the issues matching a spec, sorted and paged as
+// requested.
+func (st *state) rpcPage(p *rpcParams) ([]string, error) {
+	matches, err := st.rpcSpecIds(p)
+	if err != nil {
+		return nil, err
+	}
+	if p.Sort != "" {
+		st.it.Sort(matches, p.Sort, !p.Descending)
+	}
+	return lit.Page(matches, p.Offset, p.Limit), nil
+}
+
+func (st *state) rpcIds(p *rpcParams) (interface{}, error) {
+	matches, err := st.rpcPage(p)
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	for _, id := range matches {
+		if issue := st.it.Issue(id); issue != nil {
+			ids = append(ids, issue.Key())
+		}
+	}
+	return ids, nil
+}
+
+func (st *state) rpcList(p *rpcParams) (interface{}, error) {
+	matches, err := st.rpcPage(p)
+	if err != nil {
+		return nil, err
+	}
+	issues := []*lit.IssueData{}
+	for _, id := range matches {
+		if issue := st.it.Issue(id); issue != nil {
+			data := lit.Data(issue)
+			if st.it.Project() != "" {
+				data.Ref = st.it.PrefixedID(issue)
+			}
+			issues = append(issues, data)
+		}
+	}
+	return issues, nil
+}
+
+func (st *state) rpcSearch(p *rpcParams) (interface{}, error) {
+	opts := lit.SearchOptions{Fields: p.Fields, IncludeClosed: p.Closed, Public: !st.serveLocal,
+		Offset: p.Offset, Limit: p.Limit}
+	return st.it.Search(p.Query, opts), nil
+}
+
+func (st *state) rpcNew(p *rpcParams) (interface{}, error) {
+	num := p.Num
+	if num < 1 {
+		num = 1
+	}
+	ids := []string{}
+	for _, issue := range st.it.NewIssues(st.username, num) {
+		ids = append(ids, issue.Key())
+	}
+	return ids, st.it.Store()
+}
+
+func (st *state) rpcSet(p *rpcParams) (interface{}, error) {
+	if p.Key == "" {
+		return nil, errors.New("you must specify a key")
+	}
+	matches, err := st.rpcSpecIds(p)
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	for _, id := range matches {
+		issue, err := st.it.Lookup(id)
+		if err != nil {
+			return nil, err
+		}
+		key := p.Key
+		if !p.Exact && !p.Force {
+			if key, err = lit.ResolveKey(issue, p.Key); err != nil {
+				return nil, err
+			}
+		}
+		if err := st.it.SetField(issue, st.username, key, p.Val, p.Force); err != nil {
+			return nil, err
+		}
+		ids = append(ids, issue.Key())
+	}
+	return ids, st.rpcStore()
+}
+
+// rpcNext returns the workflow states an issue may move to.
+func (st *state) rpcNext(p *rpcParams) (interface{}, error) {
+	wf, err := st.it.Workflow()
+	if err != nil {
+		return nil, err
+	}
+	if wf == nil {
+		return nil, errors.New("no workflow is configured")
+	}
+	issue, err := st.rpcLookup(p.ID)
+	if err != nil {
+		return nil, err
+	}
+	return wf.NextStates(issue), nil
+}
+
+func (st *state) rpcComment(p *rpcParams) (interface{}, error) {
+	issue, err := st.rpcLookup(p.ID)
+	if err != nil {
+		return nil, err
+	}
+	stamp, err := st.it.Comment(issue, st.username, p.Text)
+	if err != nil {
+		return nil, err
+	}
+	return stamp, st.rpcStore()
+}
+
+func (st *state) rpcClose(p *rpcParams) (interface{}, error) {
+	return st.rpcSetClosed(p, true)
+}
+
+func (st *state) rpcReopen(p *rpcParams) (interface{}, error) {
+	return st.rpcSetClosed(p, false)
+}
+
+func (st *state) rpcSetClosed(p *rpcParams, doClose bool) (interface{}, error) {
+	matches, err := st.rpcSpecIds(p)
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	for _, id := range matches {
+		issue, err := st.it.Lookup(id)
+		if err != nil {
+			return nil, err
+		}
+		if doClose {
+			err = st.it.Close(issue, st.username, p.Resolution, p.Reason)
+		} else {
+			err = st.it.Reopen(issue, st.username)
+		}
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, issue.Key())
+	}
+	return ids, st.rpcStore()
+}
+
+// rpcStore stores the changes made by a method and sends their events, as
+// storeIssues does for commands.  Failures to notify are logged, but don't
+// fail the method.
+func (st *state) rpcStore() error {
+	if err := st.it.Store(); err != nil {
+		return err
+	}
+	for _, err := range st.it.SendEvents() {
+		st.warnf("serve: notify: %s\n", err)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/ianremmler/dgrl"
+	"github.com/ianremmler/lit"
+)
+
+const triageHelp = "0-9: priority  t: tag  a: assign  c: close  s: skip  q: quit"
+
+// triageCmd steps through open issues without a priority, applying single
+// keystroke actions to each.  Changes are stored when done or on quit.
+func (st *state) triageCmd() {
+	if len(st.args) == 0 {
+		st.args = []string{"open"}
+	}
+	st.loadIssues()
+	ids := []string{}
+	for _, id := range st.specIds() {
+		issue := st.findIssue(id)
+		closed, _ := lit.Get(issue, "closed")
+		priority, _ := lit.Get(issue, "priority")
+		if issue != nil && closed == "" && priority == "" {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		fmt.Fprintln(st.stdout, "nothing to triage")
+		return
+	}
+	defer st.storeIssues()
+	fmt.Fprintln(st.stdout, triageHelp)
+	for i, id := range ids {
+		issue := st.findIssue(id)
+		fmt.Fprintf(st.stdout, "\n[%d/%d]\n%s\n%s\n", i+1, len(ids), st.listHeader(), st.listInfo(issue))
+		if !st.triageIssue(issue) {
+			return
+		}
+	}
+}
+
+// triageIssue prompts for actions on an issue until it is done with, and
+// returns false if the user quits.
+func (st *state) triageIssue(issue *dgrl.Branch) bool {
+	for {
+		fmt.Fprint(st.stdout, "> ")
+		key := st.readKey()
+		fmt.Fprintln(st.stdout, key)
+		stamp := st.it.Stamp(st.username)
+		switch {
+		case key >= "0" && key <= "9":
+			if st.triageSet(issue, "priority", key) {
+				return true
+			}
+		case key == "t":
+			if tag := st.prompt("tag: "); tag != "" {
+				if lit.ModifyTag(issue, tag, true) && lit.Touch(issue, stamp) {
+					st.recordEvent(issue, "added tag "+tag+" to", "")
+				}
+			}
+		case key == "a":
+			if user := st.prompt("assign to: "); user != "" {
+				st.triageSet(issue, "assigned", user)
+			}
+		case key == "c":
+			if err := st.it.Close(issue, st.username, "", ""); err != nil {
+				st.warnf("triage: %s\n", err)
+				continue
+			}
+			return true
+		case key == "s":
+			return true
+		case key == "q", key == "":
+			return false
+		default:
+			fmt.Fprintln(st.stdout, triageHelp)
+		}
+	}
+}
+
+func (st *state) triageSet(issue *dgrl.Branch, key, val string) bool {
+	if err := st.it.SetField(issue, st.username, key, val, false); err != nil {
+		st.warnf("triage: %s\n", err)
+		return false
+	}
+	return true
+}
+
+// readKey reads a single keystroke, without waiting for enter if the terminal
+// allows.  It returns an empty string at the end of input.
+func (st *state) readKey() string {
+	if err := st.stty("cbreak", "-echo"); err == nil {
+		defer st.stty("-cbreak", "echo")
+	}
+	r, _, err := st.stdinReader.ReadRune()
+	if err != nil {
+		return ""
+	}
+	return string(r)
+}
+
+func (st *state) prompt(msg string) string {
+	fmt.Fprint(st.stdout, msg)
+	line, _ := st.stdinReader.ReadString('\n')
+	return strings.TrimSpace(line)
+}
+
+func (st *state) stty(settings ...string) error {
+	cmd := exec.Command("stty", settings...)
+	cmd.Stdin = st.stdin
+	return cmd.Run()
+}
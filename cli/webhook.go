@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/ianremmler/lit"
+)
+
+// sentryPayload holds the parts used of a Sentry issue alert webhook.
+type sentryPayload struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+	URL     string `json:"url"`
+	Event   struct {
+		Title       string   `json:"title"`
+		Fingerprint []string `json:"fingerprint"`
+	} `json:"event"`
+}
+
+// rollbarPayload holds the parts used of a Rollbar webhook.
+type rollbarPayload struct {
+	Data struct {
+		URL  string `json:"url"`
+		Item struct {
+			ID    json.Number `json:"id"`
+			Title string      `json:"title"`
+		} `json:"item"`
+	} `json:"data"`
+}
+
+func parseSentry(data []byte) (lit.ErrorReport, error) {
+	p := sentryPayload{}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return lit.ErrorReport{}, err
+	}
+	rep := lit.ErrorReport{Title: p.Event.Title, URL: p.URL}
+	if rep.Title == "" {
+		rep.Title = p.Message
+	}
+	if p.ID != "" {
+		rep.Fingerprint = "sentry:" + p.ID
+	} else if len(p.Event.Fingerprint) > 0 {
+		rep.Fingerprint = "sentry:" + strings.Join(p.Event.Fingerprint, ",")
+	}
+	return rep, nil
+}
+
+func parseRollbar(data []byte) (lit.ErrorReport, error) {
+	p := rollbarPayload{}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return lit.ErrorReport{}, err
+	}
+	rep := lit.ErrorReport{Title: p.Data.Item.Title, URL: p.Data.URL}
+	if p.Data.Item.ID != "" {
+		rep.Fingerprint = "rollbar:" + p.Data.Item.ID.String()
+	}
+	return rep, nil
+}
+
+// webhookHandler records errors POSTed by an error tracker.  The project
+// token may be given as a bearer token or token query parameter, since not
+// all trackers can set headers.
+type webhookHandler struct {
+	*project
+	parse func(data []byte) (lit.ErrorReport, error)
+}
+
+func (h *webhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	auth := r.URL.Query().Get("token")
+	if auth == "" {
+		auth = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}
+	if h.token != "" && subtle.ConstantTimeCompare([]byte(auth), []byte(h.token)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(r.Body, 1<<24))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rep, err := h.parse(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	st := h.st
+	st.serveMu.Lock()
+	defer st.serveMu.Unlock()
+	h.use()
+	if err := st.it.Load(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	issue, _, err := st.it.RecordError(st.username, rep)
+	if err == nil {
+		err = st.it.Store()
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	fmt.Fprintln(w, issue.Key())
+}
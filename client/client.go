@@ -0,0 +1,302 @@
+// Package client provides access to a lit issue tracker served by "lit
+// serve", through the same Tracker interface as a local *lit.Lit, so that
+// tools work the same either way.
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/ianremmler/dgrl"
+	"github.com/ianremmler/lit"
+)
+
+// Tracker is the set of operations tools use on a tracker.  It is a subset of
+// the methods of *lit.Lit, so a local tracker is one, as is a served one
+// through a Remote.
+type Tracker interface {
+	Load() error
+	Store() error
+	IssueIds() []string
+	Lookup(id string) (*dgrl.Branch, error)
+	Match(key, val string, doesMatch bool) ([]string, error)
+	Search(query string, opts lit.SearchOptions) []lit.SearchResult
+	NewIssues(username string, num int) []*dgrl.Branch
+	SetField(issue *dgrl.Branch, username, key, val string, force bool) error
+	Comment(issue *dgrl.Branch, username, text string) (string, error)
+	Close(issue *dgrl.Branch, username, resolution, reason string) error
+	Reopen(issue *dgrl.Branch, username string) error
+}
+
+var (
+	_ Tracker = (*lit.Lit)(nil)
+	_ Tracker = (*Remote)(nil)
+)
+
+// Error is an error returned by the tracker.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+type request struct {
+	Version string      `json:"jsonrpc"`
+	ID      int         `json:"id"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+type response struct {
+	ID     int             `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *Error          `json:"error"`
+}
+
+type params struct {
+	Spec       []string `json:"spec,omitempty"`
+	ID         string   `json:"id,omitempty"`
+	Key        string   `json:"key,omitempty"`
+	Val        string   `json:"val,omitempty"`
+	Text       string   `json:"text,omitempty"`
+	Num        int      `json:"num,omitempty"`
+	Resolution string   `json:"resolution,omitempty"`
+	Reason     string   `json:"reason,omitempty"`
+	Offset     int      `json:"offset,omitempty"`
+	Limit      int      `json:"limit,omitempty"`
+	Sort       string   `json:"sort,omitempty"`
+	Descending bool     `json:"descending,omitempty"`
+	Exact      bool     `json:"exact,omitempty"`
+	Force      bool     `json:"force,omitempty"`
+	Query      string   `json:"query,omitempty"`
+	Fields     []string `json:"fields,omitempty"`
+	Closed     bool     `json:"closed,omitempty"`
+}
+
+// transport sends an encoded request and returns the encoded response.
+type transport func(req []byte) ([]byte, error)
+
+// Client makes requests of a served tracker through a transport.  Specs are
+// given as on the lit command line, e.g. "open" or "with", "tags", "bug".
+type Client struct {
+	send   transport
+	mu     sync.Mutex
+	nextID int
+
+	url, token string
+}
+
+// New returns a client of the tracker served over HTTP at url, e.g.
+// "https://tracker.example.com".  If token is set, it is sent as a bearer
+// token.
+func New(url, token string) *Client {
+	httpClient := &http.Client{}
+	return &Client{url: url, token: token, send: func(req []byte) ([]byte, error) {
+		httpReq, err := http.NewRequest(http.MethodPost, url+"/rpc", bytes.NewReader(req))
+		if err != nil {
+			return nil, err
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := httpClient.Do(httpReq)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("%s: %s", url, resp.Status)
+		}
+		return ioutil.ReadAll(resp.Body)
+	}}
+}
+
+// NewLocal returns the tracker found from dir, loaded.
+func NewLocal(dir string) (*lit.Lit, error) {
+	l := lit.NewWithFS(lit.OSFS{}, dir)
+	return l, l.Load()
+}
+
+func (c *Client) call(method string, p *params, result interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nextID++
+	req, err := json.Marshal(&request{Version: "2.0", ID: c.nextID, Method: method, Params: p})
+	if err != nil {
+		return err
+	}
+	data, err := c.send(req)
+	if err != nil {
+		return err
+	}
+	resp := &response{}
+	if err := json.Unmarshal(data, resp); err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if resp.ID != c.nextID {
+		return errors.New("response does not match request")
+	}
+	return json.Unmarshal(resp.Result, result)
+}
+
+// ListOptions order and page the issues matching a spec, as sortby or
+// rsortby, --offset, and --limit do on the command line.
+type ListOptions struct {
+	SortBy     string
+	Descending bool
+	Offset     int
+	Limit      int
+}
+
+func (o ListOptions) params(spec []string) *params {
+	return &params{Spec: spec, Sort: o.SortBy, Descending: o.Descending, Offset: o.Offset, Limit: o.Limit}
+}
+
+// Ids returns the ids of the issues matching spec.
+func (c *Client) Ids(spec ...string) ([]string, error) {
+	return c.IdsWith(ListOptions{}, spec...)
+}
+
+// IdsWith returns the ids of the issues matching spec, ordered and paged by
+// opts.
+func (c *Client) IdsWith(opts ListOptions, spec ...string) ([]string, error) {
+	ids := []string{}
+	err := c.call("ids", opts.params(spec), &ids)
+	return ids, err
+}
+
+// List returns the issues matching spec.
+func (c *Client) List(spec ...string) ([]*lit.IssueData, error) {
+	return c.ListWith(ListOptions{}, spec...)
+}
+
+// ListWith returns the issues matching spec, ordered and paged by opts.
+func (c *Client) ListWith(opts ListOptions, spec ...string) ([]*lit.IssueData, error) {
+	issues := []*lit.IssueData{}
+	err := c.call("list", opts.params(spec), &issues)
+	return issues, err
+}
+
+// Search returns the issues containing every word of query, as for
+// lit.Search.
+func (c *Client) Search(query string, opts lit.SearchOptions) ([]lit.SearchResult, error) {
+	results := []lit.SearchResult{}
+	p := &params{Query: query, Fields: opts.Fields, Closed: opts.IncludeClosed, Offset: opts.Offset, Limit: opts.Limit}
+	err := c.call("search", p, &results)
+	return results, err
+}
+
+// New adds num new issues and returns their ids.
+func (c *Client) New(num int) ([]string, error) {
+	ids := []string{}
+	err := c.call("new", &params{Num: num}, &ids)
+	return ids, err
+}
+
+// Set sets key to val in the issues matching spec and returns their ids.
+func (c *Client) Set(key, val string, spec ...string) ([]string, error) {
+	ids := []string{}
+	err := c.call("set", &params{Key: key, Val: val, Spec: spec}, &ids)
+	return ids, err
+}
+
+// SetExact sets key, which must match exactly rather than as a prefix, to val
+// in the issues matching spec and returns their ids.  With force, reserved
+// keys may be set too.
+func (c *Client) SetExact(key, val string, force bool, spec ...string) ([]string, error) {
+	ids := []string{}
+	err := c.call("set", &params{Key: key, Val: val, Exact: true, Force: force, Spec: spec}, &ids)
+	return ids, err
+}
+
+// NextStates returns the workflow states an issue may move to, by setting its
+// status.
+func (c *Client) NextStates(id string) ([]string, error) {
+	states := []string{}
+	err := c.call("next", &params{ID: id}, &states)
+	return states, err
+}
+
+// Comment adds a comment to an issue and returns its stamp.
+func (c *Client) Comment(id, text string) (string, error) {
+	stamp := ""
+	err := c.call("comment", &params{ID: id, Text: text}, &stamp)
+	return stamp, err
+}
+
+// Close closes the issues matching spec and returns their ids.
+func (c *Client) Close(spec ...string) ([]string, error) {
+	ids := []string{}
+	err := c.call("close", &params{Spec: spec}, &ids)
+	return ids, err
+}
+
+// Reopen reopens the issues matching spec and returns their ids.
+func (c *Client) Reopen(spec ...string) ([]string, error) {
+	ids := []string{}
+	err := c.call("reopen", &params{Spec: spec}, &ids)
+	return ids, err
+}
+
+// Follow calls changed with the tracker's version when connected to its event
+// stream, and again whenever its issues change.  It returns when the stream
+// ends, with an error unless ctx was canceled, or when changed returns an
+// error.
+func (c *Client) Follow(ctx context.Context, changed func(version string) error) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+"/events", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", c.url, resp.Status)
+	}
+	event, data := "", ""
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if event == "changed" {
+				if err := changed(data); err != nil {
+					return err
+				}
+			}
+			event, data = "", ""
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return errors.New("event stream ended")
+}
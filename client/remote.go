@@ -0,0 +1,173 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ianremmler/dgrl"
+	"github.com/ianremmler/lit"
+)
+
+// Remote is a Tracker served by "lit serve".  Load fetches the tracker's
+// issues, which IssueIds and Lookup read.  Changes are made on the server as
+// they are requested, by the server's user rather than the username given,
+// and the issues they return or change are fetched again, so issues looked up
+// before a change don't show it.  Store returns the first error from the
+// methods that can't return one themselves.
+type Remote struct {
+	c      *Client
+	issues map[string]*dgrl.Branch
+	err    error
+}
+
+// NewRemote returns the tracker served over HTTP at url, as for New, loaded.
+func NewRemote(url, token string) (*Remote, error) {
+	r := &Remote{c: New(url, token)}
+	return r, r.Load()
+}
+
+// Load fetches the issues of the tracker.
+func (r *Remote) Load() error {
+	issues, err := r.c.List("all")
+	if err != nil {
+		return err
+	}
+	r.issues = map[string]*dgrl.Branch{}
+	r.add(issues)
+	return nil
+}
+
+// Store returns the first error from NewIssues or Search since the last Store,
+// since changes are already on the server.
+func (r *Remote) Store() error {
+	err := r.err
+	r.err = nil
+	return err
+}
+
+func (r *Remote) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// add keeps fetched issues, returning them as branches.
+func (r *Remote) add(data []*lit.IssueData) []*dgrl.Branch {
+	if r.issues == nil {
+		r.issues = map[string]*dgrl.Branch{}
+	}
+	issues := []*dgrl.Branch{}
+	for _, d := range data {
+		issue := lit.FromData(d)
+		r.issues[issue.Key()] = issue
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// fetch fetches the issues matching spec again.
+func (r *Remote) fetch(spec ...string) ([]*dgrl.Branch, error) {
+	data, err := r.c.List(spec...)
+	if err != nil {
+		return nil, err
+	}
+	return r.add(data), nil
+}
+
+// IssueIds returns the sorted ids of the issues fetched.
+func (r *Remote) IssueIds() []string {
+	ids := make([]string, 0, len(r.issues))
+	for id := range r.issues {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Lookup returns an issue for the given id, prefix, or alias, fetching it if
+// it wasn't loaded.
+func (r *Remote) Lookup(id string) (*dgrl.Branch, error) {
+	if issue, ok := r.issues[id]; ok {
+		return issue, nil
+	}
+	issues, err := r.fetch(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(issues) != 1 {
+		return nil, fmt.Errorf("error finding issue %s", id)
+	}
+	return issues[0], nil
+}
+
+// Match returns the ids of the issues whose value for key matches val, or
+// doesn't.
+func (r *Remote) Match(key, val string, doesMatch bool) ([]string, error) {
+	with := "with"
+	if !doesMatch {
+		with = "without"
+	}
+	return r.c.Ids(with, key, val)
+}
+
+// Search returns the issues containing every word of query.
+func (r *Remote) Search(query string, opts lit.SearchOptions) []lit.SearchResult {
+	results, err := r.c.Search(query, opts)
+	if err != nil {
+		r.fail(err)
+	}
+	return results
+}
+
+// NewIssues adds num new issues and returns them.
+func (r *Remote) NewIssues(username string, num int) []*dgrl.Branch {
+	ids, err := r.c.New(num)
+	if err != nil {
+		r.fail(err)
+		return nil
+	}
+	issues, err := r.fetch(ids...)
+	if err != nil {
+		r.fail(err)
+	}
+	return issues
+}
+
+// SetField sets a field of an issue, reserved ones only if force is set.
+func (r *Remote) SetField(issue *dgrl.Branch, username, key, val string, force bool) error {
+	if _, err := r.c.SetExact(key, val, force, issue.Key()); err != nil {
+		return err
+	}
+	_, err := r.fetch(issue.Key())
+	return err
+}
+
+// Comment adds a comment to an issue, returning its stamp.
+func (r *Remote) Comment(issue *dgrl.Branch, username, text string) (string, error) {
+	stamp, err := r.c.Comment(issue.Key(), text)
+	if err != nil {
+		return "", err
+	}
+	_, err = r.fetch(issue.Key())
+	return stamp, err
+}
+
+// Close closes an issue, with an optional resolution and reason.
+func (r *Remote) Close(issue *dgrl.Branch, username, resolution, reason string) error {
+	ids := []string{}
+	p := &params{Spec: []string{issue.Key()}, Resolution: resolution, Reason: reason}
+	if err := r.c.call("close", p, &ids); err != nil {
+		return err
+	}
+	_, err := r.fetch(issue.Key())
+	return err
+}
+
+// Reopen reopens an issue.
+func (r *Remote) Reopen(issue *dgrl.Branch, username string) error {
+	if _, err := r.c.Reopen(issue.Key()); err != nil {
+		return err
+	}
+	_, err := r.fetch(issue.Key())
+	return err
+}
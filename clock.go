@@ -0,0 +1,53 @@
+package lit
+
+import (
+	"fmt"
+	"time"
+)
+
+// Clock tells the time.  It lets applications and tests control the time
+// used for stamps.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a function to a Clock.
+type ClockFunc func() time.Time
+
+// Now returns f().
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// SystemClock is the Clock of the system.
+var SystemClock Clock = ClockFunc(time.Now)
+
+// SetClock sets the clock used by the tracker.  The default is SystemClock.
+func (l *Lit) SetClock(clock Clock) {
+	l.clock = clock
+}
+
+// Now returns the current time by the tracker's clock, or the system's for a
+// nil tracker.
+func (l *Lit) Now() time.Time {
+	if l == nil || l.clock == nil {
+		return SystemClock.Now()
+	}
+	return l.clock.Now()
+}
+
+// Stamp returns a string consisting of the current time in RFC3339 UTC format
+// and the username, separated by a space.  Changes to a tracker's issues are
+// stamped by its clock instead, with its Stamp method.
+func Stamp(username string) string {
+	return stampAt(SystemClock.Now(), username)
+}
+
+// Stamp is like the Stamp function, but uses the tracker's clock.
+func (l *Lit) Stamp(username string) string {
+	return stampAt(l.Now(), username)
+}
+
+func stampAt(t time.Time, username string) string {
+	return fmt.Sprintf("%s %s", t.UTC().Format(time.RFC3339), username)
+}
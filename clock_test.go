@@ -0,0 +1,106 @@
+package lit
+
+import (
+	"testing"
+	"time"
+)
+
+// clockTracker returns a tracker with a clock set to testTime, and one new
+// issue in it.
+func clockTracker(t *testing.T) (*Lit, string) {
+	t.Helper()
+	l := memTracker(t, 1)
+	l.SetClock(ClockFunc(func() time.Time { return testTime }))
+	return l, l.IssueIds()[0]
+}
+
+func TestClockStamps(t *testing.T) {
+	want := "2024-03-01T12:00:00Z tester"
+	tests := []struct {
+		name   string
+		change func(l *Lit, id string) error
+	}{
+		{"comment", func(l *Lit, id string) error {
+			_, err := l.Comment(l.Issue(id), "tester", "hi")
+			return err
+		}},
+		{"description", func(l *Lit, id string) error {
+			return l.SetDescription(l.Issue(id), "tester", "text")
+		}},
+		{"relation", func(l *Lit, id string) error {
+			return l.AddRelation(l.Issue(id), "tester", "references", "0123abcd")
+		}},
+		{"review", func(l *Lit, id string) error {
+			l.Review(l.Issue(id), "tester", true, "")
+			return nil
+		}},
+		{"ci", func(l *Lit, id string) error {
+			return l.ReportCI(l.Issue(id), "tester", "passed", "")
+		}},
+		{"close", func(l *Lit, id string) error {
+			return l.Close(l.Issue(id), "tester", "", "")
+		}},
+	}
+	for _, test := range tests {
+		l, id := clockTracker(t)
+		if err := test.change(l, id); err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if got, _ := GetExact(l.Issue(id), "updated"); got != want {
+			t.Errorf("%s: updated = %q, want %q", test.name, got, want)
+		}
+	}
+	l, _ := clockTracker(t)
+	issue := l.NewIssues("tester", 1)[0]
+	if got, _ := GetExact(issue, "created"); got != want {
+		t.Errorf("new issue created = %q, want %q", got, want)
+	}
+}
+
+func TestStale(t *testing.T) {
+	l, id := clockTracker(t)
+	if err := l.SetDescription(l.Issue(id), "tester", "text"); err != nil {
+		t.Fatal(err)
+	}
+	ids := []string{id}
+	tests := []struct {
+		after time.Duration
+		stale bool
+	}{
+		{0, false},
+		{24 * time.Hour, false},
+		{49 * time.Hour, true},
+	}
+	for _, test := range tests {
+		got := l.Stale(ids, 48*time.Hour, testTime.Add(test.after))
+		if (len(got) == 1) != test.stale {
+			t.Errorf("%s after update: Stale = %v, want stale %v", test.after, got, test.stale)
+		}
+	}
+	if err := l.Close(l.Issue(id), "tester", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := l.Stale(ids, time.Hour, testTime.Add(72*time.Hour)); len(got) != 0 {
+		t.Errorf("closed issue is stale: %v", got)
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := map[string]time.Duration{
+		"90m":  90 * time.Minute,
+		"2d":   48 * time.Hour,
+		"1.5d": 36 * time.Hour,
+		"1w":   7 * 24 * time.Hour,
+	}
+	for age, want := range tests {
+		if got, err := ParseAge(age); err != nil || got != want {
+			t.Errorf("ParseAge(%q) = %s, %v, want %s", age, got, err, want)
+		}
+	}
+	for _, age := range []string{"xd", "", "3"} {
+		if _, err := ParseAge(age); err == nil {
+			t.Errorf("ParseAge(%q): no error", age)
+		}
+	}
+}
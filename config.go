@@ -0,0 +1,173 @@
+package lit
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ianremmler/dgrl"
+)
+
+const configFilename = "config"
+
+func (l *Lit) loadConfig() error {
+	l.config = dgrl.NewRoot()
+	l.configDirty = false
+	data, err := l.fs.ReadFile(filepath.Join(l.issueDir, configFilename))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	config := dgrl.NewParser().Parse(bytes.NewReader(data))
+	if config == nil {
+		return errors.New("error parsing config file")
+	}
+	l.config = config
+	return nil
+}
+
+func (l *Lit) storeConfig() error {
+	buf := &bytes.Buffer{}
+	if err := l.config.Write(buf); err != nil {
+		return err
+	}
+	if err := l.fs.WriteFile(filepath.Join(l.issueDir, configFilename), buf.Bytes(), 0666); err != nil {
+		return err
+	}
+	l.configDirty = false
+	return nil
+}
+
+// Config returns the configured value for key.  Unlike Get, the key must
+// match exactly.
+func (l *Lit) Config(key string) (string, bool) {
+	for _, k := range l.config.Kids() {
+		if leaf, ok := k.(*dgrl.Leaf); ok && leaf.Key() == key {
+			return leaf.Value(), true
+		}
+	}
+	return "", false
+}
+
+// SetConfig sets the configured value for key.  The config is written by the
+// next Store.
+func (l *Lit) SetConfig(key, val string) {
+	l.configDirty = true
+	for _, k := range l.config.Kids() {
+		if leaf, ok := k.(*dgrl.Leaf); ok && leaf.Key() == key {
+			leaf.SetValue(val)
+			return
+		}
+	}
+	l.config.Append(dgrl.NewLeaf(key, val))
+}
+
+// Team returns the users in the named team, configured as a space separated
+// list under "team-<name>".  If there is no such team, name is treated as a
+// comma separated list of users.
+func (l *Lit) Team(name string) []string {
+	if team, ok := l.Config("team-" + name); ok {
+		return strings.Fields(team)
+	}
+	users := []string{}
+	for _, user := range strings.Split(name, ",") {
+		if user = strings.TrimSpace(user); user != "" {
+			users = append(users, user)
+		}
+	}
+	return users
+}
+
+// ValidUser reports whether user is listed in the space separated "users"
+// config, or true if no users are configured.
+func (l *Lit) ValidUser(user string) bool {
+	users, ok := l.Config("users")
+	if !ok || strings.TrimSpace(users) == "" {
+		return true
+	}
+	for _, u := range strings.Fields(users) {
+		if u == user {
+			return true
+		}
+	}
+	return false
+}
+
+// CanonicalUser returns the username for an identity from an outside
+// authority, such as an OAuth provider: the user configured with it as their
+// "email-<user>" address, or the identity itself if it is a valid user.  It
+// returns false if the identity maps to no user.
+func (l *Lit) CanonicalUser(identity string) (string, bool) {
+	if identity == "" {
+		return "", false
+	}
+	for _, k := range l.config.Kids() {
+		leaf, ok := k.(*dgrl.Leaf)
+		if !ok || !strings.HasPrefix(leaf.Key(), "email-") {
+			continue
+		}
+		if strings.EqualFold(strings.TrimSpace(leaf.Value()), identity) {
+			return strings.TrimPrefix(leaf.Key(), "email-"), true
+		}
+	}
+	if strings.ContainsAny(identity, " \t\n") || !l.ValidUser(identity) {
+		return "", false
+	}
+	return identity, true
+}
+
+// Rotate returns the next of users in a round-robin rotation.  The choice is
+// recorded in the config, so successive calls, including those of later runs,
+// continue the rotation.
+func (l *Lit) Rotate(users []string) string {
+	if len(users) == 0 {
+		return ""
+	}
+	key := "rotation-" + strings.Join(users, ",")
+	next := 0
+	if last, ok := l.Config(key); ok {
+		for i := range users {
+			if users[i] == last {
+				next = (i + 1) % len(users)
+				break
+			}
+		}
+	}
+	l.SetConfig(key, users[next])
+	return users[next]
+}
+
+// RandomUser returns one of users chosen at random.
+func RandomUser(users []string) string {
+	if len(users) == 0 {
+		return ""
+	}
+	return users[rand.Intn(len(users))]
+}
+
+// IssueURL returns the URL of an issue in the web frontend or repository,
+// given by the url-template config with "{id}" in place of the issue id, e.g.
+// "https://example.com/issues/{id}".
+func (l *Lit) IssueURL(issue *dgrl.Branch) (string, error) {
+	tmpl, _ := l.Config("url-template")
+	if tmpl == "" {
+		return "", errors.New("url-template is not configured")
+	}
+	return strings.Replace(tmpl, "{id}", issue.Key(), -1), nil
+}
+
+var defaultResolutions = []string{"fixed", "wontfix", "duplicate"}
+
+// Resolutions returns the resolutions with which issues may be closed, given
+// by the resolutions config, or else fixed, wontfix, and duplicate.
+func (l *Lit) Resolutions() []string {
+	if res, ok := l.Config("resolutions"); ok && strings.TrimSpace(res) != "" {
+		return strings.Fields(res)
+	}
+	return defaultResolutions
+}
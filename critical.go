@@ -0,0 +1,86 @@
+package lit
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// prerequisites returns the ids of the issues that must be done before an
+// issue: those it depends on and those that block it.
+func (l *Lit) prerequisites(issue *dgrl.Branch) []string {
+	deps, _ := GetExact(issue, "depends-on")
+	prereqs := []string{}
+	for _, id := range strings.Fields(deps) {
+		if _, ok := l.issueMap[id]; ok {
+			prereqs = append(prereqs, id)
+		}
+	}
+	return append(prereqs, l.filter(func(other *dgrl.Branch) bool {
+		blocks, _ := GetExact(other, "blocks")
+		for _, id := range strings.Fields(blocks) {
+			if id == issue.Key() {
+				return true
+			}
+		}
+		return false
+	})...)
+}
+
+// CriticalPath returns the longest chain of dependencies, weighted by the
+// estimates of open issues, ending at one of the given issues, in the order
+// they must be done, along with its total estimate.  Prerequisites need not
+// be among ids.  An error is returned if the dependencies form a cycle.
+func (l *Lit) CriticalPath(ids []string) ([]string, time.Duration, error) {
+	type result struct {
+		path []string
+		dur  time.Duration
+	}
+	done := map[string]*result{}
+	visiting := map[string]bool{}
+	var longest func(id string) (*result, error)
+	longest = func(id string) (*result, error) {
+		if res, ok := done[id]; ok {
+			return res, nil
+		}
+		if visiting[id] {
+			return nil, fmt.Errorf("dependency cycle through issue %s", id)
+		}
+		visiting[id] = true
+		issue := l.issueMap[id]
+		best := &result{}
+		for _, prereq := range l.prerequisites(issue) {
+			res, err := longest(prereq)
+			if err != nil {
+				return nil, err
+			}
+			if res.dur > best.dur || (res.dur == best.dur && len(res.path) > len(best.path)) {
+				best = res
+			}
+		}
+		res := &result{path: append(append([]string{}, best.path...), id), dur: best.dur}
+		if isOpen(issue) {
+			res.dur += l.Estimate(issue)
+		}
+		visiting[id] = false
+		done[id] = res
+		return res, nil
+	}
+	best := &result{}
+	for _, id := range ids {
+		issue := l.Issue(id)
+		if issue == nil {
+			continue
+		}
+		res, err := longest(issue.Key())
+		if err != nil {
+			return nil, 0, err
+		}
+		if res.dur > best.dur || (res.dur == best.dur && len(res.path) > len(best.path)) {
+			best = res
+		}
+	}
+	return best.path, best.dur, nil
+}
@@ -0,0 +1,91 @@
+package lit
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCriticalPath(t *testing.T) {
+	// issues are named a-e; fields maps "<issue> <key>" to values, in which
+	// issue names stand for their ids
+	tests := []struct {
+		name    string
+		fields  map[string]string
+		targets string
+		path    string
+		dur     time.Duration
+	}{
+		{"chain", map[string]string{
+			"a estimate": "1h", "b estimate": "2h", "c estimate": "3h",
+			"b depends-on": "a", "c depends-on": "b",
+		}, "c", "a b c", 6 * time.Hour},
+		{"blocks", map[string]string{
+			"a estimate": "4h", "b estimate": "2h", "c estimate": "1h",
+			"a blocks": "c", "c depends-on": "b",
+		}, "c", "a c", 5 * time.Hour},
+		{"closed", map[string]string{
+			"a estimate": "10h", "a closed": "2024-02-01T00:00:00Z tester", "b estimate": "1h",
+			"c estimate": "2h", "b depends-on": "a c",
+		}, "b", "c b", 3 * time.Hour},
+		{"closed only", map[string]string{
+			"a estimate": "10h", "a closed": "2024-02-01T00:00:00Z tester", "b estimate": "1h",
+			"b depends-on": "a",
+		}, "b", "a b", time.Hour},
+		{"longest of targets", map[string]string{
+			"a estimate": "1d", "b estimate": "1h", "c estimate": "2h", "d estimate": "1h",
+			"b depends-on": "a", "d depends-on": "c",
+		}, "b d", "a b", 9 * time.Hour},
+		{"ties by length", map[string]string{
+			"b depends-on": "a",
+		}, "c b", "a b", 0},
+		{"unknown dependency", map[string]string{
+			"a estimate": "1h", "a depends-on": "nosuch",
+		}, "a", "a", time.Hour},
+	}
+	for _, test := range tests {
+		l := memTracker(t, 5)
+		ids := map[string]string{}
+		for i, id := range l.IssueIds() {
+			ids[string(rune('a'+i))] = id
+		}
+		names := func(s string) string {
+			out := []string{}
+			for _, name := range strings.Fields(s) {
+				if id, ok := ids[name]; ok {
+					name = id
+				}
+				out = append(out, name)
+			}
+			return strings.Join(out, " ")
+		}
+		for field, val := range test.fields {
+			f := strings.Fields(field)
+			if f[1] == "depends-on" || f[1] == "blocks" {
+				val = names(val)
+			}
+			if !SetForce(l.Issue(ids[f[0]]), f[1], val) {
+				t.Fatalf("%s: can't set %s", test.name, field)
+			}
+		}
+		path, dur, err := l.CriticalPath(strings.Fields(names(test.targets)))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if got := strings.Join(path, " "); got != names(test.path) || dur != test.dur {
+			t.Errorf("%s: path %s, %v, want %s, %v", test.name, got, dur, names(test.path), test.dur)
+		}
+	}
+}
+
+func TestCriticalPathCycle(t *testing.T) {
+	l := memTracker(t, 3)
+	ids := l.IssueIds()
+	SetExact(l.Issue(ids[0]), "depends-on", ids[1])
+	SetExact(l.Issue(ids[1]), "depends-on", ids[2])
+	SetExact(l.Issue(ids[1]), "blocks", ids[2])
+	if _, _, err := l.CriticalPath(ids[:1]); err == nil {
+		t.Errorf("no error for a dependency cycle")
+	}
+}
@@ -0,0 +1,79 @@
+package lit
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// dataKeys orders the fields of issues made from IssueData, whose order is
+// not kept.  Other fields follow, sorted.
+var dataKeys = []string{"created", "updated", "closed", "summary", "tags", "priority", "assigned"}
+
+// IssueData is a plain representation of an issue, suitable for encoding.
+type IssueData struct {
+	ID       string            `json:"id"`
+	Ref      string            `json:"ref,omitempty"` // id prefixed by project
+	Fields   map[string]string `json:"fields"`
+	Comments []CommentData     `json:"comments,omitempty"`
+}
+
+// CommentData is a plain representation of an issue comment.
+type CommentData struct {
+	Stamp string `json:"stamp"`
+	Text  string `json:"text"`
+}
+
+// Data returns the plain representation of an issue.
+func Data(issue *dgrl.Branch) *IssueData {
+	if issue == nil {
+		return nil
+	}
+	data := &IssueData{ID: issue.Key(), Fields: map[string]string{}}
+	for _, k := range issue.Kids() {
+		switch node := k.(type) {
+		case *dgrl.Leaf:
+			data.Fields[node.Key()] = node.Value()
+		case *dgrl.Branch:
+			comment := CommentData{Stamp: node.Key()}
+			for _, kk := range node.Kids() {
+				if leaf, ok := kk.(*dgrl.Leaf); ok {
+					comment.Text += leaf.Value()
+				}
+			}
+			data.Comments = append(data.Comments, comment)
+		}
+	}
+	return data
+}
+
+// FromData returns the issue with the plain representation data, as received
+// from a server.
+func FromData(data *IssueData) *dgrl.Branch {
+	issue := dgrl.NewBranch(data.ID)
+	keys := []string{}
+	for key := range data.Fields {
+		if !hasString(dataKeys, key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range append(append([]string{}, dataKeys...), keys...) {
+		val, ok := data.Fields[key]
+		if !ok {
+			continue
+		}
+		if strings.Contains(val, "\n") || key == "description" {
+			issue.Append(dgrl.NewLongLeaf(key, val))
+		} else {
+			issue.Append(dgrl.NewLeaf(key, val))
+		}
+	}
+	for _, c := range data.Comments {
+		comment := dgrl.NewBranch(c.Stamp)
+		comment.Append(dgrl.NewText(c.Text))
+		issue.Append(comment)
+	}
+	return issue
+}
@@ -0,0 +1,98 @@
+package lit
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// dateFormat is the canonical form of date field values given without a time
+// of day.  Those with a time are stored as RFC3339 times in UTC.
+const dateFormat = "2006-01-02"
+
+// IsDate reports whether key names a date-valued field, which are due and any
+// fields listed in the "date-fields" config.
+func (l *Lit) IsDate(key string) bool {
+	keys := []string{"due"}
+	if fields, ok := l.Config("date-fields"); ok {
+		keys = append(keys, strings.Fields(fields)...)
+	}
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeDate returns val in canonical form if key is a date field, or val
+// unchanged otherwise.  An empty value, which clears a field, is kept.
+func (l *Lit) NormalizeDate(key, val string) (string, error) {
+	if val == "" || !l.IsDate(key) {
+		return val, nil
+	}
+	return ParseDate(val, l.Now())
+}
+
+// filterDate returns the canonical form of a filter value for a date field,
+// if it is a friendly date.
+func (l *Lit) filterDate(key, val string) (string, bool) {
+	if val == "" || !l.IsDate(key) {
+		return "", false
+	}
+	date, err := ParseDate(val, l.Now())
+	return date, err == nil
+}
+
+// ParseDate parses a friendly date relative to now and returns it in
+// canonical form.  Accepted are today, tomorrow, and yesterday, a weekday name
+// (optionally preceded by "next") meaning its next occurrence, an offset like
+// +2w, -3d, or +36h, and any of the time formats of stamps.
+func ParseDate(val string, now time.Time) (string, error) {
+	word := strings.ToLower(strings.TrimSpace(val))
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	switch word {
+	case "today":
+		return today.Format(dateFormat), nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1).Format(dateFormat), nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1).Format(dateFormat), nil
+	}
+	if day, ok := parseWeekday(strings.TrimPrefix(word, "next ")); ok {
+		days := (int(day) - int(today.Weekday()) + 6) % 7
+		return today.AddDate(0, 0, days+1).Format(dateFormat), nil
+	}
+	if strings.HasPrefix(word, "+") || strings.HasPrefix(word, "-") {
+		age, err := ParseAge(word[1:])
+		if err != nil {
+			return "", fmt.Errorf("invalid date '%s'", val)
+		}
+		if word[0] == '-' {
+			age = -age
+		}
+		if age%(24*time.Hour) == 0 {
+			return today.Add(age).Format(dateFormat), nil
+		}
+		return now.Add(age).UTC().Format(time.RFC3339), nil
+	}
+	if t, err := time.Parse(dateFormat, word); err == nil {
+		return t.Format(dateFormat), nil
+	}
+	if t, ok := parseTime(strings.ToUpper(word)); ok {
+		return t.UTC().Format(time.RFC3339), nil
+	}
+	return "", fmt.Errorf("invalid date '%s'", val)
+}
+
+func parseWeekday(name string) (time.Weekday, bool) {
+	if len(name) < 3 {
+		return 0, false
+	}
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.HasPrefix(strings.ToLower(day.String()), name) {
+			return day, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,45 @@
+package lit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	// testTime is Friday, 2024-03-01, 12:00 UTC
+	eastern := time.FixedZone("EST", -5*60*60)
+	tests := []struct {
+		val  string
+		now  time.Time
+		want string
+	}{
+		{"today", testTime, "2024-03-01"},
+		{" Tomorrow ", testTime, "2024-03-02"},
+		{"yesterday", testTime, "2024-02-29"},
+		{"saturday", testTime, "2024-03-02"},
+		{"thu", testTime, "2024-03-07"},
+		{"friday", testTime, "2024-03-08"},
+		{"next mon", testTime, "2024-03-04"},
+		{"sunday", time.Date(2024, 12, 28, 0, 0, 0, 0, time.UTC), "2024-12-29"},
+		{"+1d", testTime, "2024-03-02"},
+		{"+2w", testTime, "2024-03-15"},
+		{"-3d", testTime, "2024-02-27"},
+		{"+36h", testTime, "2024-03-03T00:00:00Z"},
+		{"-90m", testTime, "2024-03-01T10:30:00Z"},
+		{"2024-12-25", testTime, "2024-12-25"},
+		{"2024-03-01T12:00:00+09:00", testTime, "2024-03-01T03:00:00Z"},
+		// days are counted from the date where now is
+		{"today", time.Date(2024, 3, 1, 23, 30, 0, 0, eastern), "2024-03-01"},
+		{"tomorrow", time.Date(2024, 3, 1, 23, 30, 0, 0, eastern), "2024-03-02"},
+	}
+	for _, test := range tests {
+		if got, err := ParseDate(test.val, test.now); err != nil || got != test.want {
+			t.Errorf("ParseDate(%q) at %v = %q, %v, want %q", test.val, test.now, got, err, test.want)
+		}
+	}
+	for _, val := range []string{"", "someday", "mo", "+xd", "next", "2024-02-30"} {
+		if got, err := ParseDate(val, testTime); err == nil {
+			t.Errorf("ParseDate(%q) = %q, want error", val, got)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package lit
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// Digest summarizes tracker activity over a period of time.
+type Digest struct {
+	Since   time.Time
+	Created []string
+	Closed  []string
+	Active  []Activity
+}
+
+// Activity is the number of comments added to an issue during a digest period.
+type Activity struct {
+	ID       string
+	Comments int
+}
+
+// Digest summarizes the issues created, closed, and commented on since the
+// given time.  Active issues are ordered by number of comments, and at most
+// maxActive are included.  Confidential issues are left out.
+func (l *Lit) Digest(since time.Time, maxActive int) *Digest {
+	digest := &Digest{Since: since, Created: []string{}, Closed: []string{}, Active: []Activity{}}
+	for _, id := range l.Public(l.issueIds) {
+		issue := l.issueMap[id]
+		if created, ok := stampTime(issue, "created"); ok && !created.Before(since) {
+			digest.Created = append(digest.Created, issue.Key())
+		}
+		if closed, ok := stampTime(issue, "closed"); ok && !closed.Before(since) {
+			digest.Closed = append(digest.Closed, issue.Key())
+		}
+		numComments := 0
+		for _, kk := range issue.Kids() {
+			if comment, ok := kk.(*dgrl.Branch); ok {
+				if t, ok := parseStampTime(comment.Key()); ok && !t.Before(since) {
+					numComments++
+				}
+			}
+		}
+		if numComments > 0 {
+			digest.Active = append(digest.Active, Activity{ID: issue.Key(), Comments: numComments})
+		}
+	}
+	sort.SliceStable(digest.Active, func(i, j int) bool {
+		return digest.Active[i].Comments > digest.Active[j].Comments
+	})
+	if maxActive >= 0 && len(digest.Active) > maxActive {
+		digest.Active = digest.Active[:maxActive]
+	}
+	return digest
+}
@@ -0,0 +1,106 @@
+package lit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// Drafts are issues being composed by a user, kept as files under
+// .lit/drafts/<user>/ until submitted.  They hold an issue's fields without an
+// id.
+const draftsDirname = "drafts"
+
+// DraftPath returns the path of the named draft of the given user.
+func (l *Lit) DraftPath(username, name string) string {
+	user := strings.Replace(username, string(filepath.Separator), "_", -1)
+	return filepath.Join(l.issueDir, draftsDirname, user, name)
+}
+
+// NewDraft creates an empty draft and returns its path.
+func (l *Lit) NewDraft(username, name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("invalid draft name '%s'", name)
+	}
+	path := l.DraftPath(username, name)
+	if err := l.fs.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return "", err
+	}
+	if _, err := l.fs.Size(path); err == nil {
+		return "", fmt.Errorf("draft %s already exists", name)
+	}
+	draft := dgrl.NewRoot()
+	for _, field := range l.newFields() {
+		draft.Append(field)
+	}
+	buf := &bytes.Buffer{}
+	if err := draft.Write(buf); err != nil {
+		return "", err
+	}
+	return path, l.fs.WriteFile(path, buf.Bytes(), 0666)
+}
+
+// Drafts returns the names of the given user's drafts.
+func (l *Lit) Drafts(username string) ([]string, error) {
+	return l.fileNames(filepath.Dir(l.DraftPath(username, "x")))
+}
+
+// SubmitDraft adds a new issue with the fields of the named draft, which is
+// then removed.
+func (l *Lit) SubmitDraft(username, name string) (*dgrl.Branch, error) {
+	path := l.DraftPath(username, name)
+	data, err := l.fs.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("draft %s not found", name)
+		}
+		return nil, err
+	}
+	draft := dgrl.NewParser().Parse(bytes.NewReader(data))
+	if draft == nil {
+		return nil, fmt.Errorf("error parsing draft %s", name)
+	}
+	fields, err := fieldLeaves(draft)
+	if err != nil {
+		return nil, fmt.Errorf("draft %s: %w", name, err)
+	}
+	if summary, _ := GetExact(draft, "summary"); summary == "" {
+		return nil, fmt.Errorf("draft %s has no summary", name)
+	}
+	issue := l.NewIssues(username, 1)[0]
+	setFields(issue, fields)
+	return issue, l.fs.Remove(path)
+}
+
+// fieldLeaves returns the fields given for a new issue, which may not
+// include comments or reserved keys.
+func fieldLeaves(root *dgrl.Branch) ([]*dgrl.Leaf, error) {
+	fields := []*dgrl.Leaf{}
+	for _, k := range root.Kids() {
+		leaf, ok := k.(*dgrl.Leaf)
+		if !ok || leaf.Key() == "" {
+			return nil, errors.New("only fields are allowed")
+		}
+		if IsReserved(leaf.Key()) {
+			return nil, &ReservedError{Key: leaf.Key()}
+		}
+		fields = append(fields, leaf)
+	}
+	return fields, nil
+}
+
+// setFields sets the given fields in an issue, keeping long values long.
+func setFields(issue *dgrl.Branch, fields []*dgrl.Leaf) {
+	for _, leaf := range fields {
+		if leaf.Type() == dgrl.LeafType {
+			SetExact(issue, leaf.Key(), leaf.Value())
+		} else {
+			set(issue, leaf.Key(), leaf.Value(), true, true, false)
+		}
+	}
+}
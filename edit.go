@@ -0,0 +1,28 @@
+package lit
+
+import (
+	"fmt"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// These helpers make the same changes to an issue as the corresponding lit
+// commands, through SetField's rules, stamped by the tracker's clock.  Changes
+// are written by the next Store.  Comments are added, and issues closed, by
+// the tracker's Comment and Close methods.
+
+// SetDescription replaces the description of an issue on behalf of a user.
+func (l *Lit) SetDescription(issue *dgrl.Branch, username, desc string) error {
+	return l.update(issue, username, "description", desc, false, "")
+}
+
+// AddRelation adds the id of another issue to a relation field (parent,
+// depends-on, blocks, duplicate-of, or references) of an issue on behalf of a
+// user.
+func (l *Lit) AddRelation(issue *dgrl.Branch, username, key, id string) error {
+	if !hasString(relationKeys, key) {
+		return fmt.Errorf("unknown relation %s", key)
+	}
+	vals, _ := GetExact(issue, key)
+	return l.update(issue, username, key, ModifyValueStr(vals, id, true), false, "")
+}
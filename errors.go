@@ -0,0 +1,54 @@
+package lit
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// ErrorReport is an error reported by an error tracker such as Sentry.
+type ErrorReport struct {
+	Fingerprint string // identifies repeats of the error
+	Title       string
+	URL         string // of the error in the error tracker
+}
+
+// RecordError adds an issue for a reported error, with the error's
+// fingerprint as error-fingerprint, or if the error was seen before,
+// increments the occurrences field of its issue.  It returns the issue and
+// whether it is new.
+func (l *Lit) RecordError(username string, rep ErrorReport) (*dgrl.Branch, bool, error) {
+	if rep.Fingerprint == "" {
+		return nil, false, fmt.Errorf("error report has no fingerprint")
+	}
+	stamp := l.Stamp(username)
+	for _, id := range l.issueIds {
+		issue := l.issueMap[id]
+		if fp, _ := GetExact(issue, "error-fingerprint"); fp != rep.Fingerprint {
+			continue
+		}
+		count, _ := GetExact(issue, "occurrences")
+		n, _ := strconv.Atoi(count)
+		if !SetExact(issue, "occurrences", strconv.Itoa(n+1)) || !Touch(issue, stamp) {
+			return nil, false, fmt.Errorf("error updating fields in issue %s", id)
+		}
+		return issue, false, nil
+	}
+	issue := l.NewIssues(username, 1)[0]
+	title := rep.Title
+	if title == "" {
+		title = "error " + rep.Fingerprint
+	}
+	ok := SetExact(issue, "summary", title) &&
+		SetExact(issue, "error-fingerprint", rep.Fingerprint) &&
+		SetExact(issue, "occurrences", "1")
+	if ok && rep.URL != "" {
+		ok = SetExact(issue, "error-url", rep.URL)
+	}
+	ModifyTag(issue, "error", true)
+	if !ok {
+		return nil, false, fmt.Errorf("error setting fields in issue %s", issue.Key())
+	}
+	return issue, true, nil
+}
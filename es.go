@@ -0,0 +1,169 @@
+package lit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/ianremmler/dgrl"
+)
+
+const defaultESIndex = "lit"
+
+// esDoc is the Elasticsearch document for an issue.
+type esDoc struct {
+	ID      string            `json:"id"`
+	Open    bool              `json:"open"`
+	Fields  map[string]string `json:"fields"`
+	Comment []string          `json:"comment,omitempty"`
+}
+
+// ESNotifier keeps an Elasticsearch index up to date with changed issues.
+// The server is configured as "es-url" and the index as "es-index" (default:
+// lit).  An API key may be configured as "es-api-key" or given by the
+// LIT_ES_API_KEY environment variable.
+type ESNotifier struct {
+	l *Lit
+}
+
+// Notify indexes the event's issue.
+func (n *ESNotifier) Notify(ev *Event) error {
+	return n.l.ESIndex(ev.Issue)
+}
+
+func (l *Lit) esRequest(method, path string, body interface{}) (*http.Response, error) {
+	server, ok := l.Config("es-url")
+	if !ok || server == "" {
+		return nil, errors.New("es-url is not configured")
+	}
+	index, _ := l.Config("es-index")
+	if index == "" {
+		index = defaultESIndex
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := strings.TrimRight(server, "/") + "/" + url.PathEscape(index) + path
+	req, err := http.NewRequest(method, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	key := os.Getenv("LIT_ES_API_KEY")
+	if key == "" {
+		key, _ = l.Config("es-api-key")
+	}
+	if key != "" {
+		req.Header.Set("Authorization", "ApiKey "+key)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("elasticsearch: %s", resp.Status)
+	}
+	return resp, nil
+}
+
+// ESIndex adds or replaces an issue in the Elasticsearch index.
+// Confidential issues are not indexed.
+func (l *Lit) ESIndex(issue *dgrl.Branch) error {
+	if IsConfidential(issue) {
+		return nil
+	}
+	data := Data(issue)
+	doc := &esDoc{ID: data.ID, Open: isOpen(issue), Fields: data.Fields}
+	for _, comment := range data.Comments {
+		doc.Comment = append(doc.Comment, comment.Text)
+	}
+	resp, err := l.esRequest(http.MethodPut, "/_doc/"+url.PathEscape(issue.Key()), doc)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// ESSearch searches the Elasticsearch index, as Search does the issue file.
+func (l *Lit) ESSearch(query string, opts SearchOptions) ([]SearchResult, error) {
+	fields := []string{}
+	for _, field := range opts.Fields {
+		fields = append(fields, esField(field))
+	}
+	if len(fields) == 0 {
+		for field, weight := range fieldWeights {
+			fields = append(fields, fmt.Sprintf("%s^%g", esField(field), weight))
+		}
+		fields = append(fields, "fields.*")
+	}
+	search := map[string]interface{}{
+		"query": map[string]interface{}{"bool": map[string]interface{}{
+			"must": map[string]interface{}{"multi_match": map[string]interface{}{
+				"query": query, "fields": fields, "type": "cross_fields", "operator": "and",
+			}},
+		}},
+		"highlight": map[string]interface{}{
+			"pre_tags": []string{""}, "post_tags": []string{""},
+			"fragment_size": snippetLen, "number_of_fragments": 1,
+			"fields": map[string]interface{}{"fields.*": struct{}{}, "comment": struct{}{}},
+		},
+		"from": opts.Offset,
+	}
+	if opts.Limit > 0 {
+		search["size"] = opts.Limit
+	}
+	if !opts.IncludeClosed {
+		search["query"].(map[string]interface{})["bool"].(map[string]interface{})["filter"] =
+			map[string]interface{}{"term": map[string]interface{}{"open": true}}
+	}
+	resp, err := l.esRequest(http.MethodPost, "/_search", search)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	found := struct {
+		Hits struct {
+			Hits []struct {
+				ID        string              `json:"_id"`
+				Score     float64             `json:"_score"`
+				Highlight map[string][]string `json:"highlight"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&found); err != nil {
+		return nil, err
+	}
+	results := []SearchResult{}
+	for _, hit := range found.Hits.Hits {
+		res := SearchResult{ID: hit.ID, Score: hit.Score}
+		best := 0.0
+		for field, snippets := range hit.Highlight {
+			field = strings.TrimPrefix(field, "fields.")
+			weight, ok := fieldWeights[field]
+			if !ok {
+				weight = 1
+			}
+			if weight > best && len(snippets) > 0 {
+				best, res.Field = weight, field
+				res.Snippet = strings.Join(strings.Fields(snippets[0]), " ")
+			}
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+func esField(field string) string {
+	if field == "comment" {
+		return field
+	}
+	return "fields." + field
+}
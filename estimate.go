@@ -0,0 +1,116 @@
+package lit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ianremmler/dgrl"
+)
+
+const (
+	workDay  = 8 * time.Hour
+	workWeek = 5 * workDay
+)
+
+// ParseEstimate parses an estimate of work, given in hours by default or with
+// an "h" (hours), "d" (8 hour days), or "w" (5 day weeks) unit, e.g. "4h" or
+// "1.5d".
+func ParseEstimate(est string) (time.Duration, error) {
+	unit := time.Hour
+	num := strings.TrimSpace(est)
+	switch {
+	case strings.HasSuffix(num, "h"):
+		num = num[:len(num)-1]
+	case strings.HasSuffix(num, "d"):
+		unit, num = workDay, num[:len(num)-1]
+	case strings.HasSuffix(num, "w"):
+		unit, num = workWeek, num[:len(num)-1]
+	}
+	val, err := strconv.ParseFloat(num, 64)
+	if err != nil || val < 0 {
+		return 0, fmt.Errorf("invalid estimate '%s'", est)
+	}
+	return time.Duration(val * float64(unit)), nil
+}
+
+// children returns the ids of the issues whose parent is the given issue.
+func (l *Lit) children(issue *dgrl.Branch) []string {
+	return l.filter(func(kid *dgrl.Branch) bool {
+		parent, _ := GetExact(kid, "parent")
+		return parent == issue.Key()
+	})
+}
+
+// Estimate returns the issue's estimate, or if it has none, the sum of its
+// children's estimates.
+func (l *Lit) Estimate(issue *dgrl.Branch) time.Duration {
+	return l.estimate(issue, map[string]bool{})
+}
+
+func (l *Lit) estimate(issue *dgrl.Branch, seen map[string]bool) time.Duration {
+	if issue == nil || seen[issue.Key()] {
+		return 0
+	}
+	seen[issue.Key()] = true
+	if est, ok := GetExact(issue, "estimate"); ok && est != "" {
+		if dur, err := ParseEstimate(est); err == nil {
+			return dur
+		}
+	}
+	sum := time.Duration(0)
+	for _, id := range l.children(issue) {
+		sum += l.estimate(l.issueMap[id], seen)
+	}
+	return sum
+}
+
+// EstimateSum returns the total estimate of the given issues.  Issues whose
+// parent is also given are counted only as part of their parent.
+func (l *Lit) EstimateSum(ids []string) time.Duration {
+	sum := time.Duration(0)
+	for _, est := range l.estimates(ids) {
+		sum += est
+	}
+	return sum
+}
+
+// EstimateByUser returns the total estimate of the given issues for each
+// assignee, with unassigned issues under "".  The estimate of an issue with
+// several assignees is split evenly between them.  Parents are handled as for
+// EstimateSum.
+func (l *Lit) EstimateByUser(ids []string) map[string]time.Duration {
+	sums := map[string]time.Duration{}
+	for id, est := range l.estimates(ids) {
+		assigned, _ := GetExact(l.issueMap[id], "assigned")
+		users := strings.Fields(assigned)
+		if len(users) == 0 {
+			sums[""] += est
+		}
+		for _, user := range users {
+			sums[user] += est / time.Duration(len(users))
+		}
+	}
+	return sums
+}
+
+// estimates returns the estimates of the given issues, leaving out those whose
+// parent is also given.
+func (l *Lit) estimates(ids []string) map[string]time.Duration {
+	given := map[string]bool{}
+	for _, id := range ids {
+		if issue := l.Issue(id); issue != nil {
+			given[issue.Key()] = true
+		}
+	}
+	ests := map[string]time.Duration{}
+	for id := range given {
+		issue := l.issueMap[id]
+		if parent, _ := GetExact(issue, "parent"); given[parent] {
+			continue
+		}
+		ests[id] = l.Estimate(issue)
+	}
+	return ests
+}
@@ -0,0 +1,70 @@
+package lit
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// EventRecord is a change to an issue, reconstructed from the stamps stored
+// with it.
+type EventRecord struct {
+	Time  time.Time `json:"time"`
+	User  string    `json:"user"`
+	Issue string    `json:"issue"`
+	Type  string    `json:"type"` // "created", "commented", "attached", or "closed"
+	Text  string    `json:"text,omitempty"`
+}
+
+// Events returns the changes to the given issues since the given time, in
+// time order.  Changes that leave no stamp, like setting a field, aren't
+// included, nor are confidential issues.
+func (l *Lit) Events(ids []string, since time.Time) []EventRecord {
+	events := []EventRecord{}
+	add := func(issue *dgrl.Branch, stamp, typ, text string) {
+		t, user, err := ParseStamp(stamp)
+		if err != nil || t.Before(since) {
+			return
+		}
+		events = append(events, EventRecord{Time: t, User: user, Issue: issue.Key(), Type: typ, Text: text})
+	}
+	for _, id := range l.Public(ids) {
+		issue := l.Issue(id)
+		if issue == nil {
+			continue
+		}
+		if created, ok := GetExact(issue, "created"); ok {
+			add(issue, created, "created", "")
+		}
+		for _, k := range issue.Kids() {
+			comment, ok := k.(*dgrl.Branch)
+			if !ok {
+				continue
+			}
+			text := []string{}
+			for _, kk := range comment.Kids() {
+				if leaf, ok := kk.(*dgrl.Leaf); ok {
+					text = append(text, leaf.Value())
+				}
+			}
+			typ := "commented"
+			if len(text) > 0 && strings.HasPrefix(text[0], "Attached ") {
+				typ = "attached"
+			}
+			add(issue, comment.Key(), typ, strings.Join(text, "\n"))
+		}
+		reopened, _ := GetExact(issue, "reopened")
+		for _, stamp := range strings.Split(reopened, "\n") {
+			if stamp != "" {
+				add(issue, stamp, "reopened", "")
+			}
+		}
+		if closed, ok := GetExact(issue, "closed"); ok && closed != "" {
+			add(issue, closed, "closed", "")
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+	return events
+}
@@ -0,0 +1,119 @@
+package lit
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// Formatter renders issues in an output format.
+type Formatter interface {
+	Format(issues []*dgrl.Branch) ([]byte, error)
+}
+
+// FormatterFunc adapts a function to the Formatter interface.
+type FormatterFunc func(issues []*dgrl.Branch) ([]byte, error)
+
+// Format calls f.
+func (f FormatterFunc) Format(issues []*dgrl.Branch) ([]byte, error) {
+	return f(issues)
+}
+
+// Formatters are the available output formats by name.  Programs may add
+// their own before running the command line interface, which also offers
+// table and porcelain.
+var Formatters = map[string]Formatter{
+	"json":     FormatterFunc(formatJSON),
+	"csv":      FormatterFunc(formatCSV),
+	"markdown": FormatterFunc(formatMarkdown),
+	"org":      FormatterFunc(formatOrg),
+}
+
+// FormatterNames returns the names of the available formatters, sorted.
+func FormatterNames() []string {
+	names := []string{}
+	for name := range Formatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// formatColumns are the fields shown by the tabular formats.
+var formatColumns = []string{"closed", "priority", "assigned", "tags", "summary"}
+
+func formatJSON(issues []*dgrl.Branch) ([]byte, error) {
+	data := []*IssueData{}
+	for _, issue := range issues {
+		data = append(data, Data(issue))
+	}
+	out, err := json.MarshalIndent(data, "", "  ")
+	return append(out, '\n'), err
+}
+
+func formatCSV(issues []*dgrl.Branch) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+	w.Write(append([]string{"id"}, formatColumns...))
+	for _, issue := range issues {
+		row := []string{issue.Key()}
+		for _, key := range formatColumns {
+			row = append(row, fieldVal(issue, key))
+		}
+		w.Write(row)
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func formatMarkdown(issues []*dgrl.Branch) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "| id | %s |\n", strings.Join(formatColumns, " | "))
+	fmt.Fprintf(buf, "|----|%s\n", strings.Repeat("----|", len(formatColumns)))
+	cell := strings.NewReplacer("|", `\|`, "\n", " ")
+	for _, issue := range issues {
+		fmt.Fprintf(buf, "| %.8s |", issue.Key())
+		for _, key := range formatColumns {
+			fmt.Fprintf(buf, " %s |", cell.Replace(fieldVal(issue, key)))
+		}
+		buf.WriteString("\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// formatOrg writes issues as Org-mode headings, with their ids, priorities, and
+// assignees as properties.
+func formatOrg(issues []*dgrl.Branch) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	orgTag := strings.NewReplacer("-", "_", ":", "_")
+	for _, issue := range issues {
+		state := "TODO"
+		if !isOpen(issue) {
+			state = "DONE"
+		}
+		fmt.Fprintf(buf, "* %s %s", state, fieldVal(issue, "summary"))
+		if tags := strings.Fields(fieldVal(issue, "tags")); len(tags) > 0 {
+			for i := range tags {
+				tags[i] = orgTag.Replace(tags[i])
+			}
+			fmt.Fprintf(buf, " :%s:", strings.Join(tags, ":"))
+		}
+		buf.WriteString("\n:PROPERTIES:\n")
+		fmt.Fprintf(buf, ":ID: %s\n", issue.Key())
+		for _, key := range []string{"priority", "assigned"} {
+			if val := fieldVal(issue, key); val != "" {
+				fmt.Fprintf(buf, ":%s: %s\n", strings.ToUpper(key), val)
+			}
+		}
+		buf.WriteString(":END:\n")
+		if desc := strings.TrimSpace(fieldVal(issue, "description")); desc != "" {
+			buf.WriteString(desc + "\n")
+		}
+	}
+	return buf.Bytes(), nil
+}
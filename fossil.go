@@ -0,0 +1,202 @@
+package lit
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// fossilPriorities maps Fossil's default ticket priorities to lit's.
+var fossilPriorities = []string{"Immediate", "High", "Medium", "Low", "Zero"}
+
+// fossilClosed are the Fossil ticket statuses that mean the ticket is closed.
+var fossilClosed = map[string]bool{"closed": true, "fixed": true, "tested": true}
+
+// fossilFields are ticket fields kept as issue fields of the same name, so
+// they survive a round trip.
+var fossilFields = []string{"type", "subsystem", "severity", "foundin"}
+
+// ImportFossil adds the Fossil tickets read from r, in the tab separated form
+// printed by "fossil ticket show 0 -q".  Each issue records its ticket's uuid
+// in the fossil-uuid field, and tickets already imported are skipped.
+func (l *Lit) ImportFossil(r io.Reader, username string) ([]*dgrl.Branch, error) {
+	tickets, err := readFossilTickets(r)
+	if err != nil {
+		return nil, err
+	}
+	known := l.fieldValues("fossil-uuid")
+	newTickets := []map[string]string{}
+	for _, tkt := range tickets {
+		uuid := tkt["tkt_uuid"]
+		if _, ok := known[uuid]; !ok && uuid != "" {
+			known[uuid] = struct{}{}
+			newTickets = append(newTickets, tkt)
+		}
+	}
+	issues := l.NewIssues(username, len(newTickets))
+	for i, tkt := range newTickets {
+		if err := fossilIssue(issues[i], tkt, username); err != nil {
+			return nil, err
+		}
+	}
+	return issues, nil
+}
+
+func readFossilTickets(r io.Reader) ([]map[string]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<24)
+	var header []string
+	tickets := []map[string]string{}
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		vals := strings.Split(line, "\t")
+		if header == nil {
+			header = vals
+			continue
+		}
+		if len(vals) != len(header) {
+			return nil, fmt.Errorf("fossil ticket line %d has %d fields, expected %d",
+				len(tickets)+2, len(vals), len(header))
+		}
+		tkt := map[string]string{}
+		for i, key := range header {
+			tkt[key] = fossilUnquote(vals[i])
+		}
+		tickets = append(tickets, tkt)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if header != nil && !hasString(header, "tkt_uuid") {
+		return nil, fmt.Errorf("fossil tickets have no tkt_uuid column")
+	}
+	return tickets, nil
+}
+
+func hasString(strs []string, str string) bool {
+	for _, s := range strs {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
+
+// fossilUnquote undoes the backslash escapes of "fossil ticket show -q".
+func fossilUnquote(val string) string {
+	return strings.NewReplacer(`\\`, `\`, `\t`, "\t", `\n`, "\n", `\r`, "\r").Replace(val)
+}
+
+func fossilIssue(issue *dgrl.Branch, tkt map[string]string, username string) error {
+	user := username
+	if contact := strings.Fields(tkt["private_contact"]); len(contact) == 1 {
+		user = contact[0]
+	}
+	ok := SetExact(issue, "fossil-uuid", tkt["tkt_uuid"]) &&
+		Set(issue, "summary", tkt["title"]) &&
+		set(issue, "description", strings.TrimSpace(tkt["comment"]), false, true, false)
+	if t, valid := fossilTime(tkt["tkt_ctime"]); valid {
+		ok = ok && SetForce(issue, "created", stampAt(t, user))
+	}
+	modified := fieldVal(issue, "created")
+	if t, valid := fossilTime(tkt["tkt_mtime"]); valid {
+		modified = stampAt(t, user)
+		ok = ok && SetForce(issue, "updated", modified)
+	}
+	for i, p := range fossilPriorities {
+		if strings.EqualFold(tkt["priority"], p) {
+			ok = ok && Set(issue, "priority", strconv.Itoa(i+1))
+		}
+	}
+	for _, key := range fossilFields {
+		if val := tkt[key]; val != "" {
+			ok = ok && SetExact(issue, key, val)
+		}
+	}
+	if fossilClosed[strings.ToLower(tkt["status"])] {
+		ok = ok && SetForce(issue, "closed", modified)
+		if res := strings.ToLower(tkt["resolution"]); res != "" && res != "open" {
+			ok = ok && SetExact(issue, "resolution", strings.Join(strings.Fields(res), "-"))
+		}
+	}
+	if !ok {
+		return fmt.Errorf("error setting fields for issue %s", issue.Key())
+	}
+	return nil
+}
+
+// fossilTime parses a ticket time, which Fossil stores as a Julian day number
+// but may also be shown as a date and time.
+func fossilTime(val string) (time.Time, bool) {
+	if jd, err := strconv.ParseFloat(val, 64); err == nil {
+		const unixEpochJD = 2440587.5
+		secs := (jd - unixEpochJD) * 86400
+		return time.Unix(int64(math.Round(secs)), 0).UTC(), true
+	}
+	return parseTime(val)
+}
+
+// ExportFossil writes a shell script that creates a Fossil ticket for each of
+// the issues with the given ids using "fossil ticket add", leaving out
+// confidential issues.  Comments are appended to the ticket's comment as
+// Fossil's default ticket setup does.
+func (l *Lit) ExportFossil(w io.Writer, ids []string) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "#!/bin/sh")
+	fmt.Fprintln(bw, "set -e")
+	for _, id := range l.Public(ids) {
+		issue := l.issueMap[id]
+		if issue == nil {
+			continue
+		}
+		fmt.Fprint(bw, "fossil ticket add")
+		for _, kv := range fossilTicket(issue) {
+			fmt.Fprintf(bw, " %s %s", kv[0], shellQuote(kv[1]))
+		}
+		fmt.Fprintln(bw)
+	}
+	return bw.Flush()
+}
+
+func fossilTicket(issue *dgrl.Branch) [][2]string {
+	status := "Open"
+	if !isOpen(issue) {
+		status = "Closed"
+	}
+	fields := [][2]string{{"title", fieldVal(issue, "summary")}, {"status", status}}
+	if n, err := strconv.Atoi(fieldVal(issue, "priority")); err == nil && n >= 1 && n <= len(fossilPriorities) {
+		fields = append(fields, [2]string{"priority", fossilPriorities[n-1]})
+	}
+	for _, key := range fossilFields {
+		if val := fieldVal(issue, key); val != "" {
+			fields = append(fields, [2]string{key, val})
+		}
+	}
+	if res := fieldVal(issue, "resolution"); res != "" && !isOpen(issue) {
+		fields = append(fields, [2]string{"resolution", strings.Title(res)})
+	}
+	_, user, _ := ParseStamp(fieldVal(issue, "created"))
+	if user != "" {
+		fields = append(fields, [2]string{"private_contact", user})
+	}
+	comment := fieldVal(issue, "description")
+	for _, c := range issueComments(issue) {
+		t, user, _ := ParseStamp(c.Stamp)
+		comment += fmt.Sprintf("\n\n<hr><i>%s added on %s:</i><br>\n%s",
+			user, t.UTC().Format("2006-01-02 15:04:05"), strings.TrimSpace(c.Text))
+	}
+	return append(fields, [2]string{"comment", strings.TrimSpace(comment)})
+}
+
+func shellQuote(val string) string {
+	return "'" + strings.Replace(val, "'", `'\''`, -1) + "'"
+}
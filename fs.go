@@ -0,0 +1,284 @@
+package lit
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FS is the file system holding a tracker's files: issues, config, aliases,
+// caches, attachments, drafts, and templates.
+type FS interface {
+	ReadFile(name string) ([]byte, error)
+	WriteFile(name string, data []byte, perm os.FileMode) error
+	// Open opens a file for reading, e.g. to serve an attachment in ranges.
+	Open(name string) (File, error)
+	// Create creates or truncates a file, to be written in pieces.
+	Create(name string) (io.WriteCloser, error)
+	// Size returns the size of a file in bytes.
+	Size(name string) (int64, error)
+	MkdirAll(name string, perm os.FileMode) error
+	Remove(name string) error
+	// RemoveAll removes a file or a directory and its contents.  It is not
+	// an error if name doesn't exist.
+	RemoveAll(name string) error
+	// ReadDirNames returns the sorted names of the entries of a directory.
+	ReadDirNames(name string) ([]string, error)
+	IsDir(name string) bool
+}
+
+// File is an open file of an FS.
+type File interface {
+	io.ReadSeeker
+	io.ReaderAt
+	io.Closer
+}
+
+// OSFS is the OS file system.
+type OSFS struct{}
+
+// ReadFile reads a file.
+func (OSFS) ReadFile(name string) ([]byte, error) {
+	return ioutil.ReadFile(name)
+}
+
+// WriteFile writes a file, creating or truncating it.
+func (OSFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return ioutil.WriteFile(name, data, perm)
+}
+
+// Open opens a file for reading.
+func (OSFS) Open(name string) (File, error) {
+	return os.Open(name)
+}
+
+// Create creates or truncates a file.
+func (OSFS) Create(name string) (io.WriteCloser, error) {
+	return os.Create(name)
+}
+
+// Size returns the size of a file in bytes.
+func (OSFS) Size(name string) (int64, error) {
+	info, err := os.Stat(name)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// MkdirAll creates a directory and any missing parents.
+func (OSFS) MkdirAll(name string, perm os.FileMode) error {
+	return os.MkdirAll(name, perm)
+}
+
+// Remove removes a file or empty directory.
+func (OSFS) Remove(name string) error {
+	return os.Remove(name)
+}
+
+// RemoveAll removes a file or a directory and its contents.
+func (OSFS) RemoveAll(name string) error {
+	return os.RemoveAll(name)
+}
+
+// ReadDirNames returns the sorted names of the entries of a directory.
+func (OSFS) ReadDirNames(name string) ([]string, error) {
+	infos, err := ioutil.ReadDir(name)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(infos))
+	for i := range infos {
+		names[i] = infos[i].Name()
+	}
+	return names, nil
+}
+
+// IsDir reports whether name is a directory.
+func (OSFS) IsDir(name string) bool {
+	info, err := os.Stat(name)
+	return err == nil && info.IsDir()
+}
+
+// MemFS is an in-memory file system, e.g. for tests.  The root directory
+// always exists.  It is safe for concurrent use.
+type MemFS struct {
+	mu    sync.Mutex
+	files map[string][]byte
+	dirs  map[string]bool
+}
+
+// NewMemFS returns an empty in-memory file system.
+func NewMemFS() *MemFS {
+	return &MemFS{files: map[string][]byte{}, dirs: map[string]bool{"/": true, ".": true}}
+}
+
+func notExist(op, name string) error {
+	return &os.PathError{Op: op, Path: name, Err: os.ErrNotExist}
+}
+
+// ReadFile reads a file.
+func (m *MemFS) ReadFile(name string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.files[filepath.Clean(name)]
+	if !ok {
+		return nil, notExist("open", name)
+	}
+	return append([]byte{}, data...), nil
+}
+
+// WriteFile writes a file, creating or truncating it.  Its directory must
+// exist.
+func (m *MemFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = filepath.Clean(name)
+	if !m.dirs[filepath.Dir(name)] {
+		return notExist("open", name)
+	}
+	if m.dirs[name] {
+		return &os.PathError{Op: "open", Path: name, Err: os.ErrExist}
+	}
+	m.files[name] = append([]byte{}, data...)
+	return nil
+}
+
+// Open opens a file for reading.  Later writes to the file aren't seen by the
+// returned File.
+func (m *MemFS) Open(name string) (File, error) {
+	data, err := m.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return memFile{bytes.NewReader(data)}, nil
+}
+
+type memFile struct {
+	*bytes.Reader
+}
+
+func (memFile) Close() error {
+	return nil
+}
+
+// Create creates or truncates a file, whose contents are written on Close.
+// Its directory must exist.
+func (m *MemFS) Create(name string) (io.WriteCloser, error) {
+	if err := m.WriteFile(name, nil, 0666); err != nil {
+		return nil, err
+	}
+	return &memWriter{fs: m, name: name}, nil
+}
+
+type memWriter struct {
+	bytes.Buffer
+	fs   *MemFS
+	name string
+}
+
+func (w *memWriter) Close() error {
+	return w.fs.WriteFile(w.name, w.Bytes(), 0666)
+}
+
+// Size returns the size of a file in bytes.
+func (m *MemFS) Size(name string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.files[filepath.Clean(name)]
+	if !ok {
+		return 0, notExist("stat", name)
+	}
+	return int64(len(data)), nil
+}
+
+// MkdirAll creates a directory and any missing parents.
+func (m *MemFS) MkdirAll(name string, perm os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for dir := filepath.Clean(name); !m.dirs[dir]; dir = filepath.Dir(dir) {
+		if _, ok := m.files[dir]; ok {
+			return &os.PathError{Op: "mkdir", Path: dir, Err: os.ErrExist}
+		}
+		m.dirs[dir] = true
+	}
+	return nil
+}
+
+// Remove removes a file or empty directory.
+func (m *MemFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = filepath.Clean(name)
+	if _, ok := m.files[name]; ok {
+		delete(m.files, name)
+		return nil
+	}
+	if !m.dirs[name] {
+		return notExist("remove", name)
+	}
+	if len(m.children(name)) > 0 {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrExist}
+	}
+	delete(m.dirs, name)
+	return nil
+}
+
+// RemoveAll removes a file or a directory and its contents.
+func (m *MemFS) RemoveAll(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = filepath.Clean(name)
+	prefix := name + string(filepath.Separator)
+	for path := range m.files {
+		if path == name || strings.HasPrefix(path, prefix) {
+			delete(m.files, path)
+		}
+	}
+	for path := range m.dirs {
+		if path == name || strings.HasPrefix(path, prefix) {
+			delete(m.dirs, path)
+		}
+	}
+	return nil
+}
+
+// ReadDirNames returns the sorted names of the entries of a directory.
+func (m *MemFS) ReadDirNames(name string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = filepath.Clean(name)
+	if !m.dirs[name] {
+		return nil, notExist("open", name)
+	}
+	return m.children(name), nil
+}
+
+func (m *MemFS) children(dir string) []string {
+	names := []string{}
+	add := func(path string) {
+		if path != dir && filepath.Dir(path) == dir {
+			names = append(names, filepath.Base(path))
+		}
+	}
+	for path := range m.files {
+		add(path)
+	}
+	for path := range m.dirs {
+		add(path)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsDir reports whether name is a directory.
+func (m *MemFS) IsDir(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.dirs[filepath.Clean(name)]
+}
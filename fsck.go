@@ -0,0 +1,101 @@
+package lit
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// relationKeys are the fields holding ids of other issues.
+var relationKeys = []string{"parent", "depends-on", "blocks", "duplicate-of", "references"}
+
+var issueDirRe = regexp.MustCompile(`^(?:[0-9a-f]{8}-[0-9a-f-]+|[0-9a-hjkmnp-tv-z]{26}|[0-9]+)$`)
+
+// Problem describes an inconsistency found by Check.
+type Problem struct {
+	ID     string // issue or attachment directory
+	Kind   string // e.g. "orphan attachments", "dangling relation", or "bad stamp"
+	Detail string
+	prune  func() error
+}
+
+func (p *Problem) String() string {
+	return fmt.Sprintf("%s: %s: %s", p.ID, p.Kind, p.Detail)
+}
+
+// CanPrune reports whether Prune can fix the problem.
+func (p *Problem) CanPrune() bool {
+	return p.prune != nil
+}
+
+// Prune fixes the problem, by removing orphan attachments or dangling
+// relations.  Changes to issues are written by the next Store.
+func (p *Problem) Prune() error {
+	if p.prune == nil {
+		return fmt.Errorf("%s: %s can't be pruned", p.ID, p.Kind)
+	}
+	return p.prune()
+}
+
+// Check reports attachment directories without an issue, relations to
+// missing issues, and comments with malformed stamps.
+func (l *Lit) Check() ([]*Problem, error) {
+	problems := []*Problem{}
+	names, err := l.fs.ReadDirNames(l.issueDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		dir := filepath.Join(l.issueDir, name)
+		if !issueDirRe.MatchString(name) || !l.fs.IsDir(dir) {
+			continue
+		}
+		if _, ok := l.issueMap[name]; !ok {
+			problems = append(problems, &Problem{
+				ID: name, Kind: "orphan attachments", Detail: "no such issue",
+				prune: func() error { return l.fs.RemoveAll(dir) },
+			})
+		}
+	}
+	for _, id := range l.issueIds {
+		issue := l.issueMap[id]
+		problems = append(problems, l.checkRelations(issue)...)
+		for _, k := range issue.Kids() {
+			if comment, ok := k.(*dgrl.Branch); ok {
+				if _, _, err := ParseStamp(comment.Key()); err != nil {
+					problems = append(problems, &Problem{ID: id, Kind: "bad stamp", Detail: err.Error()})
+				}
+			}
+		}
+	}
+	return problems, nil
+}
+
+func (l *Lit) checkRelations(issue *dgrl.Branch) []*Problem {
+	problems := []*Problem{}
+	for _, key := range relationKeys {
+		val, ok := GetExact(issue, key)
+		if !ok {
+			continue
+		}
+		for _, ref := range strings.Fields(val) {
+			if _, ok := l.issueMap[ref]; ok {
+				continue
+			}
+			key, ref := key, ref
+			problems = append(problems, &Problem{
+				ID: issue.Key(), Kind: "dangling relation", Detail: key + " " + ref,
+				prune: func() error {
+					if !ModifyValues(issue, key, ref, false) {
+						return fmt.Errorf("error updating %s in issue %s", key, issue.Key())
+					}
+					return nil
+				},
+			})
+		}
+	}
+	return problems
+}
@@ -0,0 +1,142 @@
+package lit
+
+import (
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// GCStats reports what GC cleaned up.
+type GCStats struct {
+	Fields       int   // fields and comments with stray whitespace trimmed
+	Orphans      int   // attachment directories without an issue removed
+	CacheEntries int   // stale cache entries removed
+	IssueBytes   int64 // bytes reclaimed from the issue file
+	OrphanBytes  int64 // bytes reclaimed from orphan attachments
+	CacheBytes   int64 // bytes reclaimed from the cache
+}
+
+// GC compacts the tracker: it trims stray whitespace from fields and
+// comments, drops blank text outside of fields and comments, removes orphan
+// attachment directories and stale cache entries, and rewrites the issue
+// file with up to date references.
+func (l *Lit) GC() (*GCStats, error) {
+	stats := &GCStats{}
+	path := filepath.Join(l.issueDir, issueFilename)
+	data, err := l.fs.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range l.issueIds {
+		stats.Fields += compactIssue(l.issueMap[id])
+		for _, p := range l.lintFields(l.issueMap[id]) {
+			if p.Kind == "whitespace" {
+				p.Prune()
+				stats.Fields++
+			}
+		}
+	}
+	problems, err := l.Check()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range problems {
+		if p.Kind != "orphan attachments" {
+			continue
+		}
+		size := dirSize(filepath.Join(l.issueDir, p.ID))
+		if err := p.Prune(); err != nil {
+			return nil, err
+		}
+		stats.Orphans++
+		stats.OrphanBytes += size
+	}
+	hash := issueHash(data)
+	names, _ := l.fs.ReadDirNames(filepath.Join(l.issueDir, cacheDirname))
+	for _, name := range names {
+		if len(name) != sha256.Size*2 || name == hash {
+			continue
+		}
+		if entry, err := l.fs.ReadFile(l.cachePath(name)); err == nil {
+			stats.CacheBytes += int64(len(entry))
+		}
+		if err := l.fs.Remove(l.cachePath(name)); err != nil {
+			return nil, err
+		}
+		stats.CacheEntries++
+	}
+	l.changed = nil // reindex everything
+	if err := l.Store(); err != nil {
+		return nil, err
+	}
+	compacted, err := l.fs.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	stats.IssueBytes = int64(len(data) - len(compacted))
+	return stats, nil
+}
+
+// compactIssue trims trailing whitespace from the lines of an issue's long
+// fields and comments and drops blank text outside of them, returning the
+// number of nodes changed.
+func compactIssue(issue *dgrl.Branch) int {
+	n := 0
+	kids := []dgrl.Node{}
+	for _, k := range issue.Kids() {
+		switch node := k.(type) {
+		case *dgrl.Leaf:
+			if node.Key() == "" && strings.TrimSpace(node.Value()) == "" {
+				n++
+				continue
+			}
+			if node.Type() != dgrl.LeafType && trimLines(node) {
+				n++
+			}
+		case *dgrl.Branch:
+			for _, kk := range node.Kids() {
+				if leaf, ok := kk.(*dgrl.Leaf); ok && trimLines(leaf) {
+					n++
+				}
+			}
+		}
+		kids = append(kids, k)
+	}
+	if len(kids) < issue.NumKids() {
+		compacted := dgrl.NewBranch(issue.Key())
+		for _, k := range kids {
+			compacted.Append(k)
+		}
+		*issue = *compacted
+	}
+	return n
+}
+
+// trimLines trims trailing spaces and tabs from each line of a leaf's value,
+// reporting whether it changed.
+func trimLines(leaf *dgrl.Leaf) bool {
+	lines := strings.Split(leaf.Value(), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " \t\r")
+	}
+	val := strings.Join(lines, "\n")
+	if val == leaf.Value() {
+		return false
+	}
+	leaf.SetValue(val)
+	return true
+}
+
+func dirSize(dir string) int64 {
+	size := int64(0)
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
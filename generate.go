@@ -0,0 +1,31 @@
+package lit
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/ianremmler/dgrl"
+)
+
+var generateWords = []string{
+	"crash", "login", "slow", "foo", "bar", "render", "cache", "timeout",
+	"parser", "memory", "leak", "button", "config", "network", "disk",
+}
+
+// Generate adds num synthetic issues, with summaries, priorities, and tags
+// chosen using r, for tests and benchmarks.  The same seed gives the same
+// fields, though ids and stamps differ.
+func (l *Lit) Generate(username string, num int, r *rand.Rand) []*dgrl.Branch {
+	issues := l.NewIssues(username, num)
+	for _, issue := range issues {
+		words := make([]string, 5)
+		for i := range words {
+			words[i] = generateWords[r.Intn(len(generateWords))]
+		}
+		Set(issue, "summary", strings.Join(words, " "))
+		Set(issue, "priority", strconv.Itoa(r.Intn(5)))
+		ModifyTag(issue, generateWords[r.Intn(len(generateWords))], true)
+	}
+	return issues
+}
@@ -0,0 +1,225 @@
+package lit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// GitBug is the form in which git-bug prints a bug with
+// "git bug show --format json".
+type GitBug struct {
+	ID         string          `json:"id"`
+	HumanID    string          `json:"human_id"`
+	CreateTime GitBugTime      `json:"create_time"`
+	EditTime   GitBugTime      `json:"edit_time"`
+	Status     string          `json:"status"`
+	Labels     []string        `json:"labels"`
+	Title      string          `json:"title"`
+	Author     GitBugIdentity  `json:"author"`
+	Comments   []GitBugComment `json:"comments"`
+}
+
+// GitBugTime is a git-bug operation time.
+type GitBugTime struct {
+	Timestamp int64     `json:"timestamp"`
+	Time      time.Time `json:"time"`
+}
+
+// GitBugIdentity is a git-bug user.
+type GitBugIdentity struct {
+	ID      string `json:"id,omitempty"`
+	HumanID string `json:"human_id,omitempty"`
+	Name    string `json:"name"`
+	Login   string `json:"login,omitempty"`
+}
+
+// GitBugComment is a git-bug comment.  The first comment of a bug is its
+// description.
+type GitBugComment struct {
+	ID      string         `json:"id,omitempty"`
+	HumanID string         `json:"human_id,omitempty"`
+	Author  GitBugIdentity `json:"author"`
+	Message string         `json:"message"`
+	Time    *GitBugTime    `json:"time,omitempty"`
+}
+
+// ImportGitBug adds the bugs read from r, a JSON array or stream of bugs as
+// printed by "git bug show --format json".  Each issue records its bug's id in
+// the git-bug-id field, and bugs already imported or exported from the tracker
+// are skipped.
+func (l *Lit) ImportGitBug(r io.Reader, username string) ([]*dgrl.Branch, error) {
+	bugs, err := decodeGitBugs(r)
+	if err != nil {
+		return nil, err
+	}
+	known := l.fieldValues("git-bug-id")
+	newBugs := []*GitBug{}
+	for _, bug := range bugs {
+		_, isIssue := l.issueMap[bug.ID]
+		if _, ok := known[bug.ID]; !ok && !isIssue && bug.ID != "" {
+			known[bug.ID] = struct{}{}
+			newBugs = append(newBugs, bug)
+		}
+	}
+	issues := l.NewIssues(username, len(newBugs))
+	for i, bug := range newBugs {
+		if err := gitBugIssue(issues[i], bug); err != nil {
+			return nil, err
+		}
+	}
+	return issues, nil
+}
+
+// decodeGitBugs reads either a JSON array of bugs or a stream of them, as
+// printed by running "git bug show" for several bugs.
+func decodeGitBugs(r io.Reader) ([]*GitBug, error) {
+	br := bufio.NewReader(r)
+	for {
+		c, _, err := br.ReadRune()
+		if err == io.EOF {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !unicode.IsSpace(c) {
+			br.UnreadRune()
+			break
+		}
+	}
+	dec := json.NewDecoder(br)
+	bugs := []*GitBug{}
+	if c, _ := br.Peek(1); c[0] == '[' {
+		if err := dec.Decode(&bugs); err != nil {
+			return nil, fmt.Errorf("error parsing git-bug json: %v", err)
+		}
+		return bugs, nil
+	}
+	for {
+		bug := &GitBug{}
+		err := dec.Decode(bug)
+		if err == io.EOF {
+			return bugs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error parsing git-bug json: %v", err)
+		}
+		bugs = append(bugs, bug)
+	}
+}
+
+func gitBugIssue(issue *dgrl.Branch, bug *GitBug) error {
+	created := stampAt(bug.CreateTime.Time, gitBugUser(bug.Author))
+	ok := SetForce(issue, "created", created) &&
+		SetForce(issue, "updated", stampAt(bug.EditTime.Time, gitBugUser(bug.Author))) &&
+		Set(issue, "summary", bug.Title) &&
+		Set(issue, "tags", forgeTags(bug.Labels)) &&
+		SetExact(issue, "git-bug-id", bug.ID)
+	if bug.Status == "closed" {
+		ok = ok && SetForce(issue, "closed", stampAt(bug.EditTime.Time, gitBugUser(bug.Author)))
+	}
+	for i, c := range bug.Comments {
+		if i == 0 {
+			ok = ok && set(issue, "description", c.Message, false, true, false)
+			continue
+		}
+		t := bug.CreateTime.Time
+		if c.Time != nil {
+			t = c.Time.Time
+		}
+		comment := dgrl.NewBranch(stampAt(t, gitBugUser(c.Author)))
+		comment.Append(dgrl.NewText(c.Message))
+		issue.Append(comment)
+	}
+	if !ok {
+		return fmt.Errorf("error setting fields for issue %s", issue.Key())
+	}
+	return nil
+}
+
+func gitBugUser(id GitBugIdentity) string {
+	if id.Login != "" {
+		return id.Login
+	}
+	return strings.Join(strings.Fields(id.Name), "-")
+}
+
+// ExportGitBug writes the issues with the given ids as a JSON array of bugs in
+// the form read by ImportGitBug, leaving out confidential issues.
+func (l *Lit) ExportGitBug(w io.Writer, ids []string) error {
+	bugs := []*GitBug{}
+	for _, id := range l.Public(ids) {
+		issue := l.issueMap[id]
+		if issue == nil {
+			continue
+		}
+		bugs = append(bugs, litGitBug(issue))
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(bugs)
+}
+
+func litGitBug(issue *dgrl.Branch) *GitBug {
+	created, author, _ := ParseStamp(fieldVal(issue, "created"))
+	edited, _, _ := ParseStamp(fieldVal(issue, "updated"))
+	id := fieldVal(issue, "git-bug-id")
+	if id == "" {
+		id = issue.Key()
+	}
+	bug := &GitBug{
+		ID:         id,
+		HumanID:    shortID(id),
+		CreateTime: gitBugTime(created),
+		EditTime:   gitBugTime(edited),
+		Status:     "open",
+		Labels:     strings.Fields(fieldVal(issue, "tags")),
+		Title:      fieldVal(issue, "summary"),
+		Author:     GitBugIdentity{Name: author, Login: author},
+	}
+	if !isOpen(issue) {
+		bug.Status = "closed"
+	}
+	sort.Strings(bug.Labels)
+	bug.Comments = append(bug.Comments, GitBugComment{Author: bug.Author, Message: fieldVal(issue, "description")})
+	for _, c := range issueComments(issue) {
+		t, user, _ := ParseStamp(c.Stamp)
+		ct := gitBugTime(t)
+		bug.Comments = append(bug.Comments, GitBugComment{
+			Author:  GitBugIdentity{Name: user, Login: user},
+			Message: strings.TrimSpace(c.Text),
+			Time:    &ct,
+		})
+	}
+	return bug
+}
+
+func gitBugTime(t time.Time) GitBugTime {
+	return GitBugTime{Timestamp: t.Unix(), Time: t.UTC()}
+}
+
+func shortID(id string) string {
+	if len(id) > 7 {
+		return id[:7]
+	}
+	return id
+}
+
+// fieldValues returns the set of values of a field across all issues.
+func (l *Lit) fieldValues(key string) map[string]struct{} {
+	vals := map[string]struct{}{}
+	for _, issue := range l.issueMap {
+		if val := fieldVal(issue, key); val != "" {
+			vals[val] = struct{}{}
+		}
+	}
+	return vals
+}
@@ -0,0 +1,179 @@
+package lit
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Gitea is the issue tracker of a Gitea (or Forgejo) repository.
+type Gitea struct {
+	api    forgeAPI
+	repo   string
+	labels map[string]int64
+}
+
+// NewGitea returns the Gitea issue tracker of the repository (owner/name) on
+// the server at url.
+func NewGitea(url, repo, token string) *Gitea {
+	api := forgeAPI{base: strings.TrimRight(url, "/") + "/api/v1", authHeader: "Authorization"}
+	if token != "" {
+		api.auth = "token " + token
+	}
+	return &Gitea{api: api, repo: "/repos/" + repo}
+}
+
+type giteaIssue struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	Body   string `json:"body"`
+	State  string `json:"state"`
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	Updated string `json:"updated_at"`
+}
+
+func (gi *giteaIssue) forgeIssue() *ForgeIssue {
+	fi := &ForgeIssue{Number: gi.Number, Title: gi.Title, Body: gi.Body, Closed: gi.State == "closed", Updated: gi.Updated}
+	for _, label := range gi.Labels {
+		fi.Labels = append(fi.Labels, label.Name)
+	}
+	return fi
+}
+
+// Issues returns all issues, excluding pull requests.
+func (g *Gitea) Issues() ([]*ForgeIssue, error) {
+	issues := []*ForgeIssue{}
+	err := pages(50, func(page int) (int, error) {
+		found := []giteaIssue{}
+		path := fmt.Sprintf("%s/issues?state=all&type=issues&limit=50&page=%d", g.repo, page)
+		if err := g.api.call(http.MethodGet, path, nil, &found); err != nil {
+			return 0, err
+		}
+		for i := range found {
+			issues = append(issues, found[i].forgeIssue())
+		}
+		return len(found), nil
+	})
+	return issues, err
+}
+
+// Issue returns the numbered issue.
+func (g *Gitea) Issue(number int) (*ForgeIssue, error) {
+	gi := &giteaIssue{}
+	if err := g.api.call(http.MethodGet, fmt.Sprintf("%s/issues/%d", g.repo, number), nil, gi); err != nil {
+		return nil, err
+	}
+	return gi.forgeIssue(), nil
+}
+
+// Comments returns the comments of the numbered issue.
+func (g *Gitea) Comments(number int) ([]ForgeComment, error) {
+	found := []struct {
+		ID   int64  `json:"id"`
+		Body string `json:"body"`
+		User struct {
+			Login string `json:"login"`
+		} `json:"user"`
+		Created time.Time `json:"created_at"`
+	}{}
+	if err := g.api.call(http.MethodGet, fmt.Sprintf("%s/issues/%d/comments", g.repo, number), nil, &found); err != nil {
+		return nil, err
+	}
+	comments := []ForgeComment{}
+	for _, c := range found {
+		comments = append(comments, ForgeComment{ID: c.ID, Author: c.User.Login, Body: c.Body, Created: c.Created})
+	}
+	return comments, nil
+}
+
+// CreateIssue creates an issue, returning its number.
+func (g *Gitea) CreateIssue(fi *ForgeIssue) (int, error) {
+	labels, err := g.labelIDs(fi.Labels)
+	if err != nil {
+		return 0, err
+	}
+	gi := &giteaIssue{}
+	in := map[string]interface{}{"title": fi.Title, "body": fi.Body, "labels": labels}
+	if err := g.api.call(http.MethodPost, g.repo+"/issues", in, gi); err != nil {
+		return 0, err
+	}
+	if fi.Closed {
+		fi.Number = gi.Number
+		return gi.Number, g.UpdateIssue(fi)
+	}
+	return gi.Number, nil
+}
+
+// UpdateIssue updates the title, body, labels, and state of an issue.
+func (g *Gitea) UpdateIssue(fi *ForgeIssue) error {
+	state := "open"
+	if fi.Closed {
+		state = "closed"
+	}
+	path := fmt.Sprintf("%s/issues/%d", g.repo, fi.Number)
+	in := map[string]interface{}{"title": fi.Title, "body": fi.Body, "state": state}
+	if err := g.api.call(http.MethodPatch, path, in, nil); err != nil {
+		return err
+	}
+	labels, err := g.labelIDs(fi.Labels)
+	if err != nil {
+		return err
+	}
+	return g.api.call(http.MethodPut, path+"/labels", map[string]interface{}{"labels": labels}, nil)
+}
+
+// AddComment comments on the numbered issue, returning the comment's id.
+func (g *Gitea) AddComment(number int, body string) (int64, error) {
+	c := &struct {
+		ID int64 `json:"id"`
+	}{}
+	path := fmt.Sprintf("%s/issues/%d/comments", g.repo, number)
+	err := g.api.call(http.MethodPost, path, map[string]string{"body": body}, c)
+	return c.ID, err
+}
+
+// labelIDs returns the ids of the named labels, creating any that are
+// missing, since Gitea sets labels by id.
+func (g *Gitea) labelIDs(names []string) ([]int64, error) {
+	if g.labels == nil {
+		g.labels = map[string]int64{}
+		err := pages(50, func(page int) (int, error) {
+			found := []struct {
+				ID   int64  `json:"id"`
+				Name string `json:"name"`
+			}{}
+			path := fmt.Sprintf("%s/labels?limit=50&page=%d", g.repo, page)
+			if err := g.api.call(http.MethodGet, path, nil, &found); err != nil {
+				return 0, err
+			}
+			for _, label := range found {
+				g.labels[label.Name] = label.ID
+			}
+			return len(found), nil
+		})
+		if err != nil {
+			g.labels = nil
+			return nil, err
+		}
+	}
+	ids := []int64{}
+	for _, name := range names {
+		id, ok := g.labels[name]
+		if !ok {
+			label := &struct {
+				ID int64 `json:"id"`
+			}{}
+			in := map[string]string{"name": name, "color": "#cccccc"}
+			if err := g.api.call(http.MethodPost, g.repo+"/labels", in, label); err != nil {
+				return nil, err
+			}
+			id = label.ID
+			g.labels[name] = id
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,125 @@
+package lit
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// GitLab is the issue tracker of a GitLab project.
+type GitLab struct {
+	api     forgeAPI
+	project string
+}
+
+// NewGitLab returns the GitLab issue tracker of the project (group/name) on
+// the server at url.
+func NewGitLab(serverURL, project, token string) *GitLab {
+	api := forgeAPI{base: strings.TrimRight(serverURL, "/") + "/api/v4", authHeader: "PRIVATE-TOKEN", auth: token}
+	return &GitLab{api: api, project: "/projects/" + url.PathEscape(project)}
+}
+
+type gitlabIssue struct {
+	IID         int      `json:"iid"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	State       string   `json:"state"`
+	Labels      []string `json:"labels"`
+	Updated     string   `json:"updated_at"`
+}
+
+func (gi *gitlabIssue) forgeIssue() *ForgeIssue {
+	return &ForgeIssue{Number: gi.IID, Title: gi.Title, Body: gi.Description, Labels: gi.Labels,
+		Closed: gi.State == "closed", Updated: gi.Updated}
+}
+
+// Issues returns all issues.
+func (g *GitLab) Issues() ([]*ForgeIssue, error) {
+	issues := []*ForgeIssue{}
+	err := pages(100, func(page int) (int, error) {
+		found := []gitlabIssue{}
+		path := fmt.Sprintf("%s/issues?scope=all&per_page=100&page=%d", g.project, page)
+		if err := g.api.call(http.MethodGet, path, nil, &found); err != nil {
+			return 0, err
+		}
+		for i := range found {
+			issues = append(issues, found[i].forgeIssue())
+		}
+		return len(found), nil
+	})
+	return issues, err
+}
+
+// Issue returns the numbered issue.
+func (g *GitLab) Issue(number int) (*ForgeIssue, error) {
+	gi := &gitlabIssue{}
+	if err := g.api.call(http.MethodGet, fmt.Sprintf("%s/issues/%d", g.project, number), nil, gi); err != nil {
+		return nil, err
+	}
+	return gi.forgeIssue(), nil
+}
+
+// Comments returns the comments (notes other than system notes) of the
+// numbered issue, oldest first.
+func (g *GitLab) Comments(number int) ([]ForgeComment, error) {
+	comments := []ForgeComment{}
+	err := pages(100, func(page int) (int, error) {
+		found := []struct {
+			ID     int64  `json:"id"`
+			Body   string `json:"body"`
+			System bool   `json:"system"`
+			Author struct {
+				Username string `json:"username"`
+			} `json:"author"`
+			Created time.Time `json:"created_at"`
+		}{}
+		path := fmt.Sprintf("%s/issues/%d/notes?sort=asc&per_page=100&page=%d", g.project, number, page)
+		if err := g.api.call(http.MethodGet, path, nil, &found); err != nil {
+			return 0, err
+		}
+		for _, n := range found {
+			if !n.System {
+				comments = append(comments, ForgeComment{ID: n.ID, Author: n.Author.Username, Body: n.Body, Created: n.Created})
+			}
+		}
+		return len(found), nil
+	})
+	return comments, err
+}
+
+// CreateIssue creates an issue, returning its number.
+func (g *GitLab) CreateIssue(fi *ForgeIssue) (int, error) {
+	gi := &gitlabIssue{}
+	in := map[string]string{"title": fi.Title, "description": fi.Body, "labels": strings.Join(fi.Labels, ",")}
+	if err := g.api.call(http.MethodPost, g.project+"/issues", in, gi); err != nil {
+		return 0, err
+	}
+	if fi.Closed {
+		fi.Number = gi.IID
+		return gi.IID, g.UpdateIssue(fi)
+	}
+	return gi.IID, nil
+}
+
+// UpdateIssue updates the title, description, labels, and state of an issue.
+func (g *GitLab) UpdateIssue(fi *ForgeIssue) error {
+	event := "reopen"
+	if fi.Closed {
+		event = "close"
+	}
+	in := map[string]string{"title": fi.Title, "description": fi.Body,
+		"labels": strings.Join(fi.Labels, ","), "state_event": event}
+	return g.api.call(http.MethodPut, fmt.Sprintf("%s/issues/%d", g.project, fi.Number), in, nil)
+}
+
+// AddComment comments on the numbered issue, returning the comment's id.
+func (g *GitLab) AddComment(number int, body string) (int64, error) {
+	n := &struct {
+		ID int64 `json:"id"`
+	}{}
+	path := fmt.Sprintf("%s/issues/%d/notes", g.project, number)
+	err := g.api.call(http.MethodPost, path, map[string]string{"body": body}, n)
+	return n.ID, err
+}
@@ -0,0 +1,65 @@
+package lit
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// edgeStyles are the Graphviz attributes for each kind of relation.
+var edgeStyles = map[string]string{
+	"parent":       `style=dashed, label="parent"`,
+	"depends-on":   `label="depends on"`,
+	"blocks":       `label="blocks"`,
+	"duplicate-of": `style=dotted, label="duplicate of"`,
+	"references":   `color=gray`,
+}
+
+// WriteDot writes the given issues and the relations between them, and to
+// the issues they relate to, as a Graphviz dot graph.  Closed issues are
+// drawn gray.
+func (l *Lit) WriteDot(w io.Writer, ids []string) error {
+	if _, err := fmt.Fprintln(w, "digraph issues {\n\tnode [shape=box];"); err != nil {
+		return err
+	}
+	nodes := map[string]bool{}
+	edges := []string{}
+	for _, id := range ids {
+		issue := l.Issue(id)
+		if issue == nil {
+			continue
+		}
+		nodes[issue.Key()] = true
+		for _, key := range relationKeys {
+			val, _ := GetExact(issue, key)
+			for _, ref := range strings.Fields(val) {
+				if _, ok := l.issueMap[ref]; !ok {
+					continue
+				}
+				nodes[ref] = true
+				edges = append(edges, fmt.Sprintf("\t%q -> %q [%s];", l.ShortID(issue.Key()), l.ShortID(ref), edgeStyles[key]))
+			}
+		}
+	}
+	for _, id := range l.issueIds {
+		if !nodes[id] {
+			continue
+		}
+		issue := l.issueMap[id]
+		summary, _ := Get(issue, "summary")
+		attrs := ""
+		if !isOpen(issue) {
+			attrs = ", color=gray, fontcolor=gray"
+		}
+		if _, err := fmt.Fprintf(w, "\t%q [label=%q%s];\n", l.ShortID(id), l.ShortID(id)+"\n"+summary, attrs); err != nil {
+			return err
+		}
+	}
+	for _, edge := range edges {
+		if _, err := fmt.Fprintln(w, edge); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
@@ -0,0 +1,81 @@
+package lit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ianremmler/dgrl"
+)
+
+const hookDirname = "hooks"
+
+// Change describes a proposed change to an issue, as passed to hooks.
+type Change struct {
+	ID    string     `json:"id"`
+	User  string     `json:"user"`
+	Key   string     `json:"key"`
+	Old   string     `json:"old"`
+	New   string     `json:"new"`
+	Issue *IssueData `json:"issue"`
+}
+
+// HookError is returned when a hook rejects a change.
+type HookError struct {
+	Hook    string
+	Message string
+}
+
+func (e *HookError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("rejected by %s hook", e.Hook)
+	}
+	return fmt.Sprintf("rejected by %s hook: %s", e.Hook, e.Message)
+}
+
+// RunHook runs the executable .lit/hooks/<name>, if present, passing the
+// change as JSON on its standard input.  If the hook exits unsuccessfully the
+// change is rejected with a *HookError carrying the hook's output.  Hooks
+// are programs, so they run only for a tracker on the OS file system.
+func (l *Lit) RunHook(name string, change *Change) error {
+	if _, ok := l.fs.(OSFS); !ok {
+		return nil
+	}
+	path := filepath.Join(l.issueDir, hookDirname, name)
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.IsDir() || info.Mode()&0111 == 0 {
+		return nil
+	}
+	input, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+	hook := exec.Command(path)
+	hook.Dir = filepath.Dir(l.issueDir)
+	hook.Stdin = bytes.NewReader(input)
+	output, err := hook.CombinedOutput()
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			return err
+		}
+		return &HookError{Hook: name, Message: strings.TrimSpace(string(output))}
+	}
+	return nil
+}
+
+// NewChange returns a description of a change of key from its current value
+// to val in an issue, for passing to hooks.
+func NewChange(issue *dgrl.Branch, username, key, val string) *Change {
+	old, _ := Get(issue, key)
+	return &Change{ID: issue.Key(), User: username, Key: key, Old: old, New: val, Issue: Data(issue)}
+}
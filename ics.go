@@ -0,0 +1,142 @@
+package lit
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ianremmler/dgrl"
+)
+
+const (
+	icsDateFmt = "20060102"
+	icsTimeFmt = "20060102T150405Z"
+)
+
+// WriteICS writes an iCalendar document containing a VTODO entry for each of
+// the given issues that has a due date and isn't confidential, and a VEVENT
+// entry for the deadline of each release that has one.
+func (l *Lit) WriteICS(w io.Writer, ids []string) error {
+	bw := bufio.NewWriter(w)
+	icsLine(bw, "BEGIN:VCALENDAR")
+	icsLine(bw, "VERSION:2.0")
+	icsLine(bw, "PRODID:-//lit//lit//EN")
+	for _, id := range l.Public(ids) {
+		issue := l.Issue(id)
+		if issue == nil {
+			continue
+		}
+		due, ok := Get(issue, "due")
+		if !ok || due == "" {
+			continue
+		}
+		dueProp, ok := icsDate("DUE", due)
+		if !ok {
+			continue
+		}
+		icsLine(bw, "BEGIN:VTODO")
+		icsLine(bw, "UID:"+issue.Key()+"@lit")
+		icsLine(bw, "DTSTAMP:"+l.icsStamp(issue, "updated"))
+		icsLine(bw, dueProp)
+		summary, _ := Get(issue, "summary")
+		icsLine(bw, "SUMMARY:"+icsEscape(summary))
+		if desc, ok := Get(issue, "description"); ok && desc != "" {
+			icsLine(bw, "DESCRIPTION:"+icsEscape(desc))
+		}
+		if assigned, ok := Get(issue, "assigned"); ok && assigned != "" {
+			icsLine(bw, "X-LIT-ASSIGNED:"+icsEscape(assigned))
+		}
+		if priority, ok := Get(issue, "priority"); ok && len(priority) == 1 &&
+			priority[0] >= '1' && priority[0] <= '9' {
+			icsLine(bw, "PRIORITY:"+priority)
+		}
+		if closed, ok := Get(issue, "closed"); ok && closed != "" {
+			icsLine(bw, "STATUS:COMPLETED")
+		} else {
+			icsLine(bw, "STATUS:NEEDS-ACTION")
+		}
+		icsLine(bw, "END:VTODO")
+	}
+	l.writeReleaseEvents(bw)
+	icsLine(bw, "END:VCALENDAR")
+	return bw.Flush()
+}
+
+// writeReleaseEvents writes a VEVENT for each release deadline, in version
+// order.
+func (l *Lit) writeReleaseEvents(bw *bufio.Writer) {
+	deadlines := l.ReleaseDeadlines()
+	versions := make([]string, 0, len(deadlines))
+	for version := range deadlines {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	for _, version := range versions {
+		start, ok := icsDate("DTSTART", deadlines[version])
+		if !ok {
+			continue
+		}
+		ids := l.Public(l.ReleaseIssues(version))
+		open := 0
+		for _, id := range ids {
+			if isOpen(l.issueMap[id]) {
+				open++
+			}
+		}
+		icsLine(bw, "BEGIN:VEVENT")
+		icsLine(bw, "UID:release-"+icsEscape(version)+"@lit")
+		icsLine(bw, "DTSTAMP:"+l.Now().UTC().Format(icsTimeFmt))
+		icsLine(bw, start)
+		icsLine(bw, "SUMMARY:"+icsEscape("Release "+version))
+		icsLine(bw, "DESCRIPTION:"+icsEscape(fmt.Sprintf("%d issues, %d open", len(ids), open)))
+		if state, _ := l.Config("release-" + version); state != "open" {
+			icsLine(bw, "X-LIT-SHIPPED:"+icsEscape(state))
+		}
+		icsLine(bw, "END:VEVENT")
+	}
+}
+
+// icsDate formats a due value, either a date or an RFC3339 time, as an
+// iCalendar property.
+func icsDate(prop, val string) (string, bool) {
+	if t, err := time.Parse("2006-01-02", val); err == nil {
+		return fmt.Sprintf("%s;VALUE=DATE:%s", prop, t.Format(icsDateFmt)), true
+	}
+	if t, err := time.Parse(time.RFC3339, val); err == nil {
+		return fmt.Sprintf("%s:%s", prop, t.UTC().Format(icsTimeFmt)), true
+	}
+	return "", false
+}
+
+// icsStamp returns the time of the stamp stored under key, or the current
+// time if there is none.
+func (l *Lit) icsStamp(issue *dgrl.Branch, key string) string {
+	if t, ok := stampTime(issue, key); ok {
+		return t.UTC().Format(icsTimeFmt)
+	}
+	return l.Now().UTC().Format(icsTimeFmt)
+}
+
+func icsEscape(val string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(val)
+}
+
+// icsLine writes a content line, folded so that no line is longer than 75
+// octets, counting the space that begins continuation lines, as RFC 5545
+// requires.  Folds don't split UTF-8 sequences.
+func icsLine(w *bufio.Writer, line string) {
+	max := 75
+	for len(line) > max {
+		n := max
+		for n > 0 && line[n]&0xc0 == 0x80 {
+			n--
+		}
+		w.WriteString(line[:n] + "\r\n ")
+		line = line[n:]
+		max = 74
+	}
+	w.WriteString(line + "\r\n")
+}
@@ -0,0 +1,81 @@
+package lit
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestICSFolding(t *testing.T) {
+	tests := []string{
+		strings.Repeat("a", 75),
+		strings.Repeat("a", 76),
+		strings.Repeat("a", 300),
+		strings.Repeat("é", 100),
+		"SUMMARY:" + strings.Repeat("x€", 60),
+	}
+	for _, line := range tests {
+		buf := &bytes.Buffer{}
+		bw := bufio.NewWriter(buf)
+		icsLine(bw, line)
+		bw.Flush()
+		out := strings.TrimSuffix(buf.String(), "\r\n")
+		unfolded := ""
+		for i, l := range strings.Split(out, "\r\n") {
+			if len(l) > 75 {
+				t.Errorf("line %d of %d octets", i, len(l))
+			}
+			if i > 0 {
+				if !strings.HasPrefix(l, " ") {
+					t.Errorf("continuation line %d doesn't start with a space", i)
+				}
+				l = l[1:]
+			}
+			unfolded += l
+		}
+		if unfolded != line {
+			t.Errorf("unfolded %q, want %q", unfolded, line)
+		}
+	}
+}
+
+func TestICSReleaseEvents(t *testing.T) {
+	l := memTracker(t, 2)
+	l.SetClock(ClockFunc(func() time.Time { return testTime }))
+	if err := l.SetReleaseDue("1.0", "2024-04-01"); err == nil {
+		t.Errorf("set the deadline of a nonexistent release")
+	}
+	if err := l.StartRelease("1.0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.SetReleaseDue("1.0", "2024-04-01"); err != nil {
+		t.Fatal(err)
+	}
+	ids := l.IssueIds()
+	for _, id := range ids {
+		if err := l.AddToRelease(l.Issue(id), "1.0"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Close(l.Issue(ids[0]), "tester", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	if err := l.WriteICS(buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"BEGIN:VEVENT",
+		"UID:release-1.0@lit",
+		"DTSTAMP:20240301T120000Z",
+		"DTSTART;VALUE=DATE:20240401",
+		"SUMMARY:Release 1.0",
+		"DESCRIPTION:2 issues\\, 1 open",
+		"END:VEVENT",
+	}, "\r\n")
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("ics:\n%s\nwant event:\n%s", buf.String(), want)
+	}
+}
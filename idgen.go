@@ -0,0 +1,141 @@
+package lit
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/satori/go.uuid"
+)
+
+// IDGenerator generates ids for new issues.
+type IDGenerator interface {
+	// NewID returns a new id, given the current time and the existing ids.
+	NewID(now time.Time, ids []string) string
+}
+
+// IDGenerators are the available id generators by name, as configured with
+// "id-format".  The default is uuid.
+var IDGenerators = map[string]IDGenerator{
+	"uuid":  uuidV4{},
+	"uuid7": uuidV7{},
+	"ulid":  ulid{},
+	"seq":   sequence{},
+}
+
+// SetIDGenerator sets the id generator used by the tracker, overriding the
+// id-format config.
+func (l *Lit) SetIDGenerator(gen IDGenerator) {
+	l.idGen = gen
+}
+
+func (l *Lit) idGenerator() IDGenerator {
+	if l.idGen != nil {
+		return l.idGen
+	}
+	if gen, ok := IDGenerators[l.idFormat()]; ok {
+		return gen
+	}
+	return IDGenerators["uuid"]
+}
+
+func (l *Lit) idFormat() string {
+	format, _ := l.Config("id-format")
+	return strings.TrimSpace(format)
+}
+
+// ShortID returns the short form of an id, its first eight characters, or the
+// whole id if it is shorter, as with sequential ids.
+func ShortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// ShortLen returns the length of the tracker's short ids, the shortest that
+// tells all of its issues apart, but at least eight.  Ids that begin with their
+// creation time, like uuid7 and ulid, need more for issues created together.
+func (l *Lit) ShortLen() int {
+	if l.shortLen == 0 {
+		l.shortLen = 8
+		for i := 1; i < len(l.issueIds); i++ {
+			a, b := l.issueIds[i-1], l.issueIds[i]
+			n := 0
+			for n < len(a) && n < len(b) && a[n] == b[n] {
+				n++
+			}
+			if n+1 > l.shortLen {
+				l.shortLen = n + 1
+			}
+		}
+	}
+	return l.shortLen
+}
+
+// ShortID returns the short form of an issue id, its first ShortLen
+// characters.
+func (l *Lit) ShortID(id string) string {
+	if n := l.ShortLen(); len(id) > n {
+		return id[:n]
+	}
+	return id
+}
+
+// uuidV4 generates random UUIDs.
+type uuidV4 struct{}
+
+func (uuidV4) NewID(now time.Time, ids []string) string {
+	return uuid.NewV4().String()
+}
+
+// uuidV7 generates UUIDs that sort by creation time, per RFC 9562.
+type uuidV7 struct{}
+
+func (uuidV7) NewID(now time.Time, ids []string) string {
+	b := make([]byte, 16)
+	rand.Read(b[6:])
+	ms := uint64(now.UnixNano() / int64(time.Millisecond))
+	binary.BigEndian.PutUint16(b[0:], uint16(ms>>32))
+	binary.BigEndian.PutUint32(b[2:], uint32(ms))
+	b[6] = b[6]&0x0f | 0x70 // version 7
+	b[8] = b[8]&0x3f | 0x80 // variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// ulid generates ULIDs, which sort by creation time, in lower case.
+type ulid struct{}
+
+const crockford = "0123456789abcdefghjkmnpqrstvwxyz"
+
+func (ulid) NewID(now time.Time, ids []string) string {
+	b := make([]byte, 16)
+	rand.Read(b[6:])
+	ms := uint64(now.UnixNano() / int64(time.Millisecond))
+	binary.BigEndian.PutUint16(b[0:], uint16(ms>>32))
+	binary.BigEndian.PutUint32(b[2:], uint32(ms))
+	hi, lo := binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])
+	id := make([]byte, 26)
+	for i := 25; i >= 0; i-- {
+		id[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(id)
+}
+
+// sequence generates sequential numbers, counting from 1.
+type sequence struct{}
+
+func (sequence) NewID(now time.Time, ids []string) string {
+	max := 0
+	for _, id := range ids {
+		if n, err := strconv.Atoi(id); err == nil && n > max {
+			max = n
+		}
+	}
+	return strconv.Itoa(max + 1)
+}
@@ -0,0 +1,63 @@
+package lit
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeOrderedIDs(t *testing.T) {
+	tests := []struct {
+		ms          int64
+		uuid7, ulid string // time prefixes
+	}{
+		{0, "00000000-0000", "0000000000"},
+		{1, "00000000-0001", "0000000001"},
+		{32, "00000000-0020", "0000000010"},
+		{1709294400000, "018df9e2-b200", "01hqwy5cg0"},
+	}
+	for _, test := range tests {
+		now := time.Unix(0, test.ms*int64(time.Millisecond))
+		id := uuidV7{}.NewID(now, nil)
+		if len(id) != 36 || !strings.HasPrefix(id, test.uuid7) {
+			t.Errorf("uuid7 at %d ms = %s, want prefix %s", test.ms, id, test.uuid7)
+		}
+		if id[14] != '7' || !strings.ContainsRune("89ab", rune(id[19])) {
+			t.Errorf("uuid7 %s: wrong version or variant", id)
+		}
+		id = ulid{}.NewID(now, nil)
+		if len(id) != 26 || !strings.HasPrefix(id, test.ulid) {
+			t.Errorf("ulid at %d ms = %s, want prefix %s", test.ms, id, test.ulid)
+		}
+		for _, c := range id {
+			if !strings.ContainsRune(crockford, c) {
+				t.Errorf("ulid %s: %c isn't Crockford base32", id, c)
+			}
+		}
+	}
+	for _, gen := range []IDGenerator{uuidV7{}, ulid{}} {
+		a, b := gen.NewID(testTime, nil), gen.NewID(testTime.Add(time.Millisecond), nil)
+		if a >= b {
+			t.Errorf("%T: %s made before %s doesn't sort first", gen, a, b)
+		}
+	}
+}
+
+func TestSequenceIDs(t *testing.T) {
+	tests := []struct {
+		ids  []string
+		want string
+	}{
+		{nil, "1"},
+		{[]string{"1", "2", "3"}, "4"},
+		{[]string{"9", "10", "2"}, "11"},
+		{[]string{"5", "0123abcd-uuid", "x7"}, "6"},
+		{[]string{"0123abcd-uuid"}, "1"},
+		{[]string{"007"}, "8"},
+	}
+	for _, test := range tests {
+		if got := (sequence{}).NewID(testTime, test.ids); got != test.want {
+			t.Errorf("seq after %v = %s, want %s", test.ids, got, test.want)
+		}
+	}
+}
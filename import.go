@@ -0,0 +1,146 @@
+package lit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/ianremmler/dgrl"
+)
+
+var (
+	orgHeadingRe = regexp.MustCompile(`^(\*+)\s+(?:(TODO|DONE)\s+)?(.*)$`)
+	mdHeadingRe  = regexp.MustCompile(`^(#+)\s+(.*)$`)
+	checkItemRe  = regexp.MustCompile(`^(\s*)[-+*]\s+\[([ xX])\]\s+(.*)$`)
+)
+
+type outlineItem struct {
+	depth       int
+	summary     string
+	description []string
+	done        bool
+	parent      int
+}
+
+// ImportOutline creates issues from the headings and checklist items of an
+// Org-mode ("org") or Markdown ("md") document.  Nesting is preserved by
+// setting each issue's parent field to the id of the enclosing item.
+func (l *Lit) ImportOutline(r io.Reader, format, username string) ([]*dgrl.Branch, error) {
+	var headingRe *regexp.Regexp
+	switch format {
+	case "org":
+		headingRe = orgHeadingRe
+	case "md":
+		headingRe = mdHeadingRe
+	default:
+		return nil, fmt.Errorf("unknown outline format '%s'", format)
+	}
+
+	items := []*outlineItem{}
+	stack := []int{}
+	headingDepth := 0
+	add := func(item *outlineItem) {
+		for len(stack) > 0 && items[stack[len(stack)-1]].depth >= item.depth {
+			stack = stack[:len(stack)-1]
+		}
+		item.parent = -1
+		if len(stack) > 0 {
+			item.parent = stack[len(stack)-1]
+		}
+		stack = append(stack, len(items))
+		items = append(items, item)
+	}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := headingRe.FindStringSubmatch(line); m != nil {
+			headingDepth = len(m[1])
+			item := &outlineItem{depth: headingDepth, summary: strings.TrimSpace(m[len(m)-1])}
+			if format == "org" {
+				item.done = (m[2] == "DONE")
+			}
+			add(item)
+			continue
+		}
+		if m := checkItemRe.FindStringSubmatch(line); m != nil {
+			indent := len(strings.Replace(m[1], "\t", "  ", -1)) / 2
+			add(&outlineItem{
+				depth:   headingDepth + 1 + indent,
+				summary: strings.TrimSpace(m[3]),
+				done:    (m[2] != " "),
+			})
+			continue
+		}
+		if len(stack) > 0 {
+			item := items[stack[len(stack)-1]]
+			if item.depth == headingDepth {
+				item.description = append(item.description, line)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	issues := l.NewIssues(username, len(items))
+	stamp := l.Stamp(username)
+	for i, item := range items {
+		issue := issues[i]
+		Set(issue, "summary", item.summary)
+		if desc := strings.TrimSpace(strings.Join(item.description, "\n")); desc != "" {
+			Set(issue, "description", desc)
+		}
+		if item.done {
+			SetForce(issue, "closed", stamp)
+		}
+		if item.parent >= 0 {
+			Set(issue, "parent", issues[item.parent].Key())
+		}
+	}
+	return issues, nil
+}
+
+// ImportFlit adds the issues from a flit issues file, converting the user-first
+// stamps of its created, updated, and closed fields and comments to lit's
+// form.  Issues already in the tracker are skipped.
+func (l *Lit) ImportFlit(r io.Reader) ([]*dgrl.Branch, error) {
+	flitIssues := dgrl.NewParser().Parse(bufio.NewReader(r))
+	if flitIssues == nil {
+		return nil, errors.New("error parsing flit issues")
+	}
+	issues := []*dgrl.Branch{}
+	for _, k := range flitIssues.Kids() {
+		flitIssue, ok := k.(*dgrl.Branch)
+		if !ok {
+			continue
+		}
+		if _, ok := l.issueMap[flitIssue.Key()]; ok {
+			continue
+		}
+		issue := dgrl.NewBranch(flitIssue.Key())
+		for _, kk := range flitIssue.Kids() {
+			switch node := kk.(type) {
+			case *dgrl.Leaf:
+				// only stamps are converted, not values that merely
+				// begin with a date, like a summary
+				if IsReserved(node.Key()) && node.Type() == dgrl.LeafType {
+					node.SetValue(NormalizeStamp(node.Value()))
+				}
+			case *dgrl.Branch:
+				comment := dgrl.NewBranch(NormalizeStamp(node.Key()))
+				for _, text := range node.Kids() {
+					comment.Append(text)
+				}
+				kk = comment
+			}
+			issue.Append(kk)
+		}
+		l.issues.Append(issue)
+		issues = append(issues, issue)
+	}
+	l.indexIssues()
+	return issues, nil
+}
@@ -0,0 +1,54 @@
+package lit
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ianremmler/dgrl"
+)
+
+func TestImportFlit(t *testing.T) {
+	flit := `== 0123abcd
+- created: alice 2024-01-02 03:04:05
+- updated: alice 2024-01-03 03:04:05
+- closed: 
+- summary: 2024-01-01 release notes
+- due: 2024-02-01
+=== bob 2024-01-03 03:04:05
+~~
+Looks good
+~~.
+`
+	l := memTracker(t, 0)
+	issues, err := l.ImportFlit(strings.NewReader(flit))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 {
+		t.Fatalf("imported %d issues, want 1", len(issues))
+	}
+	issue := issues[0]
+	tests := map[string]string{
+		"created": "2024-01-02T03:04:05Z alice",
+		"updated": "2024-01-03T03:04:05Z alice",
+		"summary": "2024-01-01 release notes",
+		"due":     "2024-02-01",
+	}
+	for key, want := range tests {
+		if got, _ := GetExact(issue, key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	stamps := []string{}
+	for _, k := range issue.Kids() {
+		if comment, ok := k.(*dgrl.Branch); ok {
+			stamps = append(stamps, comment.Key())
+		}
+	}
+	if len(stamps) != 1 || stamps[0] != "2024-01-03T03:04:05Z bob" {
+		t.Errorf("comment stamps = %q", stamps)
+	}
+	if again, err := l.ImportFlit(strings.NewReader(flit)); err != nil || len(again) != 0 {
+		t.Errorf("reimport added %d issues, %v", len(again), err)
+	}
+}
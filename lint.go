@@ -0,0 +1,98 @@
+package lit
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ianremmler/dgrl"
+)
+
+const defaultSummaryMax = 72
+
+// Lint reports style and content problems in the given issues: missing or
+// overlong summaries (longer than the summary-max config, default 72),
+// missing descriptions, stray whitespace in fields, tags not listed in the
+// known-tags config, values not listed in an "allowed-<key>" config (e.g.
+// "allowed-priority: 1 2 3"), unknown assignees, and malformed stamps.  Stray
+// whitespace can be fixed with Prune.
+func (l *Lit) Lint(ids []string) []*Problem {
+	summaryMax := l.SummaryMax()
+	problems := []*Problem{}
+	for _, id := range ids {
+		issue := l.Issue(id)
+		if issue == nil {
+			continue
+		}
+		add := func(kind, detail string) {
+			problems = append(problems, &Problem{ID: issue.Key(), Kind: kind, Detail: detail})
+		}
+		summary, _ := GetExact(issue, "summary")
+		switch n := len([]rune(strings.TrimSpace(summary))); {
+		case n == 0:
+			add("no summary", "summary is empty")
+		case n > summaryMax:
+			add("long summary", fmt.Sprintf("%d characters, over %d", n, summaryMax))
+		}
+		if desc, _ := GetExact(issue, "description"); strings.TrimSpace(desc) == "" {
+			add("no description", "description is empty")
+		}
+		for _, key := range []string{"created", "updated", "closed"} {
+			if stamp, _ := GetExact(issue, key); stamp != "" || key != "closed" {
+				if _, _, err := ParseStamp(stamp); err != nil {
+					add("bad stamp", key+": "+err.Error())
+				}
+			}
+		}
+		problems = append(problems, l.lintFields(issue)...)
+	}
+	return problems
+}
+
+func (l *Lit) lintFields(issue *dgrl.Branch) []*Problem {
+	problems := []*Problem{}
+	knownTags, checkTags := l.Config("known-tags")
+	for _, k := range issue.Kids() {
+		leaf, ok := k.(*dgrl.Leaf)
+		if !ok || leaf.Type() != dgrl.LeafType {
+			continue
+		}
+		key, val := leaf.Key(), leaf.Value()
+		clean := strings.TrimSpace(val)
+		if l.IsMultiValue(key) {
+			clean = strings.Join(strings.Fields(val), " ")
+		}
+		if clean != val {
+			problems = append(problems, &Problem{
+				ID: issue.Key(), Kind: "whitespace", Detail: key,
+				prune: func() error { leaf.SetValue(clean); return nil },
+			})
+		}
+		vals := []string{clean}
+		if l.IsMultiValue(key) {
+			vals = strings.Fields(clean)
+		}
+		allowed, checkAllowed := l.Config("allowed-" + key)
+		for _, v := range vals {
+			switch {
+			case v == "":
+			case key == "tags" && checkTags && !hasField(knownTags, v):
+				problems = append(problems, &Problem{ID: issue.Key(), Kind: "unknown tag", Detail: v})
+			case key == "assigned" && !l.ValidUser(v):
+				problems = append(problems, &Problem{ID: issue.Key(), Kind: "unknown user", Detail: v})
+			case checkAllowed && !hasField(allowed, v):
+				problems = append(problems, &Problem{ID: issue.Key(), Kind: "invalid value", Detail: key + " " + v})
+			}
+		}
+	}
+	return problems
+}
+
+// hasField reports whether a space separated list contains val.
+func hasField(list, val string) bool {
+	for _, f := range strings.Fields(list) {
+		if f == val {
+			return true
+		}
+	}
+	return false
+}
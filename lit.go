@@ -2,96 +2,273 @@
 package lit
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
+	"mime"
+	"net/http"
 	"os"
 	"path"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"sort"
 	"strconv"
 	"strings"
-	"time"
+	"sync"
 
 	"github.com/ianremmler/dgrl"
-	"github.com/satori/go.uuid"
 )
 
 const (
 	issueBaseDir  = ".lit"
 	issueFilename = "issues"
+	aliasFilename = "aliases"
+
+	manifestFilename = ".manifest"
 )
 
-// Stamp returns a string consisting of the current time in RFC3339 UTC format
-// and the username, separated by a space.
-func Stamp(username string) string {
-	return fmt.Sprintf("%s %s", time.Now().UTC().Format(time.RFC3339), username)
+// AmbiguousKeyError is returned when a key prefix matches more than one field
+// of an issue.
+type AmbiguousKeyError struct {
+	Key        string
+	Candidates []string
+}
+
+func (e *AmbiguousKeyError) Error() string {
+	return fmt.Sprintf("key %s is ambiguous, matching %s", e.Key, strings.Join(e.Candidates, ", "))
+}
+
+// fieldIndex returns the index of the field for key in the issue, or -1 if not
+// found.  Unless exact is set, key may be a prefix of a single field key, but
+// an exact match is always preferred.
+func fieldIndex(issue *dgrl.Branch, key string, exact bool) (int, error) {
+	idx, matches := -1, []string{}
+	for i, k := range issue.Kids() {
+		leaf, ok := k.(*dgrl.Leaf)
+		if !ok || leaf.Key() == "" {
+			continue
+		}
+		if leaf.Key() == key {
+			return i, nil
+		}
+		if !exact && strings.HasPrefix(leaf.Key(), key) {
+			idx = i
+			matches = append(matches, leaf.Key())
+		}
+	}
+	if len(matches) > 1 {
+		return -1, &AmbiguousKeyError{Key: key, Candidates: matches}
+	}
+	return idx, nil
+}
+
+// ResolveKey returns the full key of the issue field matching key, which is
+// returned unchanged if there is no match.  An *AmbiguousKeyError is returned if
+// key is a prefix of more than one field key and matches none exactly.
+func ResolveKey(issue *dgrl.Branch, key string) (string, error) {
+	if issue == nil {
+		return key, nil
+	}
+	idx, err := fieldIndex(issue, key, false)
+	if err != nil || idx < 0 {
+		return key, err
+	}
+	return issue.Kids()[idx].(*dgrl.Leaf).Key(), nil
 }
 
 // Get returns the value for the given key, if found in the issue.
-// key may be a substring matching the beginning of the issue key.
+// key may be a substring matching the beginning of the issue key, unless that
+// is ambiguous.
 func Get(issue *dgrl.Branch, key string) (string, bool) {
+	return get(issue, key, false)
+}
+
+// GetExact returns the value for the given key, if found in the issue.  The
+// key must match exactly.
+func GetExact(issue *dgrl.Branch, key string) (string, bool) {
+	return get(issue, key, true)
+}
+
+func get(issue *dgrl.Branch, key string, exact bool) (string, bool) {
 	if issue == nil {
 		return "", false
 	}
-	for _, k := range issue.Kids() {
-		if leaf, ok := k.(*dgrl.Leaf); ok {
-			if strings.HasPrefix(leaf.Key(), key) {
-				return leaf.Value(), true
+	idx, err := fieldIndex(issue, key, exact)
+	if err != nil || idx < 0 {
+		return "", false
+	}
+	return issue.Kids()[idx].(*dgrl.Leaf).Value(), true
+}
+
+// reservedKeys are maintained by lit itself, and may only be written with
+// SetForce or Touch.
+var reservedKeys = map[string]bool{"id": true, "created": true, "updated": true, "closed": true}
+
+// IsReserved reports whether key is reserved for lit's own bookkeeping.
+func IsReserved(key string) bool {
+	return reservedKeys[key]
+}
+
+// Set sets the value for the given key, if found in the issue, or adds it.
+// key may be a substring matching the beginning of the issue key, unless that
+// is ambiguous, in which case nothing is set.  Multi-line values are stored
+// as long values.  Reserved keys are not set.
+func Set(issue *dgrl.Branch, key, val string) bool {
+	return set(issue, key, val, false, strings.Contains(val, "\n"), false)
+}
+
+// SetExact sets the value for the given key, which must match exactly, adding
+// it if not found in the issue.  Reserved keys are not set.
+func SetExact(issue *dgrl.Branch, key, val string) bool {
+	return set(issue, key, val, true, strings.Contains(val, "\n"), false)
+}
+
+// SetForce sets the value for the given key, which must match exactly, even if
+// it is reserved.
+func SetForce(issue *dgrl.Branch, key, val string) bool {
+	return set(issue, key, val, true, strings.Contains(val, "\n"), true)
+}
+
+// SetLong sets the value for the given key as a long (multi-line) value,
+// converting an existing short value or adding the key after the issue's
+// other fields if not found.  Keys match as for Set.
+func SetLong(issue *dgrl.Branch, key, val string) bool {
+	return set(issue, key, val, false, true, false)
+}
+
+// Touch sets the issue's update stamp.
+func Touch(issue *dgrl.Branch, stamp string) bool {
+	return SetForce(issue, "updated", stamp)
+}
+
+// Reopen clears an issue's closed stamp.  If it was closed, the reopening is
+// counted in its reopen-count field and its stamp added to the reopened
+// field, one per line.
+func Reopen(issue *dgrl.Branch, stamp string) bool {
+	if closed, _ := GetExact(issue, "closed"); closed == "" {
+		return issue != nil
+	}
+	count, _ := GetExact(issue, "reopen-count")
+	n, _ := strconv.Atoi(count)
+	history, _ := GetExact(issue, "reopened")
+	if history != "" {
+		history += "\n"
+	}
+	return SetForce(issue, "closed", "") &&
+		SetExact(issue, "reopen-count", strconv.Itoa(n+1)) &&
+		set(issue, "reopened", history+stamp, true, true, false)
+}
+
+func set(issue *dgrl.Branch, key, val string, exact, long, force bool) bool {
+	if issue == nil {
+		return false
+	}
+	idx, err := fieldIndex(issue, key, exact)
+	if err != nil {
+		return false
+	}
+	if idx >= 0 {
+		key = issue.Kids()[idx].(*dgrl.Leaf).Key()
+	}
+	if IsReserved(key) && !force {
+		return false
+	}
+	if idx < 0 {
+		last := 0
+		for i, k := range issue.Kids() {
+			if leaf, ok := k.(*dgrl.Leaf); ok && (leaf.Type() == dgrl.LeafType || long && leaf.Key() != "") {
+				last = i
 			}
 		}
+		if long {
+			return issue.Insert(dgrl.NewLongLeaf(key, val), last+1)
+		}
+		return issue.Insert(dgrl.NewLeaf(key, val), last+1)
+	}
+	leaf := issue.Kids()[idx].(*dgrl.Leaf)
+	if !long || leaf.Type() != dgrl.LeafType {
+		leaf.SetValue(val)
+		return true
+	}
+	// short leaves can't hold multiple lines, so replace it
+	newIssue := dgrl.NewBranch(issue.Key())
+	for i, k := range issue.Kids() {
+		if i == idx {
+			k = dgrl.NewLongLeaf(leaf.Key(), val)
+		}
+		newIssue.Append(k)
 	}
-	return "", false
+	*issue = *newIssue
+	return true
 }
 
-// Set sets the value for the given key, if found in the issue.
-// key may be a substring matching the beginning of the issue key.
-func Set(issue *dgrl.Branch, key, val string) bool {
+// Unset removes the given key and its value, if found in the issue.
+// Keys match as for Set, and reserved keys are not removed.
+func Unset(issue *dgrl.Branch, key string) bool {
 	if issue == nil {
 		return false
 	}
-	idx := 0
+	idx, err := fieldIndex(issue, key, false)
+	if err != nil || idx < 0 || IsReserved(issue.Kids()[idx].(*dgrl.Leaf).Key()) {
+		return false
+	}
+	newIssue := dgrl.NewBranch(issue.Key())
 	for i, k := range issue.Kids() {
-		if leaf, ok := k.(*dgrl.Leaf); ok {
-			if leaf.Type() == dgrl.LeafType {
-				idx = i
-			}
-			if strings.HasPrefix(leaf.Key(), key) {
-				leaf.SetValue(val)
-				return true
-			}
+		if i != idx {
+			newIssue.Append(k)
 		}
 	}
-	return issue.Insert(dgrl.NewLeaf(key, val), idx+1)
+	*issue = *newIssue
+	return true
 }
 
 // Lit stores and manipulates issues
 type Lit struct {
-	issues   *dgrl.Branch
-	issueIds []string
-	issueMap map[string]*dgrl.Branch
-	issueDir string
+	issues       *dgrl.Branch
+	issueIds     []string
+	issueMap     map[string]*dgrl.Branch
+	issueDir     string
+	aliases      map[string]string
+	aliasesDirty bool
+	config       *dgrl.Branch
+	configDirty  bool
+	noCache      bool
+	changed      map[*dgrl.Branch]bool
+	loaded       map[string]string // issue blocks read by Load, to find changes
+	fs           FS
+	root         string
+	clock        Clock
+	idGen        IDGenerator
+	shortLen     int
+	events       []*Event
 }
 
 // New constructs a new Lit.
 func New() *Lit {
-	return &Lit{issues: dgrl.NewRoot()}
+	return NewWithFS(OSFS{}, "")
+}
+
+// NewWithFS constructs a new Lit whose tracker is kept on fsys, found from the
+// root directory, or the working directory if root is empty.
+func NewWithFS(fsys FS, root string) *Lit {
+	return &Lit{issues: dgrl.NewRoot(), aliases: map[string]string{}, config: dgrl.NewRoot(),
+		fs: fsys, root: root}
 }
 
 // Init initializes the issue tracker.
 func (l *Lit) Init() error {
-	if err := os.Mkdir(issueBaseDir, 0777); err != nil && !os.IsExist(err) {
+	dir := filepath.Join(l.root, issueBaseDir)
+	if err := l.fs.MkdirAll(dir, 0777); err != nil {
 		return err
 	}
-
-	path := filepath.Join(issueBaseDir, issueFilename)
-	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0666)
-	if err != nil {
+	path := filepath.Join(dir, issueFilename)
+	if _, err := l.fs.ReadFile(path); os.IsNotExist(err) {
+		return l.fs.WriteFile(path, nil, 0666)
+	} else if err != nil {
 		return err
 	}
-	file.Close()
 	return nil
 }
 
@@ -105,6 +282,7 @@ func (l *Lit) IssueDir(issue *dgrl.Branch) string {
 
 func (l *Lit) indexIssues() {
 	l.issueIds = make([]string, l.issues.NumKids())
+	l.shortLen = 0
 	l.issueMap = make(map[string]*dgrl.Branch, l.issues.NumKids())
 	for i, k := range l.issues.Kids() {
 		if issue, ok := k.(*dgrl.Branch); ok {
@@ -116,57 +294,225 @@ func (l *Lit) indexIssues() {
 	sort.Strings(l.issueIds)
 }
 
-func issueDir() (string, error) {
-	path, err := os.Getwd()
+func (l *Lit) findIssueDir() (string, error) {
+	path := l.root
+	if path == "" {
+		path = "."
+	}
+	// walk up from an absolute path, so a relative root's ancestors are
+	// searched too, through the filesystem root
+	p, err := filepath.Abs(path)
 	if err != nil {
 		return "", err
 	}
-	for p := path; len(p) > 1; p = filepath.Dir(p) {
+	for {
 		dir := filepath.Join(p, issueBaseDir)
-		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		if l.fs.IsDir(dir) {
 			return dir, nil
 		}
+		parent := filepath.Dir(p)
+		if parent == p {
+			break
+		}
+		p = parent
 	}
 	return "", errors.New("issue directory not found")
 }
 
-// Load parses the issue file and populates the list of issues
+// TrackerDir returns the path of the issue tracker directory, found by
+// searching the current directory and its ancestors.
+func TrackerDir() (string, error) {
+	return New().findIssueDir()
+}
+
+// TrackerDir returns the path of the issue tracker directory, found by
+// searching the root directory and its ancestors.
+func (l *Lit) TrackerDir() (string, error) {
+	return l.findIssueDir()
+}
+
+// Load parses the issue file, in either of the IssueFormats, and populates the
+// list of issues
 func (l *Lit) Load() error {
-	dir, err := issueDir()
+	dir, err := l.findIssueDir()
 	if err != nil {
 		return err
 	}
-	path := filepath.Join(dir, issueFilename)
-	file, err := openFile(path, os.O_RDONLY, 0)
+	data, err := l.fs.ReadFile(filepath.Join(dir, issueFilename))
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-	issues := dgrl.NewParser().Parse(file)
-	if issues == nil {
-		return errors.New("error parsing issue file")
-	}
 	l.issueDir = dir
+	hash := issueHash(data)
+	issues, ok := l.readCache(hash)
+	if !ok {
+		if issues, err = DecodeIssues(data); err != nil {
+			return err
+		}
+		l.writeCache(hash, issues)
+	}
 	l.issues = issues
 	l.indexIssues()
+	if isYAML(data) {
+		// changes are found by comparing issues' text in dgrl
+		if data, err = l.writeIssues(); err != nil {
+			return err
+		}
+	}
+	l.loaded = issueBlocks(data)
+	l.changed = nil
+	l.events = nil
+	if err := l.loadConfig(); err != nil {
+		return err
+	}
+	return l.loadAliases()
+}
+
+func (l *Lit) loadAliases() error {
+	l.aliases = map[string]string{}
+	l.aliasesDirty = false
+	data, err := l.fs.ReadFile(filepath.Join(l.issueDir, aliasFilename))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	aliases := dgrl.NewParser().Parse(bytes.NewReader(data))
+	if aliases == nil {
+		return errors.New("error parsing alias file")
+	}
+	for _, k := range aliases.Kids() {
+		if leaf, ok := k.(*dgrl.Leaf); ok {
+			l.aliases[leaf.Key()] = leaf.Value()
+		}
+	}
 	return nil
 }
 
-// Store writes the issue list to the file
+// Store writes the issue list to the file, in the configured IssueFormat, with
+// issues ordered by id and their fields in a fixed order, so that diffs are
+// minimal.  Issues that
+// changed since Load, found by comparing their text with what Load read, or
+// noted with Changed, have empty summaries filled in by AutoSummary and their
+// references updated.  If issues were removed, all references are updated.
 func (l *Lit) Store() error {
-	path := filepath.Join(l.issueDir, issueFilename)
-	file, err := openFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+	l.sortIssues()
+	data, err := l.writeIssues()
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-	err = l.issues.Write(file)
+	blocks := issueBlocks(data)
+	refreshed := false
+	for _, k := range l.issues.Kids() {
+		issue, ok := k.(*dgrl.Branch)
+		if !ok {
+			continue
+		}
+		if old, ok := l.loaded[issue.Key()]; !ok || old != blocks[issue.Key()] || l.changed[issue] {
+			refreshed = l.refresh(issue) || refreshed
+		}
+	}
+	for id := range l.loaded {
+		if _, ok := blocks[id]; !ok {
+			for _, issue := range l.issueMap {
+				refreshed = l.refresh(issue) || refreshed
+			}
+			break
+		}
+	}
+	if refreshed {
+		l.sortIssues()
+		if data, err = l.writeIssues(); err != nil {
+			return err
+		}
+		blocks = issueBlocks(data)
+	}
+	path := filepath.Join(l.issueDir, issueFilename)
+	file := l.encodeIssues(data)
+	if err := l.fs.WriteFile(path, file, 0666); err != nil {
+		return err
+	}
+	l.writeCache(issueHash(file), l.issues)
+	l.loaded = blocks
+	l.changed = nil
+	if l.configDirty {
+		if err := l.storeConfig(); err != nil {
+			return err
+		}
+	}
+	if l.aliasesDirty {
+		return l.storeAliases()
+	}
+	return nil
+}
+
+// writeIssues returns the text of the issues in dgrl.
+func (l *Lit) writeIssues() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := l.issues.Write(buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (l *Lit) storeAliases() error {
+	root := dgrl.NewRoot()
+	for _, alias := range l.AliasNames() {
+		root.Append(dgrl.NewLeaf(alias, l.aliases[alias]))
+	}
+	buf := &bytes.Buffer{}
+	if err := root.Write(buf); err != nil {
+		return err
+	}
+	if err := l.fs.WriteFile(filepath.Join(l.issueDir, aliasFilename), buf.Bytes(), 0666); err != nil {
+		return err
+	}
+	l.aliasesDirty = false
+	return nil
+}
+
+// AddAlias associates a name with an issue, so the name may be used in place
+// of the issue id.
+func (l *Lit) AddAlias(alias, id string) error {
+	if alias == "" || strings.ContainsAny(alias, " \t\n:") {
+		return fmt.Errorf("invalid alias '%s'", alias)
+	}
+	issue, err := l.Lookup(id)
 	if err != nil {
 		return err
 	}
+	l.aliases[alias] = issue.Key()
+	l.aliasesDirty = true
 	return nil
 }
 
+// DelAlias removes an alias.
+func (l *Lit) DelAlias(alias string) error {
+	if _, ok := l.aliases[alias]; !ok {
+		return fmt.Errorf("alias '%s' not found", alias)
+	}
+	delete(l.aliases, alias)
+	l.aliasesDirty = true
+	return nil
+}
+
+// Alias returns the issue id for an alias.
+func (l *Lit) Alias(alias string) (string, bool) {
+	id, ok := l.aliases[alias]
+	return id, ok
+}
+
+// AliasNames returns the sorted list of aliases.
+func (l *Lit) AliasNames() []string {
+	names := make([]string, 0, len(l.aliases))
+	for alias := range l.aliases {
+		names = append(names, alias)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // IssueIds returns a slice of all issue ids
 func (l *Lit) IssueIds() []string {
 	issueIds := []string{}
@@ -178,61 +524,344 @@ func (l *Lit) IssueIds() []string {
 	return issueIds
 }
 
-// NewIssues adds and returns pointers to new issues
+const defaultNewFields = "summary tags priority assigned description"
+
+// newFields returns the fields given to new issues, after the reserved ones,
+// as leaves with their default values.  They are configured as new-fields, a
+// space separated list of keys, each optionally with "=<default>", e.g.
+// "summary tags priority=3 status=new assigned description".  The description
+// is a long field.
+func (l *Lit) newFields() []*dgrl.Leaf {
+	fields, ok := l.Config("new-fields")
+	if !ok || strings.TrimSpace(fields) == "" {
+		fields = defaultNewFields
+	}
+	leaves := []*dgrl.Leaf{}
+	for _, field := range strings.Fields(fields) {
+		kv := strings.SplitN(field, "=", 2)
+		key, val := kv[0], ""
+		if len(kv) > 1 {
+			val = kv[1]
+		}
+		if IsReserved(key) {
+			continue
+		}
+		if key == "description" {
+			leaves = append(leaves, dgrl.NewLongLeaf(key, val))
+		} else {
+			leaves = append(leaves, dgrl.NewLeaf(key, val))
+		}
+	}
+	return leaves
+}
+
+// NewIssues adds and returns pointers to new issues, with the configured
+// fields.
 func (l *Lit) NewIssues(username string, num int) []*dgrl.Branch {
 	issues := make([]*dgrl.Branch, num)
-	stamp := Stamp(username)
+	stamp := l.Stamp(username)
+	gen := l.idGenerator()
 	for i := range issues {
-		id := uuid.NewV4().String()
+		id := gen.NewID(l.Now(), l.issueIds)
 		issue := dgrl.NewBranch(id)
 		issue.Append(dgrl.NewLeaf("created", stamp))
 		issue.Append(dgrl.NewLeaf("updated", stamp))
 		issue.Append(dgrl.NewLeaf("closed", ""))
-		issue.Append(dgrl.NewLeaf("summary", ""))
-		issue.Append(dgrl.NewLeaf("tags", ""))
-		issue.Append(dgrl.NewLeaf("priority", ""))
-		issue.Append(dgrl.NewLeaf("assigned", ""))
-		issue.Append(dgrl.NewLongLeaf("description", ""))
+		for _, field := range l.newFields() {
+			issue.Append(field)
+		}
 		l.issues.Append(issue)
 		issues[i] = issue
+		l.addToIndex(issue)
+		l.Changed(issue)
 	}
-	l.indexIssues()
 	return issues
 }
 
-// Issue returns an issue for the given id
+// addToIndex adds a new issue to the index, without rebuilding it.
+func (l *Lit) addToIndex(issue *dgrl.Branch) {
+	id := issue.Key()
+	i := sort.SearchStrings(l.issueIds, id)
+	l.issueIds = append(l.issueIds, "")
+	l.shortLen = 0
+	copy(l.issueIds[i+1:], l.issueIds[i:])
+	l.issueIds[i] = id
+	l.issueMap[id] = issue
+}
+
+// Changed notes that the next Store should refresh an issue's summary and
+// references even if its text is unchanged, as Store does for changed
+// issues.
+func (l *Lit) Changed(issue *dgrl.Branch) {
+	if l.changed == nil {
+		l.changed = map[*dgrl.Branch]bool{}
+	}
+	l.changed[issue] = true
+}
+
+// AmbiguousError is returned when an id prefix matches more than one issue.
+type AmbiguousError struct {
+	ID         string
+	Candidates []string
+}
+
+func (e *AmbiguousError) Error() string {
+	return fmt.Sprintf("id %s is ambiguous, matching %s", e.ID, strings.Join(e.Candidates, ", "))
+}
+
+// NotFoundError is returned when there is no issue for an id, or the issue
+// has no field for Key, if set.
+type NotFoundError struct {
+	ID  string
+	Key string
+}
+
+func (e *NotFoundError) Error() string {
+	if e.Key != "" {
+		return fmt.Sprintf("issue %s has no field %s", e.ID, e.Key)
+	}
+	return fmt.Sprintf("error finding issue %s", e.ID)
+}
+
+// ReservedError is returned when a reserved field of an issue, or of a new
+// issue if ID is empty, is changed without forcing.
+type ReservedError struct {
+	ID  string
+	Key string
+}
+
+func (e *ReservedError) Error() string {
+	if e.ID == "" {
+		return fmt.Sprintf("%s is reserved", e.Key)
+	}
+	return fmt.Sprintf("issue %s: %s is reserved", e.ID, e.Key)
+}
+
+// Issue returns an issue for the given id or alias, or nil if it is not found
+// or ambiguous.
 func (l *Lit) Issue(id string) *dgrl.Branch {
+	issue, _ := l.Lookup(id)
+	return issue
+}
+
+// Lookup returns an issue for the given id or alias.  An id may be a prefix
+// of the full id, in which case an *AmbiguousError is returned if it matches
+// more than one issue, and may carry the project prefix of PrefixedID.
+func (l *Lit) Lookup(id string) (*dgrl.Branch, error) {
+	if aliasId, ok := l.aliases[id]; ok {
+		id = aliasId
+	}
+	id = l.unprefixID(id)
+	if issue, ok := l.issueMap[id]; ok {
+		return issue, nil
+	}
 	idx := sort.SearchStrings(l.issueIds, id)
-	if idx < len(l.issueIds) && strings.HasPrefix(l.issueIds[idx], id) {
-		return l.issueMap[l.issueIds[idx]]
+	matches := []string{}
+	for i := idx; i < len(l.issueIds) && strings.HasPrefix(l.issueIds[i], id); i++ {
+		matches = append(matches, l.issueIds[i])
 	}
-	return nil
+	switch len(matches) {
+	case 0:
+		return nil, &NotFoundError{ID: id}
+	case 1:
+		return l.issueMap[matches[0]], nil
+	}
+	return nil, &AmbiguousError{ID: id, Candidates: matches}
 }
 
-// Match returns a list of ids for all issues whose value for key contains val.
-func (l *Lit) Match(key, val string, doesMatch bool) []string {
-	matches := []string{}
+// MatchOptions control how MatchWith interprets the value to match.
+type MatchOptions struct {
+	Literal    bool // match val as plain text rather than a regular expression
+	IgnoreCase bool
+}
+
+// Match returns a list of ids for all issues whose value for key contains val,
+// a regular expression.  An error is returned if val is not a valid pattern.
+func (l *Lit) Match(key, val string, doesMatch bool) ([]string, error) {
+	return l.MatchWith(key, val, doesMatch, MatchOptions{})
+}
+
+// MatchWith returns a list of ids for all issues whose value for key contains
+// val, interpreted according to opts.
+func (l *Lit) MatchWith(key, val string, doesMatch bool, opts MatchOptions) ([]string, error) {
+	pattern := val
+	if date, ok := l.filterDate(key, val); ok {
+		val, pattern = date, regexp.QuoteMeta(date)
+	}
+	if opts.Literal {
+		pattern = regexp.QuoteMeta(val)
+	}
+	if opts.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %s", err)
+	}
+	whole := regexp.MustCompile("^(?:" + pattern + ")$")
+	return l.filter(func(issue *dgrl.Branch) bool {
+		return l.contains(issue, key, val, re, whole) == doesMatch
+	}), nil
+}
+
+// filter returns the ids of all issues for which keep returns true, in issue
+// order.  Issues are checked concurrently, so keep must be safe to call from
+// multiple goroutines.
+func (l *Lit) filter(keep func(issue *dgrl.Branch) bool) []string {
+	issues := []*dgrl.Branch{}
 	for _, k := range l.issues.Kids() {
 		if issue, ok := k.(*dgrl.Branch); ok {
-			if l.contains(issue, key, val) == doesMatch {
-				matches = append(matches, issue.Key())
+			issues = append(issues, issue)
+		}
+	}
+	kept := make([]bool, len(issues))
+	numWorkers := runtime.NumCPU()
+	chunk := (len(issues) + numWorkers - 1) / numWorkers
+	wg := sync.WaitGroup{}
+	for start := 0; start < len(issues); start += chunk {
+		end := start + chunk
+		if end > len(issues) {
+			end = len(issues)
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				kept[i] = keep(issues[i])
 			}
+		}(start, end)
+	}
+	wg.Wait()
+	matches := []string{}
+	for i, issue := range issues {
+		if kept[i] {
+			matches = append(matches, issue.Key())
 		}
 	}
 	return matches
 }
 
-type sorter struct{ ids, vals []string }
+// Count returns the number of the given issues having each value for key.
+// Values of multi-value fields are counted individually.
+func (l *Lit) Count(ids []string, key string) map[string]int {
+	counts := map[string]int{}
+	for _, id := range ids {
+		issue := l.Issue(id)
+		if issue == nil {
+			continue
+		}
+		val, _ := Get(issue, key)
+		if l.IsMultiValue(key) {
+			tags := strings.Fields(val)
+			if len(tags) == 0 {
+				counts[""]++
+			}
+			for _, tag := range tags {
+				counts[tag]++
+			}
+			continue
+		}
+		counts[val]++
+	}
+	return counts
+}
+
+// Group returns the given ids grouped by their issues' values for key.
+// Values of multi-value fields are grouped individually.  If no issue has a
+// status field, grouping by "status" groups by "open" and "closed".
+func (l *Lit) Group(ids []string, key string) map[string][]string {
+	groups := map[string][]string{}
+	for _, id := range ids {
+		issue := l.Issue(id)
+		if issue == nil {
+			continue
+		}
+		val, ok := Get(issue, key)
+		switch {
+		case key == "status" && !ok:
+			val = "open"
+			if !isOpen(issue) {
+				val = "closed"
+			}
+		case l.IsMultiValue(key):
+			tags := strings.Fields(val)
+			for _, tag := range tags {
+				groups[tag] = append(groups[tag], issue.Key())
+			}
+			if len(tags) > 0 {
+				continue
+			}
+		}
+		groups[val] = append(groups[val], issue.Key())
+	}
+	return groups
+}
+
+// Page returns the window of ids starting at offset and containing at most
+// limit ids.  A limit less than 1 means no limit.
+func Page(ids []string, offset, limit int) []string {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(ids) {
+		offset = len(ids)
+	}
+	ids = ids[offset:]
+	if limit > 0 && limit < len(ids) {
+		ids = ids[:limit]
+	}
+	return ids
+}
+
+type sorter struct {
+	key       string
+	ids, vals []string
+}
 
-func newSorter(ids []string) *sorter {
-	return &sorter{ids: ids, vals: make([]string, len(ids))}
+func newSorter(key string, ids []string) *sorter {
+	return &sorter{key: key, ids: ids, vals: make([]string, len(ids))}
 }
 
 // Len returns the number of elements to sort.
 func (s *sorter) Len() int { return len(s.ids) }
 
 // Less returns whether the first element is less than the second.
-func (s *sorter) Less(i, j int) bool { return s.vals[i] < s.vals[j] }
+func (s *sorter) Less(i, j int) bool { return compareKeyVals(s.key, s.vals[i], s.vals[j]) < 0 }
+
+// compareVals compares two values numerically if both are numbers, by time if
+// both are stamps, as versions if both are full (x.y.z) semantic versions, or
+// as strings otherwise.  It returns -1, 0, or 1 if a is less
+// than, equal to, or greater than b.
+func compareVals(a, b string) int {
+	if an, err := strconv.ParseFloat(a, 64); err == nil {
+		if bn, err := strconv.ParseFloat(b, 64); err == nil {
+			switch {
+			case an < bn:
+				return -1
+			case an > bn:
+				return 1
+			}
+			return 0
+		}
+	}
+	if strings.Count(a, ".") >= 2 && strings.Count(b, ".") >= 2 {
+		if cmp, ok := CompareVersions(a, b); ok {
+			return cmp
+		}
+	}
+	if at, ok := parseStampTime(a); ok {
+		if bt, ok := parseStampTime(b); ok {
+			switch {
+			case at.Before(bt):
+				return -1
+			case at.After(bt):
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(a, b)
+}
 
 // Swap swaps two elements
 func (s *sorter) Swap(i, j int) {
@@ -242,7 +871,7 @@ func (s *sorter) Swap(i, j int) {
 
 // Sort sorts the list of ids by the value for the given key.
 func (l *Lit) Sort(ids []string, key string, doAscend bool) {
-	srt := newSorter(ids)
+	srt := newSorter(key, ids)
 	for i := range ids {
 		if issue := l.Issue(ids[i]); issue != nil {
 			if val, ok := Get(issue, key); ok {
@@ -257,53 +886,132 @@ func (l *Lit) Sort(ids []string, key string, doAscend bool) {
 	}
 }
 
+// rank returns the issue's pin rank, or 0 if it is not pinned.
+func rank(issue *dgrl.Branch) int {
+	val, _ := GetExact(issue, "rank")
+	r, err := strconv.Atoi(val)
+	if err != nil || r < 1 {
+		return 0
+	}
+	return r
+}
+
+// Pinned reorders ids so that pinned issues come first, in rank order,
+// followed by the rest in their original order.
+func (l *Lit) Pinned(ids []string) {
+	ranks := make(map[string]int, len(ids))
+	for _, id := range ids {
+		ranks[id] = rank(l.Issue(id))
+	}
+	sort.SliceStable(ids, func(i, j int) bool {
+		ri, rj := ranks[ids[i]], ranks[ids[j]]
+		return ri > 0 && (rj == 0 || ri < rj)
+	})
+}
+
+// Pin gives an issue the rank r among pinned issues, moving those ranked at
+// or below it down one place.  If r is less than 1, or beyond the pinned
+// issues, it is ranked last.
+func (l *Lit) Pin(issue *dgrl.Branch, r int) bool {
+	l.Unpin(issue)
+	last := 0
+	for _, id := range l.issueIds {
+		if ir := rank(l.issueMap[id]); ir > last {
+			last = ir
+		}
+	}
+	if r < 1 || r > last {
+		r = last + 1
+	} else {
+		for _, id := range l.issueIds {
+			if other := l.issueMap[id]; rank(other) >= r {
+				Set(other, "rank", strconv.Itoa(rank(other)+1))
+			}
+		}
+	}
+	return SetExact(issue, "rank", strconv.Itoa(r))
+}
+
+// Unpin removes an issue's rank, moving issues ranked below it up one place.
+func (l *Lit) Unpin(issue *dgrl.Branch) bool {
+	r := rank(issue)
+	if r == 0 || !Unset(issue, "rank") {
+		return false
+	}
+	for _, id := range l.issueIds {
+		if other := l.issueMap[id]; rank(other) > r {
+			Set(other, "rank", strconv.Itoa(rank(other)-1))
+		}
+	}
+	return true
+}
+
 // Compare returns a list of ids for all issues whose value for key is less
 // or greater, determined by isLess, than val.
 func (l *Lit) Compare(key, val string, isLess bool) []string {
 	if val == "" {
 		return nil
 	}
-	matches := []string{}
-	for _, k := range l.issues.Kids() {
-		if issue, ok := k.(*dgrl.Branch); ok {
-			if l.compare(issue, key, val, isLess) == isLess {
-				matches = append(matches, issue.Key())
-			}
-		}
+	if date, ok := l.filterDate(key, val); ok {
+		val = date
 	}
-	return matches
+	return l.filter(func(issue *dgrl.Branch) bool {
+		return l.compare(issue, key, val, isLess) == isLess
+	})
 }
 
-func (l *Lit) contains(issue *dgrl.Branch, key, val string) bool {
+// contains reports whether the issue's value for key matches re, the compiled
+// form of val, or for multi-value fields, whether one of its values matches
+// whole, the same anchored at both ends.  A nil re never matches.
+func (l *Lit) contains(issue *dgrl.Branch, key, val string, re, whole *regexp.Regexp) bool {
 	switch key {
 	case "comment":
-		return commentContains(issue, val)
+		return commentContains(issue, re)
 	case "attach":
-		return l.attachContains(issue, val)
+		return l.attachContains(issue, val, re)
+	case "ack":
+		return ackContains(issue, val, whole)
 	}
 	if issueVal, ok := Get(issue, key); ok {
 		if val == "" && issueVal == "" {
 			return false
 		}
-		if ok, err := regexp.MatchString(val, issueVal); err == nil && ok {
+		if re != nil && l.IsMultiValue(key) {
+			if val == "" {
+				return true
+			}
+			// match whole values, so "ui" doesn't match "guide", and
+			// assignees by name alone, so "bob" matches "bob@host"
+			isUser := strings.HasPrefix("assigned", key)
+			for _, v := range strings.Fields(issueVal) {
+				if whole.MatchString(v) {
+					return true
+				}
+				if at := strings.Index(v, "@"); isUser && at > 0 && whole.MatchString(v[:at]) {
+					return true
+				}
+			}
+			return false
+		}
+		if re != nil && re.MatchString(issueVal) {
 			return true
 		}
 	}
 	return false
 }
 
-func commentContains(issue *dgrl.Branch, val string) bool {
-	if issue == nil {
+func commentContains(issue *dgrl.Branch, re *regexp.Regexp) bool {
+	if issue == nil || re == nil {
 		return false
 	}
 	for _, k := range issue.Kids() {
 		if comment, ok := k.(*dgrl.Branch); ok {
-			if ok, err := regexp.MatchString(val, comment.Key()); err == nil && ok {
+			if re.MatchString(comment.Key()) {
 				return true
 			}
 			for _, kk := range comment.Kids() {
 				if leaf, ok := kk.(*dgrl.Leaf); ok {
-					if ok, err := regexp.MatchString(val, leaf.Value()); err == nil && ok {
+					if re.MatchString(leaf.Value()) {
 						return true
 					}
 				}
@@ -313,13 +1021,16 @@ func commentContains(issue *dgrl.Branch, val string) bool {
 	return false
 }
 
-func (l *Lit) attachContains(issue *dgrl.Branch, val string) bool {
-	att := l.Attachments(issue)
+func (l *Lit) attachContains(issue *dgrl.Branch, val string, re *regexp.Regexp) bool {
+	att := l.AttachmentNames(issue)
 	if val == "" {
 		return len(att) > 0
 	}
+	if re == nil {
+		return false
+	}
 	for _, file := range att {
-		if ok, err := regexp.MatchString(val, file); err == nil && ok {
+		if re.MatchString(file) {
 			return true
 		}
 	}
@@ -338,9 +1049,9 @@ func (l *Lit) compare(issue *dgrl.Branch, key, val string, isLess bool) bool {
 		return !isLess
 	}
 	if isLess {
-		return issueVal < val
+		return compareKeyVals(key, issueVal, val) < 0
 	}
-	return issueVal <= val
+	return compareKeyVals(key, issueVal, val) <= 0
 }
 
 func commentCompare(issue *dgrl.Branch, time string, isLess bool) bool {
@@ -349,11 +1060,10 @@ func commentCompare(issue *dgrl.Branch, time string, isLess bool) bool {
 	}
 	for _, k := range issue.Kids() {
 		if comment, ok := k.(*dgrl.Branch); ok {
-			commentTime := comment.Key()
 			if isLess {
-				return commentTime < time
+				return compareVals(comment.Key(), time) < 0
 			}
-			return commentTime <= time
+			return compareVals(comment.Key(), time) <= 0
 		}
 	}
 	return !isLess
@@ -371,16 +1081,64 @@ func (l *Lit) attachCompare(issue *dgrl.Branch, val string, isLess bool) bool {
 	return numAtt <= num
 }
 
+// Vote adds or removes a user's vote for an issue.  Each user may vote once,
+// so it returns false if the vote is already present, or absent when removing.
+func Vote(issue *dgrl.Branch, username string, doVote bool) bool {
+	voterStr, _ := Get(issue, "voters")
+	voters := tagStrToSet(voterStr)
+	if _, didVote := voters[username]; didVote == doVote {
+		return false
+	}
+	if doVote {
+		voters[username] = struct{}{}
+	} else {
+		delete(voters, username)
+	}
+	return Set(issue, "voters", setToTagStr(voters)) &&
+		Set(issue, "votes", strconv.Itoa(len(voters)))
+}
+
+// IsMultiValue reports whether key names a field holding a space separated
+// set of values, which are the tags, assignees, and any fields listed in the
+// "multi-value" config.  key may abbreviate the field name.
+func (l *Lit) IsMultiValue(key string) bool {
+	if key == "" {
+		return false
+	}
+	keys := []string{"tags", "assigned"}
+	if multi, ok := l.Config("multi-value"); ok {
+		keys = append(keys, strings.Fields(multi)...)
+	}
+	for _, k := range keys {
+		if strings.HasPrefix(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
 // ModifyTag adds or removes a tag for a given issue
 func ModifyTag(issue *dgrl.Branch, tag string, doAdd bool) bool {
-	tags, _ := Get(issue, "tag")
-	tagSet := tagStrToSet(tags)
+	return ModifyValues(issue, "tags", tag, doAdd)
+}
+
+// ModifyValues adds or removes a value in the set of values held by a
+// multi-value field of a given issue.
+func ModifyValues(issue *dgrl.Branch, key, val string, doAdd bool) bool {
+	vals, _ := Get(issue, key)
+	return Set(issue, key, ModifyValueStr(vals, val, doAdd))
+}
+
+// ModifyValueStr adds or removes a value in a space separated set of values,
+// returning the sorted result.
+func ModifyValueStr(vals, val string, doAdd bool) string {
+	valSet := tagStrToSet(vals)
 	if doAdd {
-		tagSet[tag] = struct{}{}
+		valSet[val] = struct{}{}
 	} else {
-		delete(tagSet, tag)
+		delete(valSet, val)
 	}
-	return Set(issue, "tag", setToTagStr(tagSet))
+	return setToTagStr(valSet)
 }
 
 func tagStrToSet(tagStr string) map[string]struct{} {
@@ -392,7 +1150,7 @@ func tagStrToSet(tagStr string) map[string]struct{} {
 }
 
 func setToTagStr(set map[string]struct{}) string {
-	tags := make([]string, len(set))
+	tags := make([]string, 0, len(set))
 	for tag := range set {
 		tags = append(tags, tag)
 	}
@@ -402,81 +1160,267 @@ func setToTagStr(set map[string]struct{}) string {
 
 // Attach attaches a file to an issue
 func (l *Lit) Attach(issue *dgrl.Branch, src, username, comment string) (string, error) {
-	filename := path.Base(src)
-	attachComment := fmt.Sprintf("Attached %s", filename)
+	return l.AttachFiles(issue, []string{src}, username, comment)
+}
+
+// AttachFiles attaches several files to an issue, recorded by a single
+// comment and stamp.  The files must have distinct base names.
+func (l *Lit) AttachFiles(issue *dgrl.Branch, srcs []string, username, comment string) (string, error) {
+	if len(srcs) == 0 {
+		return "", errors.New("no files to attach")
+	}
+	filenames := make([]string, len(srcs))
+	seen := map[string]bool{}
+	for i, src := range srcs {
+		filenames[i] = filepath.Base(src)
+		if seen[filenames[i]] {
+			return "", fmt.Errorf("more than one file named %s", filenames[i])
+		}
+		seen[filenames[i]] = true
+	}
+	attachComment := fmt.Sprintf("Attached %s", strings.Join(filenames, ", "))
 	if comment != "" {
 		attachComment += fmt.Sprintf("\n\n%s", comment)
 	}
 	dir := l.IssueDir(issue)
-	if err := os.Mkdir(dir, 0777); err != nil && !os.IsExist(err) {
+	if err := l.fs.MkdirAll(dir, 0777); err != nil {
 		return "", err
 	}
-	dst := path.Join(dir, path.Base(filename))
-	if err := cp(src, dst); err != nil {
-		return "", err
+	stamp := l.Stamp(username)
+	for i, src := range srcs {
+		dst := path.Join(dir, filenames[i])
+		file, err := os.Open(src)
+		if err != nil {
+			return "", err
+		}
+		err = l.copyTo(dst, file)
+		file.Close()
+		if err != nil {
+			return "", err
+		}
+		att, err := l.newAttachment(dst, username, stamp)
+		if err != nil {
+			return "", err
+		}
+		if err := l.addToManifest(issue, att); err != nil {
+			return "", err
+		}
 	}
-	stamp := Stamp(username)
 	commentBranch := dgrl.NewBranch(stamp)
 	commentBranch.Append(dgrl.NewText(attachComment))
 	issue.Append(commentBranch)
 	return stamp, nil
 }
 
-// Attachments returns a list of an issue's attachments
-func (l *Lit) Attachments(issue *dgrl.Branch) []string {
+// Attachment describes a file attached to an issue.
+type Attachment struct {
+	Name     string
+	Size     int64
+	Type     string
+	Uploader string
+	Added    string
+}
+
+// newAttachment describes the attached file at filename, detecting its
+// content type.
+func (l *Lit) newAttachment(filename, username, stamp string) (*Attachment, error) {
+	size, err := l.fs.Size(filename)
+	if err != nil {
+		return nil, err
+	}
+	file, err := l.fs.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	name := filepath.Base(filename)
+	return &Attachment{
+		Name:     name,
+		Size:     size,
+		Type:     detectType(name, head[:n]),
+		Uploader: username,
+		Added:    stamp,
+	}, nil
+}
+
+// detectType sniffs the content type of data, preferring the type implied by
+// the file extension when sniffing only finds generic text or binary.
+func detectType(name string, data []byte) string {
+	typ := http.DetectContentType(data)
+	if strings.HasPrefix(typ, "text/plain") || typ == "application/octet-stream" {
+		if extType := mime.TypeByExtension(filepath.Ext(name)); extType != "" {
+			return extType
+		}
+	}
+	return typ
+}
+
+// Attachments returns descriptions of an issue's attachments.  Metadata is
+// read from the issue's attachment manifest.  Files attached before the
+// manifest existed have their type guessed from the extension and uploader
+// and added stamp taken from the comment that recorded them.
+func (l *Lit) Attachments(issue *dgrl.Branch) []Attachment {
 	if issue == nil {
 		return nil
 	}
 	issueDir := l.IssueDir(issue)
-	dir, err := ioutil.ReadDir(issueDir)
+	names, err := l.fs.ReadDirNames(issueDir)
 	if err != nil {
 		return nil
 	}
-	attachments := make([]string, len(dir))
-	for i := range dir {
-		attachments[i] = dir[i].Name()
+	manifest := l.readManifest(issueDir)
+	attachments := []Attachment{}
+	for _, name := range names {
+		if name == manifestFilename {
+			continue
+		}
+		size, err := l.fs.Size(filepath.Join(issueDir, name))
+		if err != nil {
+			continue
+		}
+		att := Attachment{Name: name, Size: size}
+		if entry, ok := manifest[name]; ok {
+			att.Type, att.Uploader, att.Added = entry.Type, entry.Uploader, entry.Added
+		} else {
+			att.Type = detectType(name, nil)
+			att.Added = attachedStamp(issue, name)
+			if fields := strings.Fields(att.Added); len(fields) > 1 {
+				att.Uploader = fields[1]
+			}
+		}
+		attachments = append(attachments, att)
 	}
 	return attachments
 }
 
-// GetAttachment returns a file attached to an issue
-func (l *Lit) GetAttachment(issue *dgrl.Branch, filename string) (*os.File, error) {
-	if issue == nil {
-		return nil, errors.New("nil issue")
+// AttachmentNames returns the names of an issue's attachments.
+func (l *Lit) AttachmentNames(issue *dgrl.Branch) []string {
+	names := []string{}
+	for _, att := range l.Attachments(issue) {
+		names = append(names, att.Name)
 	}
-	return os.Open(path.Join(l.IssueDir(issue), filename))
+	return names
 }
 
-func openFile(filename string, flag int, perm os.FileMode) (*os.File, error) {
-	if path.IsAbs(filename) {
-		return os.OpenFile(filename, flag, perm)
-	}
-	pwd, err := os.Getwd()
+// readManifest reads the attachment manifest in an issue directory.  The
+// manifest has a branch per file with leaves for each metadata field.
+func (l *Lit) readManifest(dir string) map[string]*Attachment {
+	manifest := map[string]*Attachment{}
+	data, err := l.fs.ReadFile(filepath.Join(dir, manifestFilename))
 	if err != nil {
-		return nil, err
+		return manifest
 	}
-	for p, pp := path.Join(pwd, filename), ""; p != pp; {
-		if stat, err := os.Stat(p); err == nil && stat.Mode().IsRegular() {
-			return os.OpenFile(p, flag, perm)
+	root := dgrl.NewParser().Parse(bytes.NewReader(data))
+	if root == nil {
+		return manifest
+	}
+	for _, k := range root.Kids() {
+		if entry, ok := k.(*dgrl.Branch); ok {
+			att := &Attachment{Name: entry.Key()}
+			att.Type, _ = Get(entry, "type")
+			att.Uploader, _ = Get(entry, "uploader")
+			att.Added, _ = Get(entry, "added")
+			if size, ok := Get(entry, "size"); ok {
+				att.Size, _ = strconv.ParseInt(size, 10, 64)
+			}
+			manifest[att.Name] = att
 		}
-		pp, p = p, path.Join(path.Dir(path.Dir(p)), path.Base(p))
 	}
-	return nil, fmt.Errorf("file '%s' not found", filename)
+	return manifest
 }
 
-func cp(src, dst string) error {
-	sf, err := os.Open(src)
-	if err != nil {
+func (l *Lit) addToManifest(issue *dgrl.Branch, att *Attachment) error {
+	dir := l.IssueDir(issue)
+	manifest := l.readManifest(dir)
+	manifest[att.Name] = att
+	names := make([]string, 0, len(manifest))
+	for name := range manifest {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	root := dgrl.NewRoot()
+	for _, name := range names {
+		att := manifest[name]
+		entry := dgrl.NewBranch(name)
+		entry.Append(dgrl.NewLeaf("type", att.Type))
+		entry.Append(dgrl.NewLeaf("size", strconv.FormatInt(att.Size, 10)))
+		entry.Append(dgrl.NewLeaf("uploader", att.Uploader))
+		entry.Append(dgrl.NewLeaf("added", att.Added))
+		root.Append(entry)
+	}
+	buf := &bytes.Buffer{}
+	if err := root.Write(buf); err != nil {
 		return err
 	}
-	defer sf.Close()
-	df, err := os.Create(dst)
-	if err != nil && !os.IsExist(err) {
+	return l.fs.WriteFile(filepath.Join(dir, manifestFilename), buf.Bytes(), 0666)
+}
+
+// attachedStamp returns the stamp of the comment Attach added for filename.
+func attachedStamp(issue *dgrl.Branch, filename string) string {
+	for _, k := range issue.Kids() {
+		if comment, ok := k.(*dgrl.Branch); ok {
+			for _, kk := range comment.Kids() {
+				leaf, ok := kk.(*dgrl.Leaf)
+				if !ok || !strings.HasPrefix(leaf.Value(), "Attached ") {
+					continue
+				}
+				firstLine := strings.SplitN(leaf.Value(), "\n", 2)[0]
+				for _, name := range strings.Split(firstLine[len("Attached "):], ", ") {
+					if name == filename {
+						return comment.Key()
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// GetAttachment returns a file attached to an issue
+func (l *Lit) GetAttachment(issue *dgrl.Branch, filename string) (File, error) {
+	if issue == nil {
+		return nil, errors.New("nil issue")
+	}
+	return l.fs.Open(path.Join(l.IssueDir(issue), filename))
+}
+
+// AttachmentReader gives random access to an attachment's contents, so large
+// attachments can be served in ranges rather than read whole.
+type AttachmentReader interface {
+	File
+}
+
+// OpenAttachment opens a file attached to an issue, returning a reader for
+// it and its description, including its size.  Unlike GetAttachment, the
+// file must be one of the issue's attachments.
+func (l *Lit) OpenAttachment(issue *dgrl.Branch, filename string) (AttachmentReader, *Attachment, error) {
+	for _, att := range l.Attachments(issue) {
+		if att.Name != filename {
+			continue
+		}
+		file, err := l.fs.Open(path.Join(l.IssueDir(issue), filename))
+		if err != nil {
+			return nil, nil, err
+		}
+		return file, &att, nil
+	}
+	return nil, nil, fmt.Errorf("no attachment %s in issue %s", filename, issue.Key())
+}
+
+// copyTo writes the contents of src to the file dst.
+func (l *Lit) copyTo(dst string, src io.Reader) error {
+	df, err := l.fs.Create(dst)
+	if err != nil {
 		return err
 	}
-	defer sf.Close()
-	if _, err := io.Copy(df, sf); err != nil {
+	if _, err := io.Copy(df, src); err != nil {
+		df.Close()
 		return err
 	}
-	return nil
+	return df.Close()
 }
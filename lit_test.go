@@ -0,0 +1,42 @@
+package lit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTrackerDir(t *testing.T) {
+	fsys := NewMemFS()
+	if err := NewWithFS(fsys, "/").Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.MkdirAll("/a/b", 0777); err != nil {
+		t.Fatal(err)
+	}
+	dir, err := NewWithFS(fsys, "/a/b").TrackerDir()
+	if want := filepath.Join("/", issueBaseDir); err != nil || dir != want {
+		t.Errorf("TrackerDir from /a/b = %q, %v, want %q", dir, err, want)
+	}
+
+	root := t.TempDir()
+	if err := NewWithFS(OSFS{}, root).Init(); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(root, "sub")
+	if err := os.Mkdir(sub, 0777); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(sub); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	dir, err = NewWithFS(OSFS{}, ".").TrackerDir()
+	if want := filepath.Join(root, issueBaseDir); err != nil || dir != want {
+		t.Errorf("TrackerDir from . = %q, %v, want %q", dir, err, want)
+	}
+}
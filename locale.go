@@ -0,0 +1,49 @@
+package lit
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ianremmler/dgrl"
+)
+
+const localeDirname = "locale"
+
+// Catalog returns the message catalog for a language, mapping field names
+// and other messages to their display text.  Catalogs are kept in the locale
+// directory, named by language, e.g. "de" or "pt_BR", holding a field for
+// each message.  If there is no catalog for a language with a region, the
+// catalog for the language alone is used.  Storage always uses the canonical
+// names.
+func (l *Lit) Catalog(lang string) (map[string]string, error) {
+	catalog := map[string]string{}
+	if i := strings.IndexAny(lang, ".@"); i >= 0 {
+		lang = lang[:i] // drop encoding and modifier, as in "de_DE.UTF-8"
+	}
+	if lang == "" || lang == "C" || lang == "POSIX" || strings.ContainsAny(lang, `/\`) {
+		return catalog, nil
+	}
+	data, err := l.fs.ReadFile(filepath.Join(l.issueDir, localeDirname, lang))
+	if os.IsNotExist(err) {
+		if i := strings.Index(lang, "_"); i > 0 {
+			return l.Catalog(lang[:i])
+		}
+		return catalog, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	root := dgrl.NewParser().Parse(bytes.NewReader(data))
+	if root == nil {
+		return nil, fmt.Errorf("error parsing %s catalog", lang)
+	}
+	for _, k := range root.Kids() {
+		if leaf, ok := k.(*dgrl.Leaf); ok && leaf.Key() != "" {
+			catalog[leaf.Key()] = strings.TrimSpace(leaf.Value())
+		}
+	}
+	return catalog, nil
+}
@@ -0,0 +1,69 @@
+package lit
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+// SendMail sends a message using the SMTP server configured as smtp-server
+// (host:port), with optional smtp-username and smtp-password.  The password
+// may instead be given by the LIT_SMTP_PASSWORD environment variable.
+// Messages are sent from smtp-from.
+func (l *Lit) SendMail(to []string, subject, body string, isHTML bool) error {
+	server, ok := l.Config("smtp-server")
+	if !ok || server == "" {
+		return errors.New("smtp-server is not configured")
+	}
+	from, ok := l.Config("smtp-from")
+	if !ok || from == "" {
+		return errors.New("smtp-from is not configured")
+	}
+	if len(to) == 0 {
+		return errors.New("no mail recipients")
+	}
+	for _, addr := range append([]string{from}, to...) {
+		if strings.ContainsAny(addr, "\r\n") {
+			return fmt.Errorf("invalid mail address %q", addr)
+		}
+	}
+	var auth smtp.Auth
+	if user, ok := l.Config("smtp-username"); ok && user != "" {
+		password := os.Getenv("LIT_SMTP_PASSWORD")
+		if password == "" {
+			password, _ = l.Config("smtp-password")
+		}
+		host, _, err := net.SplitHostPort(server)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", user, password, host)
+	}
+	contentType := "text/plain"
+	if isHTML {
+		contentType = "text/html"
+	}
+	msg := &strings.Builder{}
+	fmt.Fprintf(msg, "From: %s\r\n", from)
+	fmt.Fprintf(msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(msg, "Subject: %s\r\n", mailSubject(subject))
+	fmt.Fprintf(msg, "Date: %s\r\n", l.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(msg, "Content-Type: %s; charset=utf-8\r\n\r\n", contentType)
+	msg.WriteString(strings.Replace(body, "\n", "\r\n", -1))
+	return smtp.SendMail(server, auth, from, to, []byte(msg.String()))
+}
+
+// mailSubject encodes a subject for its header, with line breaks, which could
+// otherwise inject headers, as spaces.
+func mailSubject(subject string) string {
+	subject = strings.Join(strings.FieldsFunc(subject, func(r rune) bool {
+		return r == '\r' || r == '\n'
+	}), " ")
+	return mime.QEncoding.Encode("utf-8", subject)
+}
@@ -0,0 +1,26 @@
+package lit
+
+import (
+	"mime"
+	"strings"
+	"testing"
+)
+
+func TestMailSubject(t *testing.T) {
+	tests := map[string]string{
+		"plain":                      "plain",
+		"Größe":                      "Größe",
+		"x\r\nBcc: evil@example.com": "x Bcc: evil@example.com",
+		"two\nlines\n":               "two lines",
+	}
+	dec := &mime.WordDecoder{}
+	for subject, want := range tests {
+		enc := mailSubject(subject)
+		if strings.ContainsAny(enc, "\r\n") {
+			t.Errorf("mailSubject(%q) = %q, has a line break", subject, enc)
+		}
+		if got, err := dec.DecodeHeader(enc); err != nil || got != want {
+			t.Errorf("mailSubject(%q) decodes to %q, %v, want %q", subject, got, err, want)
+		}
+	}
+}
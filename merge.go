@@ -0,0 +1,72 @@
+package lit
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// Merge folds a duplicate issue into its canonical issue.  The duplicate's
+// comments and attachments are copied to the canonical issue, and the
+// duplicate is closed with a duplicate-of field pointing at the canonical
+// issue.  It returns the stamp used for the changes.
+func (l *Lit) Merge(dup, canonical *dgrl.Branch, username string) (string, error) {
+	if dup == nil || canonical == nil {
+		return "", fmt.Errorf("nil issue")
+	}
+	if dup == canonical {
+		return "", fmt.Errorf("cannot merge issue %s into itself", dup.Key())
+	}
+	for _, k := range dup.Kids() {
+		if comment, ok := k.(*dgrl.Branch); ok {
+			canonical.Append(copyComment(comment))
+		}
+	}
+	if att := l.Attachments(dup); len(att) > 0 {
+		dir := l.IssueDir(canonical)
+		if err := l.fs.MkdirAll(dir, 0777); err != nil {
+			return "", err
+		}
+		for i := range att {
+			src, err := l.fs.Open(path.Join(l.IssueDir(dup), att[i].Name))
+			if err != nil {
+				return "", err
+			}
+			err = l.copyTo(path.Join(dir, att[i].Name), src)
+			src.Close()
+			if err != nil {
+				return "", err
+			}
+			if err := l.addToManifest(canonical, &att[i]); err != nil {
+				return "", err
+			}
+		}
+	}
+	stamp := l.Stamp(username)
+	commentBranch := dgrl.NewBranch(stamp)
+	commentBranch.Append(dgrl.NewText(fmt.Sprintf("Merged duplicate %s", dup.Key())))
+	canonical.Append(commentBranch)
+	ok := Touch(canonical, stamp)
+	ok = ok && Set(dup, "duplicate-of", canonical.Key())
+	ok = ok && SetForce(dup, "closed", stamp)
+	ok = ok && Touch(dup, stamp)
+	if !ok {
+		return "", fmt.Errorf("error updating fields merging %s into %s", dup.Key(), canonical.Key())
+	}
+	return stamp, nil
+}
+
+func copyComment(comment *dgrl.Branch) *dgrl.Branch {
+	dup := dgrl.NewBranch(comment.Key())
+	for _, k := range comment.Kids() {
+		if leaf, ok := k.(*dgrl.Leaf); ok {
+			if leaf.Type() == dgrl.LeafType {
+				dup.Append(dgrl.NewLeaf(leaf.Key(), leaf.Value()))
+			} else {
+				dup.Append(dgrl.NewText(leaf.Value()))
+			}
+		}
+	}
+	return dup
+}
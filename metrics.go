@@ -0,0 +1,112 @@
+package lit
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// LeadTimes returns the time from creation to closing of each closed issue
+// among ids.
+func (l *Lit) LeadTimes(ids []string) []time.Duration {
+	times := []time.Duration{}
+	for _, id := range ids {
+		issue := l.Issue(id)
+		created, ok := stampTime(issue, "created")
+		if !ok {
+			continue
+		}
+		if closed, ok := stampTime(issue, "closed"); ok {
+			times = append(times, closed.Sub(created))
+		}
+	}
+	return times
+}
+
+// CycleTimes returns the time from the start of work to closing of each closed
+// issue among ids.  Work starts at the issue's "started" stamp, if set, or
+// else its first comment.  Issues with neither are left out.
+func (l *Lit) CycleTimes(ids []string) []time.Duration {
+	times := []time.Duration{}
+	for _, id := range ids {
+		issue := l.Issue(id)
+		closed, ok := stampTime(issue, "closed")
+		if !ok {
+			continue
+		}
+		if started, ok := startTime(issue); ok {
+			times = append(times, closed.Sub(started))
+		}
+	}
+	return times
+}
+
+func startTime(issue *dgrl.Branch) (time.Time, bool) {
+	if started, ok := stampTime(issue, "started"); ok {
+		return started, true
+	}
+	for _, k := range issue.Kids() {
+		if comment, ok := k.(*dgrl.Branch); ok {
+			return parseStampTime(comment.Key())
+		}
+	}
+	return time.Time{}, false
+}
+
+// Percentile returns the duration below which the fraction p of durations
+// fall, using the nearest rank.  durations is sorted in place.
+func Percentile(durations []time.Duration, p float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	rank := int(p*float64(len(durations))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(durations) {
+		rank = len(durations) - 1
+	}
+	return durations[rank]
+}
+
+// AgeBucket counts the open issues whose age is less than Max.
+type AgeBucket struct {
+	Label string
+	Max   time.Duration
+	Count int
+}
+
+const maxAge = time.Duration(1<<63 - 1)
+
+// AgeBuckets counts the open issues among ids by age as of now.
+func (l *Lit) AgeBuckets(ids []string, now time.Time) []AgeBucket {
+	day := 24 * time.Hour
+	buckets := []AgeBucket{
+		{Label: "<1d", Max: day},
+		{Label: "1d-1w", Max: 7 * day},
+		{Label: "1w-1m", Max: 30 * day},
+		{Label: "1m-3m", Max: 91 * day},
+		{Label: "3m-1y", Max: 365 * day},
+		{Label: ">1y", Max: maxAge},
+	}
+	for _, id := range ids {
+		issue := l.Issue(id)
+		if issue == nil || !isOpen(issue) {
+			continue
+		}
+		created, ok := stampTime(issue, "created")
+		if !ok {
+			continue
+		}
+		age := now.Sub(created)
+		for i := range buckets {
+			if age < buckets[i].Max {
+				buckets[i].Count++
+				break
+			}
+		}
+	}
+	return buckets
+}
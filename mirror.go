@@ -0,0 +1,41 @@
+package lit
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// MirrorOf returns the URL of the tracker served by "lit serve" of which this
+// tracker is a read-only copy, given by the mirror-of config.
+func (l *Lit) MirrorOf() (string, bool) {
+	url, _ := l.Config("mirror-of")
+	url = strings.TrimSpace(url)
+	return url, url != ""
+}
+
+// ReplaceIssues replaces all of the tracker's issues, as when refreshing a
+// mirror.  The next Store writes them all.
+func (l *Lit) ReplaceIssues(issues []*dgrl.Branch) {
+	l.issues = dgrl.NewRoot()
+	for _, issue := range issues {
+		l.issues.Append(issue)
+	}
+	l.changed = nil
+	l.indexIssues()
+}
+
+// Version returns the hash of the issue file's contents, which changes
+// whenever the issues do, as when a mirrored tracker is changed.
+func (l *Lit) Version() (string, error) {
+	dir, err := l.findIssueDir()
+	if err != nil {
+		return "", err
+	}
+	data, err := l.fs.ReadFile(filepath.Join(dir, issueFilename))
+	if err != nil {
+		return "", err
+	}
+	return issueHash(data), nil
+}
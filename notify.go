@@ -0,0 +1,249 @@
+package lit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ianremmler/dgrl"
+)
+
+const defaultSubject = "[lit {{.Short}}] {{.Summary}}"
+
+// Event describes a change made to an issue.
+type Event struct {
+	Issue  *dgrl.Branch
+	Action string
+	User   string
+	Detail string
+}
+
+// Short returns the short form of the event's issue id.
+func (e *Event) Short() string {
+	return ShortID(e.Issue.Key())
+}
+
+// Summary returns the summary of the event's issue.
+func (e *Event) Summary() string {
+	summary, _ := Get(e.Issue, "summary")
+	return summary
+}
+
+// Notifier delivers issue change events.
+type Notifier interface {
+	Notify(ev *Event) error
+}
+
+// Notifiers returns the notifiers enabled by the space separated list of
+// backends configured as "notify".
+func (l *Lit) Notifiers() ([]Notifier, error) {
+	backends, _ := l.Config("notify")
+	notifiers := []Notifier{}
+	for _, backend := range strings.Fields(backends) {
+		switch backend {
+		case "smtp":
+			notifiers = append(notifiers, &SMTPNotifier{l: l})
+		case "slack":
+			notifiers = append(notifiers, &SlackNotifier{l: l})
+		case "matrix":
+			notifiers = append(notifiers, &MatrixNotifier{l: l})
+		case "es":
+			notifiers = append(notifiers, &ESNotifier{l: l})
+		default:
+			return nil, fmt.Errorf("unknown notify backend '%s'", backend)
+		}
+	}
+	return notifiers, nil
+}
+
+// Record notes an event, to be sent by SendEvents once the change is stored,
+// and notes its issue as Changed.
+func (l *Lit) Record(ev *Event) {
+	l.events = append(l.events, ev)
+	l.Changed(ev.Issue)
+}
+
+// Recorded returns the events recorded and not yet sent.  Loading the tracker
+// discards them along with the changes.
+func (l *Lit) Recorded() []*Event {
+	return l.events
+}
+
+// SendEvents sends the recorded events to the notifiers, then forgets them.
+// It returns the errors of the events that could not be sent.
+func (l *Lit) SendEvents() []error {
+	errs := []error{}
+	for _, ev := range l.events {
+		if err := l.Notify(ev); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	l.events = nil
+	return errs
+}
+
+// Notify sends an event to all enabled notifiers, returning the first error
+// encountered.  Events for confidential issues are not sent.
+func (l *Lit) Notify(ev *Event) error {
+	if IsConfidential(ev.Issue) {
+		return nil
+	}
+	notifiers, err := l.Notifiers()
+	if err != nil {
+		return err
+	}
+	for _, n := range notifiers {
+		if nerr := n.Notify(ev); nerr != nil && err == nil {
+			err = nerr
+		}
+	}
+	return err
+}
+
+// SMTPNotifier mails events to an issue's assignee and watchers.  Users are
+// mapped to addresses by "email-<user>" config entries, and users without an
+// address are skipped.  The subject is a text/template configured as
+// "notify-subject", executed with the Event.
+type SMTPNotifier struct {
+	l *Lit
+}
+
+// Notify mails the event.
+func (n *SMTPNotifier) Notify(ev *Event) error {
+	users, _ := Get(ev.Issue, "assigned")
+	if watchers, ok := Get(ev.Issue, "watchers"); ok {
+		users += " " + watchers
+	}
+	to := []string{}
+	seen := map[string]bool{}
+	for _, user := range strings.Fields(users) {
+		if user == ev.User || seen[user] {
+			continue
+		}
+		seen[user] = true
+		if addr, ok := n.l.Config("email-" + user); ok && addr != "" {
+			to = append(to, addr)
+		}
+	}
+	if len(to) == 0 {
+		return nil
+	}
+	subjectTmpl, ok := n.l.Config("notify-subject")
+	if !ok {
+		subjectTmpl = defaultSubject
+	}
+	tmpl, err := template.New("subject").Parse(subjectTmpl)
+	if err != nil {
+		return err
+	}
+	subject := &bytes.Buffer{}
+	if err := tmpl.Execute(subject, ev); err != nil {
+		return err
+	}
+	body := fmt.Sprintf("%s %s issue %s\n\n%s\n", ev.User, ev.Action, ev.Issue.Key(), ev.Summary())
+	if ev.Detail != "" {
+		body += "\n" + ev.Detail + "\n"
+	}
+	return n.l.SendMail(to, subject.String(), body, false)
+}
+
+// Text returns a one line description of the event.
+func (e *Event) Text() string {
+	return fmt.Sprintf("%s %s issue %s: %s", e.User, e.Action, e.Short(), e.Summary())
+}
+
+// route returns the configured targets for an event.  If the issue has tags
+// with targets configured as "<key>-<tag>", those are used, otherwise the
+// target configured as key is used.
+func (l *Lit) route(ev *Event, key string) []string {
+	targets := []string{}
+	tags, _ := Get(ev.Issue, "tags")
+	for _, tag := range strings.Fields(tags) {
+		if target, ok := l.Config(key + "-" + tag); ok && target != "" {
+			targets = append(targets, target)
+		}
+	}
+	if len(targets) == 0 {
+		if target, ok := l.Config(key); ok && target != "" {
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// SlackNotifier posts events to Slack incoming webhooks configured as
+// "slack-webhook", or "slack-webhook-<tag>" for issues with a given tag.
+type SlackNotifier struct {
+	l *Lit
+}
+
+// Notify posts the event.
+func (n *SlackNotifier) Notify(ev *Event) error {
+	msg, err := json.Marshal(map[string]string{"text": ev.Text()})
+	if err != nil {
+		return err
+	}
+	for _, hook := range n.l.route(ev, "slack-webhook") {
+		resp, err := http.Post(hook, "application/json", bytes.NewReader(msg))
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			return fmt.Errorf("slack: %s", resp.Status)
+		}
+	}
+	return nil
+}
+
+// MatrixNotifier sends events to Matrix rooms configured as "matrix-room", or
+// "matrix-room-<tag>" for issues with a given tag, on the homeserver
+// configured as "matrix-server".  The access token is configured as
+// "matrix-token" or given by the LIT_MATRIX_TOKEN environment variable.
+type MatrixNotifier struct {
+	l *Lit
+}
+
+// Notify sends the event.
+func (n *MatrixNotifier) Notify(ev *Event) error {
+	server, ok := n.l.Config("matrix-server")
+	if !ok || server == "" {
+		return errors.New("matrix-server is not configured")
+	}
+	token := os.Getenv("LIT_MATRIX_TOKEN")
+	if token == "" {
+		token, _ = n.l.Config("matrix-token")
+	}
+	msg, err := json.Marshal(map[string]string{"msgtype": "m.text", "body": ev.Text()})
+	if err != nil {
+		return err
+	}
+	for i, room := range n.l.route(ev, "matrix-room") {
+		txn := strconv.FormatInt(time.Now().UnixNano(), 10) + "-" + strconv.Itoa(i)
+		endpoint := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+			strings.TrimRight(server, "/"), url.PathEscape(room), txn)
+		req, err := http.NewRequest(http.MethodPut, endpoint, bytes.NewReader(msg))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			return fmt.Errorf("matrix: %s", resp.Status)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package lit
+
+import (
+	"sort"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// sortIssues orders issues by id, and their fields as in sortFields, so the
+// issue file changes as little as possible between stores.
+func (l *Lit) sortIssues() {
+	order := map[string]int{"created": 0, "updated": 1, "closed": 2}
+	for _, field := range l.newFields() {
+		if _, ok := order[field.Key()]; !ok {
+			order[field.Key()] = len(order)
+		}
+	}
+	kids := append([]dgrl.Node{}, l.issues.Kids()...)
+	sort.SliceStable(kids, func(i, j int) bool { return nodeKey(kids[i]) < nodeKey(kids[j]) })
+	root := dgrl.NewRoot()
+	for _, k := range kids {
+		if issue, ok := k.(*dgrl.Branch); ok {
+			sortFields(issue, order)
+		}
+		root.Append(k)
+	}
+	l.issues = root
+}
+
+// sortFields orders an issue's short fields, then its long fields, each with
+// the fields in order first, as ranked, and the rest by key, followed by its
+// comments in their existing order.
+func sortFields(issue *dgrl.Branch, order map[string]int) {
+	kids := append([]dgrl.Node{}, issue.Kids()...)
+	rank := func(n dgrl.Node) int {
+		switch node := n.(type) {
+		case *dgrl.Leaf:
+			if node.Type() == dgrl.LeafType {
+				return 0
+			}
+			return 1
+		}
+		return 2
+	}
+	sort.SliceStable(kids, func(i, j int) bool {
+		ri, rj := rank(kids[i]), rank(kids[j])
+		if ri != rj {
+			return ri < rj
+		}
+		if ri == 2 {
+			return false
+		}
+		ki, kj := nodeKey(kids[i]), nodeKey(kids[j])
+		oi, okI := order[ki]
+		oj, okJ := order[kj]
+		switch {
+		case okI && okJ:
+			return oi < oj
+		case okI != okJ:
+			return okI
+		}
+		return ki < kj
+	})
+	sorted := dgrl.NewBranch(issue.Key())
+	for _, k := range kids {
+		sorted.Append(k)
+	}
+	*issue = *sorted
+}
+
+func nodeKey(n dgrl.Node) string {
+	switch node := n.(type) {
+	case *dgrl.Branch:
+		return node.Key()
+	case *dgrl.Leaf:
+		return node.Key()
+	}
+	return ""
+}
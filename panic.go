@@ -0,0 +1,84 @@
+package lit
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ianremmler/dgrl"
+)
+
+var frameRe = regexp.MustCompile(`^(\S+)\(.*\)$`)
+
+// ParsePanic extracts a summary, from the panic message and the top frame
+// outside the runtime, and a signature, from the function names of the
+// panicking goroutine's stack, from a Go panic trace.
+func ParsePanic(trace string) (summary, signature string, err error) {
+	msg, funcs := "", []string{}
+	inStack := false
+	for _, line := range strings.Split(trace, "\n") {
+		line = strings.TrimRight(line, "\r")
+		switch {
+		case msg == "" && strings.HasPrefix(line, "panic: "):
+			msg = strings.TrimPrefix(line, "panic: ")
+		case strings.HasPrefix(line, "goroutine ") && len(funcs) == 0:
+			inStack = true
+		case line == "":
+			inStack = inStack && len(funcs) == 0
+		case inStack && !strings.HasPrefix(line, "\t"):
+			if m := frameRe.FindStringSubmatch(line); m != nil {
+				funcs = append(funcs, m[1])
+			}
+		}
+	}
+	if msg == "" || len(funcs) == 0 {
+		return "", "", errors.New("no panic found")
+	}
+	top := funcs[0]
+	for _, fn := range funcs {
+		if !strings.HasPrefix(fn, "runtime.") && fn != "panic" {
+			top = fn
+			break
+		}
+	}
+	sum := sha1.Sum([]byte(strings.Join(funcs, "\n")))
+	return fmt.Sprintf("panic: %s in %s", msg, top), hex.EncodeToString(sum[:8]), nil
+}
+
+// NewFromPanic adds an issue for a Go panic trace, with the trace as its
+// description and its signature as panic-signature.  If an issue with the
+// same signature exists, the trace is added to it as a comment instead.  It
+// returns the issue and whether it is new.
+func (l *Lit) NewFromPanic(username, trace string) (*dgrl.Branch, bool, error) {
+	trace = strings.TrimSpace(trace)
+	summary, sig, err := ParsePanic(trace)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, id := range l.issueIds {
+		issue := l.issueMap[id]
+		if other, _ := GetExact(issue, "panic-signature"); other == sig {
+			stamp := l.Stamp(username)
+			comment := dgrl.NewBranch(stamp)
+			comment.Append(dgrl.NewText("Occurred again:\n" + trace))
+			issue.Append(comment)
+			l.Changed(issue)
+			if !Touch(issue, stamp) {
+				return nil, false, fmt.Errorf("error setting update time for issue %s", id)
+			}
+			return issue, false, nil
+		}
+	}
+	issue := l.NewIssues(username, 1)[0]
+	ok := SetExact(issue, "summary", summary) &&
+		set(issue, "description", trace, true, true, false) &&
+		SetExact(issue, "panic-signature", sig)
+	ModifyTag(issue, "panic", true)
+	if !ok {
+		return nil, false, fmt.Errorf("error setting fields in issue %s", issue.Key())
+	}
+	return issue, true, nil
+}
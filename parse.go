@@ -0,0 +1,121 @@
+package lit
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// Rejected text of edit sessions is kept under .lit/recovery/<user>/.
+const recoveryDirname = "recovery"
+
+// BlockError is an issue block of an issues file that could not be parsed.
+type BlockError struct {
+	First, Last int // line numbers, counting from 1
+	Text        string
+}
+
+func (e *BlockError) Error() string {
+	return fmt.Sprintf("lines %d-%d: error parsing issue block", e.First, e.Last)
+}
+
+// ParseIssues parses an issues file one issue block at a time, a block
+// beginning with an issue's "== <id>" line, so that a malformed issue doesn't
+// spoil the rest.  It returns the issues that parsed and the blocks that
+// didn't.  Text before the first block is rejected unless it is blank.
+func ParseIssues(r io.Reader) ([]*dgrl.Branch, []*BlockError, error) {
+	issues := []*dgrl.Branch{}
+	bad := []*BlockError{}
+	lines := []string{}
+	first := 1
+	flush := func() {
+		text := strings.Join(lines, "")
+		if strings.TrimSpace(text) != "" {
+			if issue := parseIssueBlock(text); issue != nil {
+				issues = append(issues, issue)
+			} else {
+				bad = append(bad, &BlockError{First: first, Last: first + len(lines) - 1, Text: text})
+			}
+		}
+		first += len(lines)
+		lines = lines[:0]
+	}
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if line != "" {
+			if strings.HasPrefix(line, "== ") {
+				flush()
+			}
+			lines = append(lines, line)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	flush()
+	return issues, bad, nil
+}
+
+// issueBlocks returns the text of each issue in an issue file, by id.
+func issueBlocks(data []byte) map[string]string {
+	blocks := map[string]string{}
+	text := string(data)
+	id, start := "", 0
+	for pos := 0; pos < len(text); {
+		end := strings.IndexByte(text[pos:], '\n') + 1
+		if end == 0 {
+			end = len(text) - pos
+		}
+		if strings.HasPrefix(text[pos:], "== ") {
+			if id != "" {
+				blocks[id] = text[start:pos]
+			}
+			id, start = strings.TrimSpace(text[pos+3:pos+end]), pos
+		}
+		pos += end
+	}
+	if id != "" {
+		blocks[id] = text[start:]
+	}
+	return blocks
+}
+
+// parseIssueBlock returns the single issue of a block, or nil.
+func parseIssueBlock(text string) *dgrl.Branch {
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	root := dgrl.NewParser().Parse(strings.NewReader(text))
+	if root == nil || root.NumKids() != 1 {
+		return nil
+	}
+	issue, _ := root.Kids()[0].(*dgrl.Branch)
+	return issue
+}
+
+// SaveRecovery writes text rejected from a user's edit session to a new file
+// under .lit/recovery/<user>/, returning its path.
+func (l *Lit) SaveRecovery(username, text string) (string, error) {
+	user := strings.Replace(username, string(filepath.Separator), "_", -1)
+	dir := filepath.Join(l.issueDir, recoveryDirname, user)
+	if err := l.fs.MkdirAll(dir, 0777); err != nil {
+		return "", err
+	}
+	name := "edit-" + l.Now().UTC().Format("20060102T150405")
+	path := filepath.Join(dir, name)
+	for n := 2; ; n++ {
+		if _, err := l.fs.ReadFile(path); err != nil {
+			break
+		}
+		path = filepath.Join(dir, fmt.Sprintf("%s-%d", name, n))
+	}
+	return path, l.fs.WriteFile(path, []byte(text), 0666)
+}
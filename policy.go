@@ -0,0 +1,151 @@
+package lit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// noAutoCloseTag exempts an issue from policies.
+const noAutoCloseTag = "no-autoclose"
+
+// Policy warns about, then closes, open issues that have gone without an
+// update.  Policies are configured as "policy-<name>: [tag=<tag>] [warn=<age>]
+// [close=<age>]", e.g. "policy-needs-info: tag=needs-info warn=23d close=30d".
+type Policy struct {
+	Name  string
+	Tag   string        // if set, only issues with the tag are affected
+	Warn  time.Duration // if set, comment on issues not updated in this long
+	Close time.Duration // if set, close issues not updated in this long
+}
+
+// PolicyAction is a warning or closing done by a policy.
+type PolicyAction struct {
+	ID     string
+	Policy string
+	Action string // "warned" or "closed"
+}
+
+// Policies returns the configured policies, ordered by name.
+func (l *Lit) Policies() ([]Policy, error) {
+	policies := []Policy{}
+	for _, k := range l.config.Kids() {
+		leaf, ok := k.(*dgrl.Leaf)
+		if !ok || !strings.HasPrefix(leaf.Key(), "policy-") {
+			continue
+		}
+		p := Policy{Name: strings.TrimPrefix(leaf.Key(), "policy-")}
+		for _, setting := range strings.Fields(leaf.Value()) {
+			kv := strings.SplitN(setting, "=", 2)
+			if len(kv) < 2 {
+				return nil, fmt.Errorf("%s: invalid setting '%s'", leaf.Key(), setting)
+			}
+			var err error
+			switch kv[0] {
+			case "tag":
+				p.Tag = kv[1]
+			case "warn":
+				p.Warn, err = ParseAge(kv[1])
+			case "close":
+				p.Close, err = ParseAge(kv[1])
+			default:
+				err = fmt.Errorf("unknown setting '%s'", kv[0])
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", leaf.Key(), err)
+			}
+		}
+		policies = append(policies, p)
+	}
+	sort.Slice(policies, func(i, j int) bool { return policies[i].Name < policies[j].Name })
+	return policies, nil
+}
+
+// RunPolicies applies the configured policies as of now.  Warnings are added
+// as comments, which don't count as updates, and each is given once per
+// period without an update.  Issues tagged no-autoclose are exempt.  If
+// dryRun is set, the actions are returned without being done.
+func (l *Lit) RunPolicies(now time.Time, username string, dryRun bool) ([]PolicyAction, error) {
+	policies, err := l.Policies()
+	if err != nil {
+		return nil, err
+	}
+	actions := []PolicyAction{}
+	for _, p := range policies {
+		for _, id := range l.issueIds {
+			issue := l.issueMap[id]
+			tags, _ := GetExact(issue, "tags")
+			tagSet := tagStrToSet(tags)
+			if !isOpen(issue) {
+				continue
+			}
+			if _, ok := tagSet[noAutoCloseTag]; ok {
+				continue
+			}
+			if _, ok := tagSet[p.Tag]; p.Tag != "" && !ok {
+				continue
+			}
+			updated, ok := stampTime(issue, "updated")
+			if !ok {
+				continue
+			}
+			age := now.Sub(updated)
+			prefix := "Policy " + p.Name + ": "
+			switch {
+			case p.Close > 0 && age >= p.Close:
+				actions = append(actions, PolicyAction{ID: id, Policy: p.Name, Action: "closed"})
+				if !dryRun {
+					stamp := l.Stamp(username)
+					l.addComment(issue, stamp, fmt.Sprintf("%sclosed after no update in %s.", prefix, fmtDays(p.Close)))
+					SetForce(issue, "closed", stamp)
+					Touch(issue, stamp)
+				}
+			case p.Warn > 0 && age >= p.Warn && !hasCommentSince(issue, prefix, updated):
+				actions = append(actions, PolicyAction{ID: id, Policy: p.Name, Action: "warned"})
+				if !dryRun {
+					msg := "no update in " + fmtDays(p.Warn) + "."
+					if p.Close > 0 {
+						closeAt := updated.Add(p.Close).UTC().Format("2006-01-02")
+						msg = "will be closed if not updated by " + closeAt + "."
+					}
+					l.addComment(issue, l.Stamp(username), prefix+msg)
+				}
+			}
+		}
+	}
+	return actions, nil
+}
+
+func (l *Lit) addComment(issue *dgrl.Branch, stamp, text string) {
+	comment := dgrl.NewBranch(stamp)
+	comment.Append(dgrl.NewText(text))
+	issue.Append(comment)
+	l.Changed(issue)
+}
+
+// hasCommentSince reports whether an issue has a comment starting with prefix
+// made after the given time.
+func hasCommentSince(issue *dgrl.Branch, prefix string, since time.Time) bool {
+	for _, k := range issue.Kids() {
+		comment, ok := k.(*dgrl.Branch)
+		if !ok {
+			continue
+		}
+		if t, ok := parseStampTime(comment.Key()); !ok || t.Before(since) {
+			continue
+		}
+		for _, kk := range comment.Kids() {
+			if text, ok := kk.(*dgrl.Leaf); ok && strings.HasPrefix(text.Value(), prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func fmtDays(age time.Duration) string {
+	return fmt.Sprintf("%g days", age.Hours()/24)
+}
@@ -0,0 +1,75 @@
+package lit
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// Project returns the tracker's project name, configured as "project", used
+// to prefix ids when referring to issues across trackers.
+func (l *Lit) Project() string {
+	project, _ := l.Config("project")
+	return strings.TrimSpace(project)
+}
+
+// PrefixedID returns the id of an issue prefixed by the project name, e.g.
+// "backend-3f2a...", or the plain id if no project is configured.  Lookup
+// accepts either.
+func (l *Lit) PrefixedID(issue *dgrl.Branch) string {
+	if project := l.Project(); project != "" {
+		return project + "-" + issue.Key()
+	}
+	return issue.Key()
+}
+
+// unprefixID removes the project prefix from an id, if present.
+func (l *Lit) unprefixID(id string) string {
+	if project := l.Project(); project != "" {
+		return strings.TrimPrefix(id, project+"-")
+	}
+	return id
+}
+
+// Resolver looks up issues by prefixed id across several trackers.
+type Resolver struct {
+	trackers map[string]*Lit
+}
+
+// NewResolver constructs a Resolver for the given loaded trackers, each of
+// which must have a distinct project name.
+func NewResolver(trackers ...*Lit) (*Resolver, error) {
+	r := &Resolver{trackers: map[string]*Lit{}}
+	for _, l := range trackers {
+		project := l.Project()
+		if project == "" {
+			return nil, fmt.Errorf("tracker in %s has no project configured", l.issueDir)
+		}
+		if _, ok := r.trackers[project]; ok {
+			return nil, fmt.Errorf("duplicate project %s", project)
+		}
+		r.trackers[project] = l
+	}
+	return r, nil
+}
+
+// Lookup returns the tracker and issue for a prefixed id.  As project names
+// may contain dashes, the longest matching project prefix is used.
+func (r *Resolver) Lookup(id string) (*Lit, *dgrl.Branch, error) {
+	var tracker *Lit
+	project := ""
+	for p, l := range r.trackers {
+		if strings.HasPrefix(id, p+"-") && len(p) > len(project) {
+			tracker, project = l, p
+		}
+	}
+	if tracker == nil {
+		return nil, nil, fmt.Errorf("no project found for issue %s", id)
+	}
+	issue, err := tracker.Lookup(id[len(project)+1:])
+	if err != nil {
+		return nil, nil, err
+	}
+	return tracker, issue, nil
+}
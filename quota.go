@@ -0,0 +1,91 @@
+package lit
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AttachUsage is the attachment storage used by an issue.
+type AttachUsage struct {
+	ID    string
+	Files int
+	Bytes int64
+	Over  bool // over the attachment quota
+}
+
+// AttachQuota returns the most attachment storage an issue should use, in
+// bytes, given by the attach-quota config (e.g. "10M"), or 0 if unlimited.
+func (l *Lit) AttachQuota() (int64, error) {
+	quota, ok := l.Config("attach-quota")
+	if !ok || strings.TrimSpace(quota) == "" {
+		return 0, nil
+	}
+	return ParseSize(quota)
+}
+
+// AttachUsage returns the attachment storage of the given issues that have
+// attachments, largest first.
+func (l *Lit) AttachUsage(ids []string) ([]AttachUsage, error) {
+	quota, err := l.AttachQuota()
+	if err != nil {
+		return nil, err
+	}
+	usage := []AttachUsage{}
+	for _, id := range ids {
+		issue := l.Issue(id)
+		if issue == nil {
+			continue
+		}
+		u := AttachUsage{ID: issue.Key()}
+		for _, att := range l.Attachments(issue) {
+			u.Files++
+			u.Bytes += att.Size
+		}
+		if u.Files == 0 {
+			continue
+		}
+		u.Over = quota > 0 && u.Bytes > quota
+		usage = append(usage, u)
+	}
+	sort.SliceStable(usage, func(i, j int) bool { return usage[i].Bytes > usage[j].Bytes })
+	return usage, nil
+}
+
+// ParseSize parses a size in bytes, optionally with a K, M, or G suffix for
+// powers of 1024, e.g. 512K or 1.5G.  A trailing B or iB is ignored.
+func ParseSize(size string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(size))
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "B"), "I")
+	mult := 1.0
+	if n := len(s); n > 0 {
+		switch s[n-1] {
+		case 'K':
+			mult = 1 << 10
+		case 'M':
+			mult = 1 << 20
+		case 'G':
+			mult = 1 << 30
+		}
+		if mult > 1 {
+			s = s[:n-1]
+		}
+	}
+	num, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || num < 0 {
+		return 0, fmt.Errorf("invalid size '%s'", size)
+	}
+	return int64(num * mult), nil
+}
+
+// FormatSize formats a size in bytes with a K, M, or G suffix as appropriate.
+func FormatSize(size int64) string {
+	for i, unit := range []string{"G", "M", "K"} {
+		scale := int64(1) << uint(10*(3-i))
+		if size >= scale {
+			return fmt.Sprintf("%.1f%s", float64(size)/float64(scale), unit)
+		}
+	}
+	return strconv.FormatInt(size, 10)
+}
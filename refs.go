@@ -0,0 +1,86 @@
+package lit
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// refRe matches words that may be full or short (at least 8 digit) UUID
+// issue ids, or ULIDs.  Sequential ids are not distinguishable from numbers.
+var refRe = regexp.MustCompile(`\b(?:[0-9a-f]{8}[0-9a-f-]*|[0-9a-hjkmnp-tv-z]{26})\b`)
+
+// References returns the ids of the other issues mentioned by full or short id
+// in an issue's description and comments.
+func (l *Lit) References(issue *dgrl.Branch) []string {
+	refSet := map[string]struct{}{}
+	scan := func(text string) {
+		for _, word := range refRe.FindAllString(text, -1) {
+			if ref, err := l.Lookup(word); err == nil && ref != issue {
+				refSet[ref.Key()] = struct{}{}
+			}
+		}
+	}
+	if desc, ok := GetExact(issue, "description"); ok {
+		scan(desc)
+	}
+	for _, k := range issue.Kids() {
+		if comment, ok := k.(*dgrl.Branch); ok {
+			for _, kk := range comment.Kids() {
+				if text, ok := kk.(*dgrl.Leaf); ok {
+					scan(text.Value())
+				}
+			}
+		}
+	}
+	refs := make([]string, 0, len(refSet))
+	for ref := range refSet {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	return refs
+}
+
+// updateReferences records the issues an issue mentions in its references
+// field.  It returns whether the field changed.
+func (l *Lit) updateReferences(issue *dgrl.Branch) bool {
+	refs := strings.Join(l.References(issue), " ")
+	old, ok := GetExact(issue, "references")
+	if ok && refs == "" {
+		return Unset(issue, "references")
+	}
+	if refs != old {
+		return SetExact(issue, "references", refs)
+	}
+	return false
+}
+
+// refresh fills in an issue's summary and updates its references, as Store
+// does for changed issues.  It returns whether the issue changed.
+func (l *Lit) refresh(issue *dgrl.Branch) bool {
+	summarized := l.AutoSummary(issue)
+	return l.updateReferences(issue) || summarized
+}
+
+// Reindex updates the references of all issues.  Store updates those of the
+// issues that changed.
+func (l *Lit) Reindex() {
+	for _, id := range l.issueIds {
+		l.updateReferences(l.issueMap[id])
+	}
+}
+
+// Backlinks returns the ids of the issues that reference an issue.
+func (l *Lit) Backlinks(issue *dgrl.Branch) []string {
+	return l.filter(func(other *dgrl.Branch) bool {
+		refs, _ := GetExact(other, "references")
+		for _, ref := range strings.Fields(refs) {
+			if ref == issue.Key() {
+				return true
+			}
+		}
+		return false
+	})
+}
@@ -0,0 +1,133 @@
+package lit
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// Releases are recorded in the config as "release-<version>", with a value of
+// "open" until shipped, and then the ship stamp.  The release to which issues
+// are added is recorded as "release-current", and a release's deadline, if
+// any, as "release-due-<version>".
+
+// StartRelease begins a release and makes it current.
+func (l *Lit) StartRelease(version string) error {
+	if _, ok := l.Config("release-" + version); ok {
+		return fmt.Errorf("release %s already exists", version)
+	}
+	l.SetConfig("release-"+version, "open")
+	l.SetConfig("release-current", version)
+	return nil
+}
+
+// CurrentRelease returns the version of the current release, if any.
+func (l *Lit) CurrentRelease() (string, bool) {
+	version, ok := l.Config("release-current")
+	return version, ok && version != ""
+}
+
+// AddToRelease adds an issue to the open release version by setting its
+// milestone.
+func (l *Lit) AddToRelease(issue *dgrl.Branch, version string) error {
+	if state, _ := l.Config("release-" + version); state != "open" {
+		return fmt.Errorf("release %s is not open", version)
+	}
+	if !SetExact(issue, "milestone", version) {
+		return fmt.Errorf("error setting milestone for issue %s", issue.Key())
+	}
+	return nil
+}
+
+// SetReleaseDue sets the deadline of release version to a date, as accepted by
+// ParseDate.
+func (l *Lit) SetReleaseDue(version, date string) error {
+	if _, ok := l.Config("release-" + version); !ok {
+		return fmt.Errorf("release %s doesn't exist", version)
+	}
+	due, err := ParseDate(date, l.Now())
+	if err != nil {
+		return err
+	}
+	l.SetConfig("release-due-"+version, due)
+	return nil
+}
+
+// ReleaseDeadlines returns the deadlines of the releases that have one, by
+// version.
+func (l *Lit) ReleaseDeadlines() map[string]string {
+	deadlines := map[string]string{}
+	for _, k := range l.config.Kids() {
+		leaf, ok := k.(*dgrl.Leaf)
+		if !ok || !strings.HasPrefix(leaf.Key(), "release-due-") || leaf.Value() == "" {
+			continue
+		}
+		deadlines[strings.TrimPrefix(leaf.Key(), "release-due-")] = leaf.Value()
+	}
+	return deadlines
+}
+
+// ReleaseIssues returns the ids of the issues in release version.
+func (l *Lit) ReleaseIssues(version string) []string {
+	return l.filter(func(issue *dgrl.Branch) bool {
+		milestone, _ := GetExact(issue, "milestone")
+		return milestone == version
+	})
+}
+
+// ShipRelease ships release version, setting fixed-in for each of its issues,
+// and returns their ids.  All of them must be closed.
+func (l *Lit) ShipRelease(version, username string) ([]string, error) {
+	if state, _ := l.Config("release-" + version); state != "open" {
+		return nil, fmt.Errorf("release %s is not open", version)
+	}
+	ids := l.ReleaseIssues(version)
+	open := []string{}
+	for _, id := range ids {
+		if isOpen(l.issueMap[id]) {
+			open = append(open, id)
+		}
+	}
+	if len(open) > 0 {
+		return nil, fmt.Errorf("release %s has open issues: %s", version, strings.Join(open, ", "))
+	}
+	stamp := l.Stamp(username)
+	for _, id := range ids {
+		issue := l.issueMap[id]
+		if !SetExact(issue, "fixed-in", version) || !Touch(issue, stamp) {
+			return nil, fmt.Errorf("error updating fields in issue %s", id)
+		}
+	}
+	l.SetConfig("release-"+version, stamp)
+	if current, _ := l.CurrentRelease(); current == version {
+		l.SetConfig("release-current", "")
+	}
+	return ids, nil
+}
+
+// WriteChangelog writes a Markdown changelog entry for release version,
+// listing the summaries of the given issues that aren't confidential.
+func (l *Lit) WriteChangelog(w io.Writer, version string, ids []string) error {
+	date := l.Now().UTC()
+	if state, _ := l.Config("release-" + version); state != "open" {
+		if t, ok := parseStampTime(state); ok {
+			date = t
+		}
+	}
+	if _, err := fmt.Fprintf(w, "## %s (%s)\n\n", version, date.Format("2006-01-02")); err != nil {
+		return err
+	}
+	for _, id := range l.Public(ids) {
+		issue := l.issueMap[id]
+		if issue == nil {
+			continue
+		}
+		summary, _ := Get(issue, "summary")
+		if _, err := fmt.Fprintf(w, "- %s (%s)\n", summary, l.ShortID(id)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package lit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// Review states are tracked as sets of users in these fields.  A user is in
+// at most one of them.
+var reviewFields = []string{"reviewers", "approved-by", "rejected-by"}
+
+// RequestReview asks a user to review an issue, returning false if the user
+// has already been asked or has reviewed it.
+func RequestReview(issue *dgrl.Branch, reviewer string) bool {
+	if ReviewState(issue, reviewer) != "" {
+		return false
+	}
+	return setReviewState(issue, reviewer, "reviewers")
+}
+
+// Review records a user's approval or rejection of an issue, with an optional
+// comment, replacing any earlier review by the same user.
+func (l *Lit) Review(issue *dgrl.Branch, username string, approve bool, text string) bool {
+	field, verb := "rejected-by", "Rejected"
+	if approve {
+		field, verb = "approved-by", "Approved"
+	}
+	if !setReviewState(issue, username, field) {
+		return false
+	}
+	_, err := l.comment(issue, username, strings.TrimSpace(verb+" "+text))
+	return err == nil
+}
+
+// ReviewState returns the review field holding a user, or "" if the user
+// has not been asked to review the issue.
+func ReviewState(issue *dgrl.Branch, username string) string {
+	for _, field := range reviewFields {
+		vals, _ := GetExact(issue, field)
+		if _, ok := tagStrToSet(vals)[username]; ok {
+			return field
+		}
+	}
+	return ""
+}
+
+// Approvals returns the users who have approved an issue.
+func Approvals(issue *dgrl.Branch) []string {
+	vals, _ := GetExact(issue, "approved-by")
+	return strings.Fields(vals)
+}
+
+func setReviewState(issue *dgrl.Branch, username, state string) bool {
+	for _, field := range reviewFields {
+		vals, ok := GetExact(issue, field)
+		vals = ModifyValueStr(vals, username, field == state)
+		switch {
+		case vals != "" || field == state:
+			ok = SetExact(issue, field, vals)
+		case ok:
+			ok = Unset(issue, field)
+		default:
+			ok = true
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// RequiredApprovals returns the number of approvals an issue needs before it
+// may be closed, configured as "close-approvals".  It defaults to 0.
+func (l *Lit) RequiredApprovals() int {
+	val, _ := l.Config("close-approvals")
+	n, err := strconv.Atoi(strings.TrimSpace(val))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// checkApprovals returns an error if an issue lacks the approvals it needs to
+// be closed.
+func (l *Lit) checkApprovals(issue *dgrl.Branch) error {
+	if n, need := len(Approvals(issue)), l.RequiredApprovals(); n < need {
+		return fmt.Errorf("issue %s has %d of %d required approvals", l.ShortID(issue.Key()), n, need)
+	}
+	return nil
+}
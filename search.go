@@ -0,0 +1,151 @@
+package lit
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ianremmler/dgrl"
+)
+
+const snippetLen = 60
+
+// fieldWeights gives the relevance of a match in each field.  Fields not
+// listed have weight 1.
+var fieldWeights = map[string]float64{
+	"summary":     4,
+	"tags":        3,
+	"description": 2,
+	"comment":     1,
+}
+
+// SearchOptions controls a search.
+type SearchOptions struct {
+	// Fields restricts the search to the given fields ("comment" for
+	// comments).  All fields are searched if empty.
+	Fields []string
+	// IncludeClosed includes closed issues in the results.
+	IncludeClosed bool
+	// Public leaves out confidential issues, as Lit.Public does.
+	Public bool
+	// Offset and Limit select a window of results, as for Page.
+	Offset, Limit int
+}
+
+// SearchResult is an issue matching a search.
+type SearchResult struct {
+	ID      string
+	Score   float64
+	Field   string
+	Snippet string
+}
+
+// Search returns the issues containing every word of query, ignoring case,
+// ranked by relevance.  Each result names the field that contributed most to
+// its score, with a snippet of that field's text around the match.
+func (l *Lit) Search(query string, opts SearchOptions) []SearchResult {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil
+	}
+	fields := map[string]bool{}
+	for _, field := range opts.Fields {
+		fields[field] = true
+	}
+	results := []SearchResult{}
+	for _, k := range l.issues.Kids() {
+		issue, ok := k.(*dgrl.Branch)
+		if !ok || (!opts.IncludeClosed && !isOpen(issue)) || (opts.Public && IsConfidential(issue)) {
+			continue
+		}
+		if res, ok := searchIssue(issue, terms, fields); ok {
+			results = append(results, res)
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if opts.Offset > len(results) {
+		opts.Offset = len(results)
+	}
+	results = results[opts.Offset:]
+	if opts.Limit > 0 && opts.Limit < len(results) {
+		results = results[:opts.Limit]
+	}
+	return results
+}
+
+func searchIssue(issue *dgrl.Branch, terms []string, fields map[string]bool) (SearchResult, bool) {
+	res := SearchResult{ID: issue.Key()}
+	found := make([]bool, len(terms))
+	best := 0.0
+	check := func(field, text string) {
+		if len(fields) > 0 && !fields[field] {
+			return
+		}
+		lower := strings.ToLower(text)
+		weight, ok := fieldWeights[field]
+		if !ok {
+			weight = 1
+		}
+		score, first := 0.0, -1
+		for i, term := range terms {
+			if n := strings.Count(lower, term); n > 0 {
+				found[i] = true
+				score += weight * float64(n)
+				if idx := strings.Index(lower, term); first < 0 || idx < first {
+					first = idx
+				}
+			}
+		}
+		res.Score += score
+		if score > best {
+			best = score
+			res.Field = field
+			res.Snippet = snippet(text, first)
+		}
+	}
+	for _, k := range issue.Kids() {
+		switch node := k.(type) {
+		case *dgrl.Leaf:
+			check(node.Key(), node.Value())
+		case *dgrl.Branch:
+			for _, kk := range node.Kids() {
+				if leaf, ok := kk.(*dgrl.Leaf); ok {
+					check("comment", leaf.Value())
+				}
+			}
+		}
+	}
+	for i := range found {
+		if !found[i] {
+			return res, false
+		}
+	}
+	return res, true
+}
+
+// snippet returns a single line excerpt of text around position idx.
+func snippet(text string, idx int) string {
+	start := idx - snippetLen/3
+	if start < 0 {
+		start = 0
+	}
+	end := start + snippetLen
+	if end > len(text) {
+		end = len(text)
+	}
+	for start > 0 && text[start]&0xc0 == 0x80 {
+		start--
+	}
+	for end < len(text) && text[end]&0xc0 == 0x80 {
+		end++
+	}
+	snip := strings.Join(strings.Fields(text[start:end]), " ")
+	if start > 0 {
+		snip = "..." + snip
+	}
+	if end < len(text) {
+		snip += "..."
+	}
+	return snip
+}
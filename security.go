@@ -0,0 +1,79 @@
+package lit
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// IsConfidential reports whether an issue is marked confidential.  Events for
+// confidential issues are not sent to notifiers.
+func IsConfidential(issue *dgrl.Branch) bool {
+	confidential, _ := GetExact(issue, "confidential")
+	return confidential != ""
+}
+
+// Public returns the ids of the given issues that aren't confidential.  It is
+// the filter for everything that sends issues out of the tracker: exports,
+// digests, forge sync, and the server, and so mirrors of it.
+func (l *Lit) Public(ids []string) []string {
+	public := []string{}
+	for _, id := range ids {
+		if issue := l.Issue(id); issue != nil && !IsConfidential(issue) {
+			public = append(public, id)
+		}
+	}
+	return public
+}
+
+// NewAdvisory creates a confidential security issue, with fields for its CVSS
+// score and the versions it affects.
+func (l *Lit) NewAdvisory(username, summary string) *dgrl.Branch {
+	issue := l.NewIssues(username, 1)[0]
+	Set(issue, "summary", summary)
+	ModifyTag(issue, "security", true)
+	SetExact(issue, "confidential", "yes")
+	SetExact(issue, "cvss", "")
+	SetExact(issue, "affects", "")
+	return issue
+}
+
+// Publish declassifies a confidential issue.
+func (l *Lit) Publish(issue *dgrl.Branch, username string) error {
+	if !IsConfidential(issue) {
+		return fmt.Errorf("issue %s is not confidential", issue.Key())
+	}
+	stamp := l.Stamp(username)
+	if !SetExact(issue, "confidential", "") || !SetExact(issue, "published", stamp) || !Touch(issue, stamp) {
+		return fmt.Errorf("error updating fields in issue %s", issue.Key())
+	}
+	return nil
+}
+
+// WriteAdvisory writes a Markdown security advisory for an issue.
+func (l *Lit) WriteAdvisory(w io.Writer, issue *dgrl.Branch) error {
+	field := func(key string) string {
+		if val, _ := GetExact(issue, key); val != "" {
+			return val
+		}
+		return "unknown"
+	}
+	date := l.Now().UTC()
+	if published, ok := stampTime(issue, "published"); ok {
+		date = published
+	}
+	desc, _ := GetExact(issue, "description")
+	_, err := fmt.Fprintf(w, `# Security advisory: %s
+
+- ID: %s
+- Published: %s
+- CVSS: %s
+- Affected versions: %s
+- Fixed in: %s
+
+%s
+`, field("summary"), issue.Key(), date.Format("2006-01-02"), field("cvss"),
+		field("affects"), field("fixed-in"), desc)
+	return err
+}
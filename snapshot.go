@@ -0,0 +1,144 @@
+package lit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// Snapshots capture the tracker state, e.g. at a release, as copies of the
+// issue, config, and alias files under .lit/snapshots/<name>/.
+const snapshotsDirname = "snapshots"
+
+func (l *Lit) snapshotDir(name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("invalid snapshot name '%s'", name)
+	}
+	return filepath.Join(l.issueDir, snapshotsDirname, name), nil
+}
+
+// CreateSnapshot captures the stored tracker state as the named snapshot.
+func (l *Lit) CreateSnapshot(name string) error {
+	dir, err := l.snapshotDir(name)
+	if err != nil {
+		return err
+	}
+	if l.fs.IsDir(dir) {
+		return fmt.Errorf("snapshot %s already exists", name)
+	}
+	if err := l.fs.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+	for _, filename := range []string{issueFilename, configFilename, aliasFilename} {
+		data, err := l.fs.ReadFile(filepath.Join(l.issueDir, filename))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := l.fs.WriteFile(filepath.Join(dir, filename), data, 0666); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Snapshots returns the names of the tracker's snapshots.
+func (l *Lit) Snapshots() ([]string, error) {
+	names, err := l.fs.ReadDirNames(filepath.Join(l.issueDir, snapshotsDirname))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return names, err
+}
+
+// snapshotIssues returns the issues of the named snapshot by id, or the
+// current issues if name is empty.
+func (l *Lit) snapshotIssues(name string) (map[string]*dgrl.Branch, error) {
+	if name == "" {
+		return l.issueMap, nil
+	}
+	dir, err := l.snapshotDir(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := l.fs.ReadFile(filepath.Join(dir, issueFilename))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no snapshot %s", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	root, err := DecodeIssues(data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing snapshot %s: %v", name, err)
+	}
+	issues := map[string]*dgrl.Branch{}
+	for _, k := range root.Kids() {
+		if issue, ok := k.(*dgrl.Branch); ok {
+			issues[issue.Key()] = issue
+		}
+	}
+	return issues, nil
+}
+
+// SnapshotDiff lists the ids of issues that differ between two snapshots.
+type SnapshotDiff struct {
+	Opened   []string // created since
+	Closed   []string // closed since, including those created since
+	Reopened []string
+	Changed  []string // otherwise changed, ignoring field order
+	Removed  []string
+}
+
+// DiffSnapshots compares the from snapshot with the to snapshot, or the
+// current issues if to is empty.  Ids are sorted.
+func (l *Lit) DiffSnapshots(from, to string) (*SnapshotDiff, error) {
+	before, err := l.snapshotIssues(from)
+	if err != nil {
+		return nil, err
+	}
+	after, err := l.snapshotIssues(to)
+	if err != nil {
+		return nil, err
+	}
+	diff := &SnapshotDiff{}
+	for _, id := range sortedKeys(after) {
+		issue := after[id]
+		old, existed := before[id]
+		switch {
+		case !existed:
+			diff.Opened = append(diff.Opened, id)
+			if !isOpen(issue) {
+				diff.Closed = append(diff.Closed, id)
+			}
+		case isOpen(old) && !isOpen(issue):
+			diff.Closed = append(diff.Closed, id)
+		case !isOpen(old) && isOpen(issue):
+			diff.Reopened = append(diff.Reopened, id)
+		case !reflect.DeepEqual(Data(old), Data(issue)):
+			diff.Changed = append(diff.Changed, id)
+		}
+	}
+	for _, id := range sortedKeys(before) {
+		if _, ok := after[id]; !ok {
+			diff.Removed = append(diff.Removed, id)
+		}
+	}
+	return diff, nil
+}
+
+func sortedKeys(issues map[string]*dgrl.Branch) []string {
+	ids := make([]string, 0, len(issues))
+	for id := range issues {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
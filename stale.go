@@ -0,0 +1,103 @@
+package lit
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// ParseAge parses a duration that may use day ("d") and week ("w") units in
+// addition to those understood by time.ParseDuration, e.g. "2d" or "1w".
+func ParseAge(age string) (time.Duration, error) {
+	unit := time.Duration(0)
+	switch {
+	case strings.HasSuffix(age, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(age, "w"):
+		unit = 7 * 24 * time.Hour
+	default:
+		return time.ParseDuration(age)
+	}
+	num, err := strconv.ParseFloat(age[:len(age)-1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid age '%s'", age)
+	}
+	return time.Duration(num * float64(unit)), nil
+}
+
+// stampTime returns the time of the stamp stored under key.
+func stampTime(issue *dgrl.Branch, key string) (time.Time, bool) {
+	stamp, ok := Get(issue, key)
+	if !ok {
+		return time.Time{}, false
+	}
+	return parseStampTime(stamp)
+}
+
+func isOpen(issue *dgrl.Branch) bool {
+	closed, _ := Get(issue, "closed")
+	return closed == ""
+}
+
+// Stale returns the ids of the open issues among ids that have not been
+// updated within age of now.
+func (l *Lit) Stale(ids []string, age time.Duration, now time.Time) []string {
+	stale := []string{}
+	for _, id := range ids {
+		issue := l.Issue(id)
+		if issue == nil || !isOpen(issue) {
+			continue
+		}
+		if updated, ok := stampTime(issue, "updated"); !ok || now.Sub(updated) > age {
+			stale = append(stale, issue.Key())
+		}
+	}
+	return stale
+}
+
+// SLAViolation describes an open issue that has gone without an update for
+// longer than its priority allows.
+type SLAViolation struct {
+	ID       string
+	Priority string
+	Limit    time.Duration
+	Age      time.Duration
+}
+
+// SLAViolations checks the open issues among ids against the update
+// intervals configured per priority as "sla-<priority>", e.g. "sla-1: 2d".
+func (l *Lit) SLAViolations(ids []string, now time.Time) ([]SLAViolation, error) {
+	violations := []SLAViolation{}
+	for _, id := range ids {
+		issue := l.Issue(id)
+		if issue == nil || !isOpen(issue) {
+			continue
+		}
+		priority, _ := Get(issue, "priority")
+		if priority == "" {
+			continue
+		}
+		sla, ok := l.Config("sla-" + priority)
+		if !ok {
+			continue
+		}
+		limit, err := ParseAge(sla)
+		if err != nil {
+			return nil, fmt.Errorf("sla-%s: %s", priority, err)
+		}
+		updated, _ := stampTime(issue, "updated")
+		if age := now.Sub(updated); age > limit {
+			violations = append(violations, SLAViolation{
+				ID: issue.Key(), Priority: priority, Limit: limit, Age: age,
+			})
+		}
+	}
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Age-violations[i].Limit > violations[j].Age-violations[j].Limit
+	})
+	return violations, nil
+}
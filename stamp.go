@@ -0,0 +1,114 @@
+package lit
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// stampLayouts are the time formats accepted in stamps, most common first.
+var stampLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ParseStamp parses a stamp, as made by Stamp, into its time and user.  Besides
+// RFC3339, times with a space in place of the "T", without seconds or a
+// zone (taken as UTC), or consisting of only a date are accepted, as are
+// flit style stamps, which put the user first.
+func ParseStamp(stamp string) (time.Time, string, error) {
+	fields := strings.Fields(stamp)
+	for n := 2; n > 0; n-- {
+		if len(fields) < n {
+			continue
+		}
+		if t, ok := parseTime(strings.Join(fields[:n], " ")); ok {
+			return t, strings.Join(fields[n:], " "), nil
+		}
+	}
+	for n := 2; n > 0; n-- {
+		if len(fields) <= n {
+			continue
+		}
+		if t, ok := parseTime(strings.Join(fields[len(fields)-n:], " ")); ok {
+			return t, strings.Join(fields[:len(fields)-n], " "), nil
+		}
+	}
+	return time.Time{}, "", errors.New("invalid stamp '" + stamp + "'")
+}
+
+func parseTime(val string) (time.Time, bool) {
+	for _, layout := range stampLayouts {
+		if t, err := time.Parse(layout, val); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// NormalizeStamp rewrites a stamp with a user in the form made by Stamp.
+// Other values are returned unchanged.
+func NormalizeStamp(stamp string) string {
+	t, user, err := ParseStamp(stamp)
+	if err != nil || user == "" {
+		return stamp
+	}
+	return t.UTC().Format(time.RFC3339) + " " + user
+}
+
+// parseStampTime returns the time of a stamp, if it is valid.
+func parseStampTime(stamp string) (time.Time, bool) {
+	t, _, err := ParseStamp(stamp)
+	return t, err == nil
+}
+
+// FormatStamp returns stamp with its time rendered in loc, or relative to now
+// if relative is set.  Values that are not stamps are returned unchanged.
+func FormatStamp(stamp string, loc *time.Location, relative bool, now time.Time) string {
+	t, user, err := ParseStamp(stamp)
+	if err != nil {
+		return stamp
+	}
+	disp := t.In(loc).Format(time.RFC3339)
+	if relative {
+		disp = Ago(t, now)
+	}
+	if user != "" {
+		disp += " " + user
+	}
+	return disp
+}
+
+// Ago describes the time t relative to now, e.g. "3 days ago".
+func Ago(t, now time.Time) string {
+	age := now.Sub(t)
+	suffix := "ago"
+	if age < 0 {
+		age, suffix = -age, "from now"
+	}
+	num, unit := 0, ""
+	switch {
+	case age < time.Minute:
+		return "just now"
+	case age < time.Hour:
+		num, unit = int(age.Minutes()), "minute"
+	case age < 24*time.Hour:
+		num, unit = int(age.Hours()), "hour"
+	case age < 30*24*time.Hour:
+		num, unit = int(age.Hours()/24), "day"
+	case age < 365*24*time.Hour:
+		num, unit = int(age.Hours()/(30*24)), "month"
+	default:
+		num, unit = int(age.Hours()/(365*24)), "year"
+	}
+	if num != 1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("%d %s %s", num, unit, suffix)
+}
@@ -0,0 +1,85 @@
+package lit
+
+import (
+	"testing"
+)
+
+// memTracker returns a loaded tracker on a MemFS holding num new issues.
+func memTracker(t *testing.T, num int) *Lit {
+	t.Helper()
+	l := NewWithFS(NewMemFS(), "/test")
+	if err := l.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Load(); err != nil {
+		t.Fatal(err)
+	}
+	l.NewIssues("tester", num)
+	if err := l.Store(); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Load(); err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func TestStoreRefreshesUnnotedChanges(t *testing.T) {
+	l := memTracker(t, 3)
+	ids := l.IssueIds()
+	noted, unnoted, other := l.Issue(ids[0]), l.Issue(ids[1]), l.Issue(ids[2])
+	if err := l.SetDescription(noted, "tester", "Noted change"); err != nil {
+		t.Fatal(err)
+	}
+	// a change made without the tracker, so not noted
+	if !SetLong(unnoted, "description", "Blocked by "+ShortID(other.Key())) {
+		t.Fatal("error setting description")
+	}
+	if err := l.Store(); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := GetExact(l.Issue(ids[0]), "summary"); got != "Noted change" {
+		t.Errorf("noted issue summary = %q, want %q", got, "Noted change")
+	}
+	if got, _ := GetExact(l.Issue(ids[1]), "summary"); got != "Blocked by "+ShortID(other.Key()) {
+		t.Errorf("unnoted issue summary = %q", got)
+	}
+	if got, _ := GetExact(l.Issue(ids[1]), "references"); got != other.Key() {
+		t.Errorf("unnoted issue references = %q, want %q", got, other.Key())
+	}
+}
+
+func TestStoreUnchanged(t *testing.T) {
+	l := memTracker(t, 2)
+	before, err := l.fs.ReadFile("/test/.lit/issues")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Store(); err != nil {
+		t.Fatal(err)
+	}
+	after, err := l.fs.ReadFile("/test/.lit/issues")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(before) != string(after) {
+		t.Errorf("Store without changes rewrote\n%s\nas\n%s", before, after)
+	}
+}
+
+func TestIssueBlocks(t *testing.T) {
+	text := "== a\n- summary: x\n=== stamp\n~~\ny\n~~.\n== b\n- summary: z"
+	blocks := issueBlocks([]byte(text))
+	if len(blocks) != 2 {
+		t.Fatalf("got %d blocks, want 2: %q", len(blocks), blocks)
+	}
+	if want := "== a\n- summary: x\n=== stamp\n~~\ny\n~~.\n"; blocks["a"] != want {
+		t.Errorf("block a = %q, want %q", blocks["a"], want)
+	}
+	if want := "== b\n- summary: z"; blocks["b"] != want {
+		t.Errorf("block b = %q, want %q", blocks["b"], want)
+	}
+}
@@ -0,0 +1,65 @@
+package lit
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// SummaryMax returns the longest a summary should be, in characters, given by
+// the summary-max config, default 72.
+func (l *Lit) SummaryMax() int {
+	if max, ok := l.Config("summary-max"); ok {
+		if n, err := strconv.Atoi(strings.TrimSpace(max)); err == nil {
+			return n
+		}
+	}
+	return defaultSummaryMax
+}
+
+// LongSummary returns the length of an issue's summary, and whether it is
+// longer than SummaryMax.
+func (l *Lit) LongSummary(issue *dgrl.Branch) (int, bool) {
+	summary, _ := GetExact(issue, "summary")
+	n := len([]rune(strings.TrimSpace(summary)))
+	return n, n > l.SummaryMax()
+}
+
+// AutoSummary fills in an issue's empty summary from the first line of its
+// description, cut at a word to fit SummaryMax, as Store does for the issues
+// it writes.  The auto-summary config selects the behavior: "first-line"
+// (the default) or "off".  It returns whether the summary was set.
+func (l *Lit) AutoSummary(issue *dgrl.Branch) bool {
+	if mode, _ := l.Config("auto-summary"); strings.TrimSpace(mode) == "off" {
+		return false
+	}
+	if summary, _ := GetExact(issue, "summary"); strings.TrimSpace(summary) != "" {
+		return false
+	}
+	desc, _ := GetExact(issue, "description")
+	line := ""
+	for _, ln := range strings.Split(desc, "\n") {
+		if line = strings.TrimSpace(ln); line != "" {
+			break
+		}
+	}
+	if line == "" {
+		return false
+	}
+	return SetForce(issue, "summary", truncateWords(line, l.SummaryMax()))
+}
+
+// truncateWords shortens text to at most max characters, at a space if there
+// is one.
+func truncateWords(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	cut := string(runes[:max])
+	if i := strings.LastIndex(cut, " "); i > 0 && runes[max] != ' ' {
+		cut = cut[:i]
+	}
+	return strings.TrimSpace(cut)
+}
@@ -0,0 +1,333 @@
+package lit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// ForgeIssue is an issue on a forge.
+type ForgeIssue struct {
+	Number  int
+	Title   string
+	Body    string
+	Labels  []string
+	Closed  bool
+	Updated string // as reported by the forge, compared to detect changes
+}
+
+// ForgeComment is a comment on a forge issue.
+type ForgeComment struct {
+	ID      int64
+	Author  string
+	Body    string
+	Created time.Time
+}
+
+// Forge is the issue tracker of a forge, such as Gitea or GitLab, that Sync
+// keeps in step with the tracker.
+type Forge interface {
+	Issues() ([]*ForgeIssue, error)
+	Issue(number int) (*ForgeIssue, error)
+	Comments(number int) ([]ForgeComment, error)
+	CreateIssue(fi *ForgeIssue) (int, error)
+	UpdateIssue(fi *ForgeIssue) error
+	AddComment(number int, body string) (int64, error)
+}
+
+// Forge returns the forge configured as "sync-forge" (gitea or gitlab), at
+// "sync-url", for the repository "sync-repo" (e.g. owner/name).  The access
+// token is configured as "sync-token" or given by the LIT_SYNC_TOKEN
+// environment variable.
+func (l *Lit) Forge() (Forge, error) {
+	kind, _ := l.Config("sync-forge")
+	url, _ := l.Config("sync-url")
+	repo, _ := l.Config("sync-repo")
+	if url == "" || repo == "" {
+		return nil, errors.New("sync-url and sync-repo must be configured")
+	}
+	token := os.Getenv("LIT_SYNC_TOKEN")
+	if token == "" {
+		token, _ = l.Config("sync-token")
+	}
+	switch kind {
+	case "gitea":
+		return NewGitea(url, repo, token), nil
+	case "gitlab":
+		return NewGitLab(url, repo, token), nil
+	}
+	return nil, fmt.Errorf("unknown sync-forge '%s' (gitea, gitlab)", kind)
+}
+
+// SyncReport lists what Sync did, by lit issue id.
+type SyncReport struct {
+	Pulled    []string // created or updated from the forge
+	Pushed    []string // created or updated on the forge
+	Conflicts []string // changed on both sides, left alone
+}
+
+// Sync brings the tracker and a forge in step.  Issues are paired by the
+// forge-number field.  Forge issues without a pair are added, as are open,
+// non-confidential issues on the forge.  Summary, description, tags (labels),
+// and closed state are copied from whichever side changed since the last
+// sync.  If both did, the issue is reported as a conflict, unless prefer is
+// "lit" or "forge".  New comments are copied both ways.  Issues that have since
+// become confidential are left alone.  Changes to issues are
+// written by the next Store, which should be done even if an error is
+// returned, so issues already created on the forge are not created again.
+func (l *Lit) Sync(forge Forge, username, prefer string) (*SyncReport, error) {
+	report := &SyncReport{}
+	forgeIssues, err := forge.Issues()
+	if err != nil {
+		return report, err
+	}
+	byNumber := map[int]*dgrl.Branch{}
+	for _, id := range l.issueIds {
+		if n, err := strconv.Atoi(forgeField(l.issueMap[id], "number")); err == nil {
+			byNumber[n] = l.issueMap[id]
+		}
+	}
+	for _, fi := range forgeIssues {
+		issue, ok := byNumber[fi.Number]
+		if !ok {
+			issue = l.NewIssues(username, 1)[0]
+			SetExact(issue, "forge-number", strconv.Itoa(fi.Number))
+			if err := l.pull(forge, issue, fi, username, false); err != nil {
+				return report, err
+			}
+			report.Pulled = append(report.Pulled, issue.Key())
+			continue
+		}
+		delete(byNumber, fi.Number)
+		if IsConfidential(issue) {
+			continue
+		}
+		litChanged := fieldVal(issue, "updated") != forgeField(issue, "synced")
+		forgeChanged := fi.Updated != forgeField(issue, "updated")
+		switch {
+		case litChanged && forgeChanged && prefer == "" && !sameFields(issue, fi):
+			if err := l.syncComments(forge, issue, fi.Number, true, true); err != nil {
+				return report, err
+			}
+			report.Conflicts = append(report.Conflicts, issue.Key())
+		case litChanged && (!forgeChanged || prefer == "lit"):
+			if err := l.push(forge, issue, fi.Number, forgeChanged); err != nil {
+				return report, err
+			}
+			report.Pushed = append(report.Pushed, issue.Key())
+		case forgeChanged:
+			if err := l.pull(forge, issue, fi, username, litChanged); err != nil {
+				return report, err
+			}
+			report.Pulled = append(report.Pulled, issue.Key())
+		}
+	}
+	for _, id := range l.Public(l.issueIds) {
+		issue := l.issueMap[id]
+		if forgeField(issue, "number") != "" || !isOpen(issue) {
+			continue
+		}
+		number, err := forge.CreateIssue(forgeIssue(issue, 0))
+		if err != nil {
+			return report, err
+		}
+		SetExact(issue, "forge-number", strconv.Itoa(number))
+		if err := l.push(forge, issue, number, false); err != nil {
+			return report, err
+		}
+		report.Pushed = append(report.Pushed, issue.Key())
+	}
+	return report, nil
+}
+
+// pull updates an issue from its forge issue, first pushing the issue's new
+// comments if pushComments is set.
+func (l *Lit) pull(forge Forge, issue *dgrl.Branch, fi *ForgeIssue, username string, pushComments bool) error {
+	if err := l.syncComments(forge, issue, fi.Number, pushComments, true); err != nil {
+		return err
+	}
+	if pushComments {
+		var err error
+		if fi, err = forge.Issue(fi.Number); err != nil {
+			return err
+		}
+	}
+	stamp := l.Stamp(username)
+	ok := SetExact(issue, "summary", fi.Title) &&
+		set(issue, "description", fi.Body, true, true, false) &&
+		SetExact(issue, "tags", forgeTags(fi.Labels))
+	if closed := !isOpen(issue); closed != fi.Closed {
+		if fi.Closed {
+			ok = ok && SetForce(issue, "closed", stamp)
+		} else {
+			ok = ok && Reopen(issue, stamp)
+		}
+	}
+	if !ok || !Touch(issue, stamp) {
+		return fmt.Errorf("error updating fields for issue %s", issue.Key())
+	}
+	return l.markSynced(issue, fi.Updated)
+}
+
+// push updates the forge issue of an issue, including its new comments.
+// Forge comments are pulled first if the forge issue changed.
+func (l *Lit) push(forge Forge, issue *dgrl.Branch, number int, forgeChanged bool) error {
+	if err := l.syncComments(forge, issue, number, true, forgeChanged); err != nil {
+		return err
+	}
+	if err := forge.UpdateIssue(forgeIssue(issue, number)); err != nil {
+		return err
+	}
+	fi, err := forge.Issue(number)
+	if err != nil {
+		return err
+	}
+	return l.markSynced(issue, fi.Updated)
+}
+
+// syncComments pushes an issue's comments made since the last sync to the
+// forge and pulls forge comments not yet seen, as enabled.
+func (l *Lit) syncComments(forge Forge, issue *dgrl.Branch, number int, doPush, doPull bool) error {
+	known := tagStrToSet(forgeField(issue, "comments"))
+	comments := issueComments(issue)
+	if doPush {
+		count, _ := strconv.Atoi(forgeField(issue, "comment-count"))
+		for i := count; i < len(comments); i++ {
+			_, user, _ := ParseStamp(comments[i].Stamp)
+			id, err := forge.AddComment(number, fmt.Sprintf("%s wrote:\n\n%s", user, comments[i].Text))
+			if err != nil {
+				return err
+			}
+			known[strconv.FormatInt(id, 10)] = struct{}{}
+		}
+	}
+	if doPull {
+		forgeComments, err := forge.Comments(number)
+		if err != nil {
+			return err
+		}
+		for _, fc := range forgeComments {
+			id := strconv.FormatInt(fc.ID, 10)
+			if _, ok := known[id]; ok {
+				continue
+			}
+			comment := dgrl.NewBranch(stampAt(fc.Created, fc.Author))
+			comment.Append(dgrl.NewText(fc.Body))
+			issue.Append(comment)
+			known[id] = struct{}{}
+		}
+	}
+	ok := SetExact(issue, "forge-comments", setToTagStr(known)) &&
+		SetExact(issue, "forge-comment-count", strconv.Itoa(len(issueComments(issue))))
+	if !ok {
+		return fmt.Errorf("error updating forge fields for issue %s", issue.Key())
+	}
+	l.Changed(issue)
+	return nil
+}
+
+// markSynced records that an issue and its forge issue are in step.
+func (l *Lit) markSynced(issue *dgrl.Branch, forgeUpdated string) error {
+	l.Changed(issue)
+	if !SetExact(issue, "forge-updated", forgeUpdated) ||
+		!SetExact(issue, "forge-synced", fieldVal(issue, "updated")) {
+		return fmt.Errorf("error updating forge fields for issue %s", issue.Key())
+	}
+	return nil
+}
+
+func forgeField(issue *dgrl.Branch, key string) string {
+	return fieldVal(issue, "forge-"+key)
+}
+
+func fieldVal(issue *dgrl.Branch, key string) string {
+	val, _ := GetExact(issue, key)
+	return val
+}
+
+func issueComments(issue *dgrl.Branch) []CommentData {
+	return Data(issue).Comments
+}
+
+func forgeIssue(issue *dgrl.Branch, number int) *ForgeIssue {
+	return &ForgeIssue{
+		Number: number,
+		Title:  fieldVal(issue, "summary"),
+		Body:   fieldVal(issue, "description"),
+		Labels: strings.Fields(fieldVal(issue, "tags")),
+		Closed: !isOpen(issue),
+	}
+}
+
+// forgeTags converts labels to tags, which can't contain spaces.
+func forgeTags(labels []string) string {
+	tags := []string{}
+	for _, label := range labels {
+		tags = append(tags, strings.Join(strings.Fields(label), "-"))
+	}
+	sort.Strings(tags)
+	return strings.Join(tags, " ")
+}
+
+func sameFields(issue *dgrl.Branch, fi *ForgeIssue) bool {
+	mine := forgeIssue(issue, fi.Number)
+	return mine.Title == fi.Title && mine.Body == fi.Body && mine.Closed == fi.Closed &&
+		strings.Join(mine.Labels, " ") == forgeTags(fi.Labels)
+}
+
+// forgeAPI makes JSON requests to a forge's REST API.
+type forgeAPI struct {
+	base       string
+	authHeader string
+	auth       string
+}
+
+func (a *forgeAPI) call(method, path string, in, out interface{}) error {
+	body := []byte{}
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, a.base+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if a.auth != "" {
+		req.Header.Set(a.authHeader, a.auth)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// pages calls get for successive pages, counting from 1, until it returns
+// fewer than perPage items.
+func pages(perPage int, get func(page int) (int, error)) error {
+	for page := 1; ; page++ {
+		n, err := get(page)
+		if err != nil || n < perPage {
+			return err
+		}
+	}
+}
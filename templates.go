@@ -0,0 +1,86 @@
+package lit
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// Templates are files under .lit/templates holding the fields of new issues,
+// as for drafts.  They are text/templates, in which {{prompt "question"}} is
+// replaced by the answer to question.
+const templatesDirname = "templates"
+
+// Templates returns the names of the available templates.
+func (l *Lit) Templates() ([]string, error) {
+	return l.fileNames(filepath.Join(l.issueDir, templatesDirname))
+}
+
+// fileNames returns the sorted names of the files in dir, other than hidden
+// ones, or none if dir doesn't exist.
+func (l *Lit) fileNames(dir string) ([]string, error) {
+	entries, err := l.fs.ReadDirNames(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, name := range entries {
+		if !strings.HasPrefix(name, ".") && !l.fs.IsDir(filepath.Join(dir, name)) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// NewFromTemplate adds num new issues with the fields of the named template.
+// Each question prompted for is passed to answer once.
+func (l *Lit) NewFromTemplate(username, name string, num int, answer func(question string) (string, error)) ([]*dgrl.Branch, error) {
+	if strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("invalid template name '%s'", name)
+	}
+	data, err := l.fs.ReadFile(filepath.Join(l.issueDir, templatesDirname, name))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("template %s not found", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	answers := map[string]string{}
+	prompt := func(question string) (string, error) {
+		if ans, ok := answers[question]; ok {
+			return ans, nil
+		}
+		ans, err := answer(question)
+		answers[question] = ans
+		return ans, err
+	}
+	tmpl, err := template.New(name).Funcs(template.FuncMap{"prompt": prompt}).Parse(string(data))
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, nil); err != nil {
+		return nil, err
+	}
+	root := dgrl.NewParser().Parse(buf)
+	if root == nil {
+		return nil, fmt.Errorf("error parsing template %s", name)
+	}
+	fields, err := fieldLeaves(root)
+	if err != nil {
+		return nil, fmt.Errorf("template %s: %w", name, err)
+	}
+	issues := l.NewIssues(username, num)
+	for _, issue := range issues {
+		setFields(issue, fields)
+	}
+	return issues, nil
+}
@@ -0,0 +1,96 @@
+package lit
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// versionKeys are the fields whose values are always compared as versions.
+var versionKeys = []string{"affects", "fixed-in"}
+
+var semverRe = regexp.MustCompile(`^v?(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// isVersionKey returns whether key names a version field.  key may abbreviate
+// the field name.
+func isVersionKey(key string) bool {
+	for _, k := range versionKeys {
+		if key != "" && strings.HasPrefix(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// compareKeyVals compares two values of key, as versions for version fields,
+// or as compareVals does otherwise.
+func compareKeyVals(key, a, b string) int {
+	if isVersionKey(key) {
+		if cmp, ok := CompareVersions(a, b); ok {
+			return cmp
+		}
+	}
+	return compareVals(a, b)
+}
+
+// CompareVersions compares two semantic versions, which may have a leading
+// "v" and omit the minor and patch numbers.  It returns -1, 0, or 1 if a is
+// less than, equal to, or greater than b, and false if either is not a
+// version.
+func CompareVersions(a, b string) (int, bool) {
+	am, bm := semverRe.FindStringSubmatch(a), semverRe.FindStringSubmatch(b)
+	if am == nil || bm == nil {
+		return 0, false
+	}
+	for i := 1; i <= 3; i++ {
+		an, _ := strconv.Atoi(am[i])
+		bn, _ := strconv.Atoi(bm[i])
+		if cmp := compareInts(an, bn); cmp != 0 {
+			return cmp, true
+		}
+	}
+	return comparePrerelease(am[4], bm[4]), true
+}
+
+// comparePrerelease compares prerelease tags, which rank below the release
+// itself, by their dot separated identifiers.
+func comparePrerelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+	aIds, bIds := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(aIds) && i < len(bIds); i++ {
+		an, aErr := strconv.Atoi(aIds[i])
+		bn, bErr := strconv.Atoi(bIds[i])
+		cmp := 0
+		switch {
+		case aErr == nil && bErr == nil:
+			cmp = compareInts(an, bn)
+		case aErr == nil:
+			cmp = -1
+		case bErr == nil:
+			cmp = 1
+		default:
+			cmp = strings.Compare(aIds[i], bIds[i])
+		}
+		if cmp != 0 {
+			return cmp
+		}
+	}
+	return compareInts(len(aIds), len(bIds))
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
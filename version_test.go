@@ -0,0 +1,39 @@
+package lit
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	// in increasing order, as in the semver spec's precedence example
+	ordered := []string{
+		"0.9", "1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta",
+		"1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.2", "1.10", "v2",
+	}
+	for i := range ordered {
+		for j := range ordered {
+			want := compareInts(i, j)
+			if got, ok := CompareVersions(ordered[i], ordered[j]); !ok || got != want {
+				t.Errorf("CompareVersions(%q, %q) = %d, %v, want %d", ordered[i], ordered[j], got, ok, want)
+			}
+		}
+	}
+	tests := []struct {
+		a, b string
+		want int
+		ok   bool
+	}{
+		{"v1.2.3", "1.2.3", 0, true},
+		{"1", "1.0.0", 0, true},
+		{"1.0.0+build.1", "1.0.0+build.2", 0, true},
+		{"1.0.0-rc.1+build", "1.0.0-rc.1", 0, true},
+		{"1.0.0-1", "1.0.0-a", -1, true},
+		{"1.0.0-a-b", "1.0.0-a", 1, true},
+		{"next", "1.0.0", 0, false},
+		{"1.0.0", "1.0.0.0", 0, false},
+		{"", "1", 0, false},
+	}
+	for _, test := range tests {
+		if got, ok := CompareVersions(test.a, test.b); got != test.want || ok != test.ok {
+			t.Errorf("CompareVersions(%q, %q) = %d, %v, want %d, %v", test.a, test.b, got, ok, test.want, test.ok)
+		}
+	}
+}
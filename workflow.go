@@ -0,0 +1,149 @@
+package lit
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// A Workflow is a state machine for the status field of issues.  It is
+// configured by "workflow-states", listing the states with the initial one
+// first, "workflow-<state>" for each state, listing the states it may move
+// to, and "workflow-closed", listing the states in which issues are closed,
+// e.g.
+//
+//   - workflow-states: new review done
+//   - workflow-new: review
+//   - workflow-review: new done
+//   - workflow-closed: done
+type Workflow struct {
+	States []string
+	Next   map[string][]string
+	Closed map[string]bool
+
+	l *Lit // for its clock and the approvals needed to close, if set
+}
+
+// Workflow returns the configured workflow, or nil if there is none.
+func (l *Lit) Workflow() (*Workflow, error) {
+	states, _ := l.Config("workflow-states")
+	wf := &Workflow{States: strings.Fields(states), Next: map[string][]string{}, Closed: map[string]bool{}, l: l}
+	if len(wf.States) == 0 {
+		return nil, nil
+	}
+	for _, state := range wf.States {
+		next, _ := l.Config("workflow-" + state)
+		for _, to := range strings.Fields(next) {
+			if !wf.IsState(to) {
+				return nil, fmt.Errorf("workflow state %s moves to unknown state %s", state, to)
+			}
+			wf.Next[state] = append(wf.Next[state], to)
+		}
+	}
+	closed, _ := l.Config("workflow-closed")
+	for _, state := range strings.Fields(closed) {
+		if !wf.IsState(state) {
+			return nil, fmt.Errorf("unknown closed workflow state %s", state)
+		}
+		wf.Closed[state] = true
+	}
+	return wf, nil
+}
+
+// IsState reports whether state is one of the workflow's states.
+func (wf *Workflow) IsState(state string) bool {
+	return hasString(wf.States, state)
+}
+
+// State returns the state of an issue, which is the initial state if its
+// status is empty.
+func (wf *Workflow) State(issue *dgrl.Branch) string {
+	if state := fieldVal(issue, "status"); state != "" {
+		return state
+	}
+	return wf.States[0]
+}
+
+// NextStates returns the states an issue may move to.
+func (wf *Workflow) NextStates(issue *dgrl.Branch) []string {
+	return append([]string{}, wf.Next[wf.State(issue)]...)
+}
+
+// CanTransition reports whether an issue may move to a state.
+func (wf *Workflow) CanTransition(issue *dgrl.Branch, state string) bool {
+	return wf.checkTransition(issue, state) == nil
+}
+
+func (wf *Workflow) checkTransition(issue *dgrl.Branch, state string) error {
+	if !wf.IsState(state) {
+		return fmt.Errorf("unknown workflow state %s (%s)", state, strings.Join(wf.States, ", "))
+	}
+	if next := wf.NextStates(issue); !hasString(next, state) {
+		if len(next) == 0 {
+			next = []string{"none"}
+		}
+		return fmt.Errorf("issue %.8s can't move from %s to %s (next: %s)",
+			issue.Key(), wf.State(issue), state, strings.Join(next, ", "))
+	}
+	return nil
+}
+
+// Transition moves an issue to a state, closing or reopening it as the state
+// requires.  Closing needs the tracker's required approvals.  The issue is
+// stamped by the tracker's clock.
+func (wf *Workflow) Transition(issue *dgrl.Branch, state, username string) error {
+	if err := wf.checkTransition(issue, state); err != nil {
+		return err
+	}
+	if wf.Closed[state] && isOpen(issue) && wf.l != nil {
+		if err := wf.l.checkApprovals(issue); err != nil {
+			return err
+		}
+	}
+	return wf.move(issue, state, wf.l.Stamp(username))
+}
+
+// closeState returns the state to which closing, or reopening, an issue moves
+// it: the one closed, or open, state it may move to.  It returns "" if the
+// issue is in such a state already, or if the workflow has no closed states,
+// so doesn't govern closing.
+func (wf *Workflow) closeState(issue *dgrl.Branch, closing bool) (string, error) {
+	if len(wf.Closed) == 0 || wf.Closed[wf.State(issue)] == closing {
+		return "", nil
+	}
+	states := []string{}
+	for _, state := range wf.NextStates(issue) {
+		if wf.Closed[state] == closing {
+			states = append(states, state)
+		}
+	}
+	what := "open"
+	if closing {
+		what = "closed"
+	}
+	switch len(states) {
+	case 0:
+		return "", fmt.Errorf("issue %.8s can't move from %s to a %s state", issue.Key(), wf.State(issue), what)
+	case 1:
+		return states[0], nil
+	}
+	return "", fmt.Errorf("issue %.8s may move from %s to %s states %s, so set its status instead",
+		issue.Key(), wf.State(issue), what, strings.Join(states, ", "))
+}
+
+// move sets an issue's state, closing or reopening it as the state requires,
+// with the given stamp.
+func (wf *Workflow) move(issue *dgrl.Branch, state, stamp string) error {
+	ok := SetExact(issue, "status", state)
+	switch closed := !isOpen(issue); {
+	case wf.Closed[state] && !closed:
+		ok = ok && SetForce(issue, "closed", stamp)
+	case !wf.Closed[state] && closed:
+		ok = ok && Reopen(issue, stamp)
+	}
+	if !ok || !Touch(issue, stamp) {
+		return fmt.Errorf("error moving issue %s to %s", issue.Key(), state)
+	}
+	return nil
+}
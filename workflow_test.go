@@ -0,0 +1,131 @@
+package lit
+
+import (
+	"testing"
+	"time"
+)
+
+var testTime = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// workflowTracker returns a tracker with a fixed clock and a workflow, and one
+// new issue in it.
+func workflowTracker(t *testing.T, closed string) (*Lit, string) {
+	t.Helper()
+	l := memTracker(t, 1)
+	l.SetClock(ClockFunc(func() time.Time { return testTime }))
+	l.SetConfig("workflow-states", "new review done wontfix")
+	l.SetConfig("workflow-new", "review")
+	l.SetConfig("workflow-review", "new done")
+	l.SetConfig("workflow-done", "review")
+	l.SetConfig("workflow-closed", closed)
+	return l, l.IssueIds()[0]
+}
+
+func TestWorkflowTransitions(t *testing.T) {
+	l, id := workflowTracker(t, "done")
+	wf, err := l.Workflow()
+	if err != nil || wf == nil {
+		t.Fatalf("Workflow() = %v, %v", wf, err)
+	}
+	issue := l.Issue(id)
+	tests := []struct {
+		from, to string
+		ok       bool
+	}{
+		{"", "review", true},
+		{"", "done", false},
+		{"new", "new", false},
+		{"review", "done", true},
+		{"review", "new", true},
+		{"done", "review", true},
+		{"done", "new", false},
+		{"review", "nosuch", false},
+	}
+	for _, test := range tests {
+		SetExact(issue, "status", test.from)
+		if got := wf.CanTransition(issue, test.to); got != test.ok {
+			t.Errorf("%q to %q: CanTransition = %v, want %v", test.from, test.to, got, test.ok)
+		}
+	}
+}
+
+func TestWorkflowConfigErrors(t *testing.T) {
+	for _, config := range [][2]string{{"workflow-new", "nosuch"}, {"workflow-closed", "nosuch"}} {
+		l, _ := workflowTracker(t, "done")
+		l.SetConfig(config[0], config[1])
+		if _, err := l.Workflow(); err == nil {
+			t.Errorf("%s: %s: no error", config[0], config[1])
+		}
+	}
+}
+
+func TestTransitionStamps(t *testing.T) {
+	l, id := workflowTracker(t, "done")
+	issue := l.Issue(id)
+	for _, state := range []string{"review", "done"} {
+		if err := l.SetField(issue, "tester", "status", state, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := l.Stamp("tester")
+	for _, key := range []string{"closed", "updated"} {
+		if got, _ := GetExact(issue, key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestCloseThroughWorkflow(t *testing.T) {
+	l, id := workflowTracker(t, "done")
+	issue := l.Issue(id)
+	if err := l.Close(issue, "tester", "", ""); err == nil {
+		t.Errorf("closed an issue with no closed state to move to")
+	}
+	if !isOpen(issue) || fieldVal(issue, "status") != "" {
+		t.Errorf("failed close changed issue: status %q", fieldVal(issue, "status"))
+	}
+	if err := l.SetField(issue, "tester", "status", "review", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Close(issue, "tester", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := fieldVal(issue, "status"); got != "done" || isOpen(issue) {
+		t.Errorf("after Close: status %q, open %v, want done, closed", got, isOpen(issue))
+	}
+	if err := l.Reopen(issue, "tester"); err != nil {
+		t.Fatal(err)
+	}
+	if got := fieldVal(issue, "status"); got != "review" || !isOpen(issue) {
+		t.Errorf("after Reopen: status %q, open %v, want review, open", got, isOpen(issue))
+	}
+}
+
+func TestCloseAmbiguousState(t *testing.T) {
+	l, id := workflowTracker(t, "done wontfix")
+	l.SetConfig("workflow-review", "new done wontfix")
+	issue := l.Issue(id)
+	if err := l.SetField(issue, "tester", "status", "review", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Close(issue, "tester", "", ""); err == nil {
+		t.Errorf("closed an issue with two closed states to move to")
+	}
+	if err := l.SetField(issue, "tester", "status", "wontfix", false); err != nil {
+		t.Fatal(err)
+	}
+	if isOpen(issue) {
+		t.Errorf("moving to closed state wontfix didn't close the issue")
+	}
+}
+
+func TestCloseWithoutClosedStates(t *testing.T) {
+	l, id := workflowTracker(t, "")
+	issue := l.Issue(id)
+	if err := l.Close(issue, "tester", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if isOpen(issue) || fieldVal(issue, "status") != "" {
+		t.Errorf("Close: open %v, status %q", isOpen(issue), fieldVal(issue, "status"))
+	}
+}
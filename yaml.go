@@ -0,0 +1,508 @@
+package lit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// IssueFormats are the formats in which the issue file may be written,
+// configured as issue-format, by default dgrl.  In yaml, for tools that can't
+// parse dgrl, the file is a sequence of issues, each a single key mapping of
+// its id to a sequence of its fields and comments.  Fields are single key
+// mappings, those of long fields to literal block scalars, and comments are
+// mappings of their stamps to a sequence of their text, as block scalars, and
+// fields.  Empty comments map to [].
+var IssueFormats = map[string]bool{"dgrl": true, "yaml": true}
+
+// yamlStart begins an issue file in yaml, telling it from one in dgrl.
+const yamlStart = "---"
+
+// IssueFormat returns the format in which Store writes the issue file.
+func (l *Lit) IssueFormat() string {
+	if format, _ := l.Config("issue-format"); format != "" {
+		return format
+	}
+	return "dgrl"
+}
+
+// SetIssueFormat sets the format in which Store writes the issue file.  Load
+// reads either format, so the next Store converts the file.
+func (l *Lit) SetIssueFormat(format string) error {
+	if !IssueFormats[format] {
+		return fmt.Errorf("unknown issue format %s", format)
+	}
+	l.SetConfig("issue-format", format)
+	return nil
+}
+
+// DecodeIssues parses the contents of an issue file in either format.
+func DecodeIssues(data []byte) (*dgrl.Branch, error) {
+	if isYAML(data) {
+		return decodeYAML(data)
+	}
+	issues := dgrl.NewParser().Parse(bytes.NewReader(data))
+	if issues == nil {
+		return nil, errors.New("error parsing issue file")
+	}
+	return issues, nil
+}
+
+// encodeIssues returns the issue file for the text of the issues in dgrl, in
+// the configured format.
+func (l *Lit) encodeIssues(data []byte) []byte {
+	if l.IssueFormat() == "yaml" {
+		return encodeYAML(l.issues)
+	}
+	return data
+}
+
+func isYAML(data []byte) bool {
+	line := strings.TrimSpace(string(data))
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = strings.TrimSpace(line[:i])
+	}
+	return line == yamlStart || strings.HasPrefix(line, yamlStart+" ")
+}
+
+func encodeYAML(root *dgrl.Branch) []byte {
+	buf := &bytes.Buffer{}
+	if root.NumKids() == 0 {
+		buf.WriteString(yamlStart + " []\n")
+		return buf.Bytes()
+	}
+	buf.WriteString(yamlStart + "\n")
+	writeYAMLKids(buf, root, "")
+	return buf.Bytes()
+}
+
+// writeYAMLKids writes the nodes of a branch as a sequence at indent.
+func writeYAMLKids(buf *bytes.Buffer, branch *dgrl.Branch, indent string) {
+	for _, k := range branch.Kids() {
+		buf.WriteString(indent + "- ")
+		switch node := k.(type) {
+		case *dgrl.Branch:
+			buf.WriteString(yamlScalar(node.Key()) + ":")
+			if node.NumKids() == 0 {
+				buf.WriteString(" []\n")
+				continue
+			}
+			buf.WriteString("\n")
+			writeYAMLKids(buf, node, indent+"  ")
+		case *dgrl.Leaf:
+			switch node.Type() {
+			case dgrl.TextType:
+				writeYAMLBlock(buf, node.Value(), indent+"  ")
+			case dgrl.LongLeafType:
+				buf.WriteString(yamlScalar(node.Key()) + ": ")
+				writeYAMLBlock(buf, node.Value(), indent+"    ")
+			default:
+				buf.WriteString(yamlScalar(node.Key()) + ": " + yamlScalar(node.Value()) + "\n")
+			}
+		}
+	}
+}
+
+// writeYAMLBlock writes val as a literal block scalar with its lines at
+// indent, two spaces in from the node holding it, or quoted if it has
+// characters a block scalar can't hold.
+func writeYAMLBlock(buf *bytes.Buffer, val, indent string) {
+	if !yamlBlockSafe(val) {
+		buf.WriteString(yamlQuote(val) + "\n")
+		return
+	}
+	if val == "" {
+		buf.WriteString("|-\n")
+		return
+	}
+	lines := strings.Split(strings.TrimSuffix(val, "\n"), "\n")
+	header := "|"
+	for _, line := range lines {
+		if line != "" {
+			// indentation is otherwise taken from the first line
+			if line[0] == ' ' {
+				header += "2"
+			}
+			break
+		}
+	}
+	if strings.HasSuffix(val, "\n") {
+		header += "+"
+	} else {
+		header += "-"
+	}
+	buf.WriteString(header + "\n")
+	for _, line := range lines {
+		if line != "" {
+			buf.WriteString(indent + line)
+		}
+		buf.WriteString("\n")
+	}
+}
+
+// yamlPrintable reports whether r may appear unescaped in yaml.  Line breaks
+// other than newline are excluded, as parsers read them differently.
+func yamlPrintable(r rune) bool {
+	switch {
+	case r == utf8.RuneError, r == 0x7f, r == 0x85, r == 0xfeff, r == 0x2028, r == 0x2029:
+		return false
+	case r < 0x20:
+		return r == '\t' || r == '\n'
+	case r >= 0x80 && r < 0xa0:
+		return false
+	}
+	return true
+}
+
+func yamlBlockSafe(val string) bool {
+	for _, r := range val {
+		if !yamlPrintable(r) {
+			return false
+		}
+	}
+	return true
+}
+
+var yamlWords = map[string]bool{
+	"true": true, "false": true, "yes": true, "no": true, "on": true, "off": true,
+	"y": true, "n": true, "null": true, "~": true,
+}
+
+// yamlScalar returns s as a plain scalar if it would be read back as the same
+// string, and quoted otherwise.  Anything beginning with a digit is quoted, so
+// that no number, date, or time is read as one.
+func yamlScalar(s string) string {
+	if s == "" || strings.TrimSpace(s) != s || yamlWords[strings.ToLower(s)] ||
+		strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`+.0123456789") ||
+		strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return yamlQuote(s)
+	}
+	for _, r := range s {
+		if r == '\t' || r == '\n' || !yamlPrintable(r) {
+			return yamlQuote(s)
+		}
+	}
+	return s
+}
+
+func yamlQuote(s string) string {
+	buf := &strings.Builder{}
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case r == '\n':
+			buf.WriteString(`\n`)
+		case r == '\t':
+			buf.WriteString(`\t`)
+		case r == '\r':
+			buf.WriteString(`\r`)
+		case !yamlPrintable(r):
+			fmt.Fprintf(buf, `\u%04x`, r)
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
+
+// yamlParser reads the subset of yaml that encodeYAML writes, allowing blank
+// lines, comments, other indentation, and single quoted scalars.
+type yamlParser struct {
+	lines []string
+	pos   int
+}
+
+func decodeYAML(data []byte) (*dgrl.Branch, error) {
+	text := strings.Replace(string(data), "\r\n", "\n", -1)
+	p := &yamlParser{lines: strings.Split(strings.TrimSuffix(text, "\n"), "\n")}
+	root := dgrl.NewRoot()
+	p.skip()
+	if p.pos < len(p.lines) {
+		start := strings.TrimSpace(p.lines[p.pos])
+		p.pos++
+		if rest := strings.TrimSpace(strings.TrimPrefix(start, yamlStart)); rest == "[]" {
+			p.skip()
+			if p.pos < len(p.lines) {
+				return nil, p.errorf("content after empty issue list")
+			}
+			return root, nil
+		} else if rest != "" && rest[0] != '#' {
+			return nil, p.errorf("expected issue list")
+		}
+	}
+	if err := p.parseSeq(root, 0); err != nil {
+		return nil, err
+	}
+	if p.skip(); p.pos < len(p.lines) {
+		return nil, p.errorf("unexpected indentation")
+	}
+	return root, nil
+}
+
+func (p *yamlParser) errorf(format string, v ...interface{}) error {
+	return fmt.Errorf("issue file line %d: %s", p.pos+1, fmt.Sprintf(format, v...))
+}
+
+// skip skips blank lines and comments.
+func (p *yamlParser) skip() {
+	for p.pos < len(p.lines) {
+		line := strings.TrimSpace(p.lines[p.pos])
+		if line != "" && line[0] != '#' {
+			return
+		}
+		p.pos++
+	}
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// parseSeq appends the items of a sequence, indented at least min, to branch.
+func (p *yamlParser) parseSeq(branch *dgrl.Branch, min int) error {
+	if p.skip(); p.pos >= len(p.lines) {
+		return nil
+	}
+	indent := indentOf(p.lines[p.pos])
+	if indent < min {
+		return nil
+	}
+	for {
+		if p.skip(); p.pos >= len(p.lines) {
+			return nil
+		}
+		line := p.lines[p.pos]
+		switch n := indentOf(line); {
+		case n < indent:
+			return nil
+		case n > indent:
+			return p.errorf("unexpected indentation")
+		}
+		item := line[indent:]
+		if item != "-" && !strings.HasPrefix(item, "- ") {
+			return p.errorf("expected sequence item")
+		}
+		item = strings.TrimSpace(item[1:])
+		if err := p.parseItem(branch, item, indent); err != nil {
+			return err
+		}
+	}
+}
+
+// parseItem appends the node of a sequence item at indent to branch.
+func (p *yamlParser) parseItem(branch *dgrl.Branch, item string, indent int) error {
+	switch {
+	case strings.HasPrefix(item, "|"):
+		val, err := p.parseBlock(item, indent)
+		if err != nil {
+			return err
+		}
+		branch.Append(dgrl.NewText(val))
+		return nil
+	case strings.HasPrefix(item, `"`), strings.HasPrefix(item, "'"):
+		val, rest, err := p.parseQuoted(item)
+		if err != nil {
+			return err
+		}
+		if rest = strings.TrimSpace(rest); rest == "" || rest[0] == '#' {
+			p.pos++
+			branch.Append(dgrl.NewText(val))
+			return nil
+		}
+	}
+	key, rest, err := p.parseKey(item)
+	if err != nil {
+		return err
+	}
+	switch {
+	case rest == "[]":
+		p.pos++
+		branch.Append(dgrl.NewBranch(key))
+	case rest == "" || rest[0] == '#':
+		p.pos++
+		kids := dgrl.NewBranch(key)
+		if err := p.parseSeq(kids, indent+1); err != nil {
+			return err
+		}
+		if kids.NumKids() == 0 {
+			branch.Append(dgrl.NewLeaf(key, ""))
+		} else {
+			branch.Append(kids)
+		}
+	case rest[0] == '|':
+		val, err := p.parseBlock(rest, indent+2)
+		if err != nil {
+			return err
+		}
+		branch.Append(dgrl.NewLongLeaf(key, val))
+	case rest[0] == '"' || rest[0] == '\'':
+		val, after, err := p.parseQuoted(rest)
+		if err != nil {
+			return err
+		}
+		if after = strings.TrimSpace(after); after != "" && after[0] != '#' {
+			return p.errorf("unexpected text after quoted value")
+		}
+		p.pos++
+		if strings.Contains(val, "\n") {
+			branch.Append(dgrl.NewLongLeaf(key, val))
+		} else {
+			branch.Append(dgrl.NewLeaf(key, val))
+		}
+	default:
+		p.pos++
+		if i := strings.Index(rest, " #"); i >= 0 {
+			rest = strings.TrimSpace(rest[:i])
+		}
+		branch.Append(dgrl.NewLeaf(key, rest))
+	}
+	return nil
+}
+
+// parseKey splits a mapping item into its key and the trimmed rest of the
+// line.
+func (p *yamlParser) parseKey(item string) (string, string, error) {
+	if strings.HasPrefix(item, `"`) || strings.HasPrefix(item, "'") {
+		key, rest, err := p.parseQuoted(item)
+		if err != nil {
+			return "", "", err
+		}
+		if rest = strings.TrimSpace(rest); !strings.HasPrefix(rest, ":") {
+			return "", "", p.errorf("expected mapping")
+		}
+		return key, strings.TrimSpace(rest[1:]), nil
+	}
+	if strings.HasSuffix(item, ":") && !strings.Contains(item, ": ") {
+		return strings.TrimSpace(item[:len(item)-1]), "", nil
+	}
+	i := strings.Index(item, ": ")
+	if i < 0 {
+		return "", "", p.errorf("expected mapping")
+	}
+	return strings.TrimSpace(item[:i]), strings.TrimSpace(item[i+2:]), nil
+}
+
+// parseQuoted returns the value of the quoted scalar beginning s, which must
+// end on the same line, and the rest of s.
+func (p *yamlParser) parseQuoted(s string) (string, string, error) {
+	buf := &strings.Builder{}
+	if s[0] == '\'' {
+		for i := 1; i < len(s); i++ {
+			if s[i] != '\'' {
+				buf.WriteByte(s[i])
+			} else if i+1 < len(s) && s[i+1] == '\'' {
+				buf.WriteByte('\'')
+				i++
+			} else {
+				return buf.String(), s[i+1:], nil
+			}
+		}
+		return "", "", p.errorf("unterminated quoted scalar")
+	}
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			return buf.String(), s[i+1:], nil
+		case '\\':
+			if i+1 >= len(s) {
+				return "", "", p.errorf("unterminated quoted scalar")
+			}
+			i++
+			size := 0
+			switch s[i] {
+			case 'n':
+				buf.WriteByte('\n')
+			case 't':
+				buf.WriteByte('\t')
+			case 'r':
+				buf.WriteByte('\r')
+			case '0':
+				buf.WriteByte(0)
+			case '"', '\\', '/', ' ':
+				buf.WriteByte(s[i])
+			case 'x':
+				size = 2
+			case 'u':
+				size = 4
+			case 'U':
+				size = 8
+			default:
+				return "", "", p.errorf("unknown escape \\%c", s[i])
+			}
+			if size > 0 {
+				if i+size >= len(s) {
+					return "", "", p.errorf("short escape")
+				}
+				r, err := strconv.ParseUint(s[i+1:i+1+size], 16, 32)
+				if err != nil {
+					return "", "", p.errorf("bad escape \\%s", s[i:i+1+size])
+				}
+				buf.WriteRune(rune(r))
+				i += size
+			}
+		default:
+			buf.WriteByte(s[i])
+		}
+	}
+	return "", "", p.errorf("unterminated quoted scalar")
+}
+
+// parseBlock returns the value of the literal block scalar with the given
+// header, in a node at indent.
+func (p *yamlParser) parseBlock(header string, indent int) (string, error) {
+	if i := strings.Index(header, " #"); i >= 0 {
+		header = header[:i]
+	}
+	chomp, content := byte(0), 0
+	for _, c := range []byte(strings.TrimSpace(header[1:])) {
+		switch {
+		case (c == '-' || c == '+') && chomp == 0:
+			chomp = c
+		case c >= '1' && c <= '9' && content == 0:
+			content = indent + int(c-'0')
+		default:
+			return "", p.errorf("bad block scalar header %q", header)
+		}
+	}
+	p.pos++
+	lines := []string{}
+	blank := 0
+	for ; p.pos < len(p.lines); p.pos++ {
+		line := p.lines[p.pos]
+		n := indentOf(line)
+		if n == len(line) && (content == 0 || n <= content) {
+			blank++
+			continue
+		}
+		if content == 0 {
+			content = n
+			if content <= indent {
+				break
+			}
+		}
+		if n < content {
+			break
+		}
+		for ; blank > 0; blank-- {
+			lines = append(lines, "")
+		}
+		lines = append(lines, line[content:])
+	}
+	val := strings.Join(lines, "\n")
+	if len(lines) > 0 && chomp != '-' {
+		val += "\n"
+	}
+	if chomp == '+' {
+		val += strings.Repeat("\n", blank)
+	}
+	return val, nil
+}
@@ -0,0 +1,118 @@
+package lit
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ianremmler/dgrl"
+)
+
+// yamlTree returns issues with values that need care in yaml.
+func yamlTree() *dgrl.Branch {
+	root := dgrl.NewRoot()
+	issue := dgrl.NewBranch("0123abcd")
+	issue.Append(dgrl.NewLeaf("summary", "Fix: the # thing: now"))
+	issue.Append(dgrl.NewLeaf("assigned", ""))
+	issue.Append(dgrl.NewLeaf("tags", "yes"))
+	issue.Append(dgrl.NewLeaf("estimate", "3"))
+	issue.Append(dgrl.NewLeaf("quote", `say "hi" \ 'bye'`))
+	issue.Append(dgrl.NewLeaf("control", "bell\a and \u0085 next line"))
+	issue.Append(dgrl.NewLongLeaf("description", "line one\n\n  indented\ttab\nlast"))
+	issue.Append(dgrl.NewLongLeaf("trailing", "ends with newlines\n\n"))
+	issue.Append(dgrl.NewLongLeaf("leading", "  starts indented\nthen not"))
+	issue.Append(dgrl.NewLongLeaf("empty", ""))
+	issue.Append(dgrl.NewLongLeaf("crlf", "windows\r\nline\r\n"))
+	comment := dgrl.NewBranch("2024-03-01T12:00:00Z tester")
+	comment.Append(dgrl.NewText("a comment\n- with a list\n"))
+	comment.Append(dgrl.NewLeaf("review", "approve"))
+	issue.Append(comment)
+	issue.Append(dgrl.NewBranch("2024-03-02T12:00:00Z tester"))
+	root.Append(issue)
+	other := dgrl.NewBranch("seq: 2")
+	other.Append(dgrl.NewLeaf("summary", "  spaced  "))
+	root.Append(other)
+	return root
+}
+
+func dgrlText(t *testing.T, root *dgrl.Branch) string {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	if err := root.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestYAMLRoundTrip(t *testing.T) {
+	for _, root := range []*dgrl.Branch{yamlTree(), dgrl.NewRoot()} {
+		data := encodeYAML(root)
+		if !isYAML(data) {
+			t.Errorf("encoding isn't recognized as yaml:\n%s", data)
+		}
+		got, err := DecodeIssues(data)
+		if err != nil {
+			t.Fatalf("%v decoding:\n%s", err, data)
+		}
+		if want := dgrlText(t, root); dgrlText(t, got) != want {
+			t.Errorf("decoded:\n%s\nwant:\n%s\nfrom:\n%s", dgrlText(t, got), want, data)
+		}
+	}
+}
+
+func TestYAMLDecode(t *testing.T) {
+	tests := []struct {
+		yaml, dgrl string
+	}{
+		{"---\n# issues\n- abc:\n    - summary: 'it''s' # comment\n\n    - description: |\n        text\n", "== abc\n- summary: it's\n-- description\ntext\n\n--.\n"},
+		{"--- []\n", ""},
+		{"---\n- abc:\n  - x:\n", "== abc\n- x: \n"},
+	}
+	for _, test := range tests {
+		got, err := DecodeIssues([]byte(test.yaml))
+		if err != nil {
+			t.Errorf("%q: %v", test.yaml, err)
+			continue
+		}
+		if text := dgrlText(t, got); text != test.dgrl {
+			t.Errorf("%q: decoded %q, want %q", test.yaml, text, test.dgrl)
+		}
+	}
+	for _, bad := range []string{"---\nabc\n", "---\n- abc:\n  - \"open\n", "---\n- abc:\n   - x: 1\n  - y: 2\n"} {
+		if _, err := DecodeIssues([]byte(bad)); err == nil {
+			t.Errorf("%q: no error", bad)
+		}
+	}
+}
+
+func TestIssueFormatConvert(t *testing.T) {
+	l := memTracker(t, 2)
+	want := dgrlText(t, l.issues)
+	if err := l.SetIssueFormat("toml"); err == nil {
+		t.Errorf("set unknown issue format")
+	}
+	for _, format := range []string{"yaml", "dgrl"} {
+		if err := l.SetIssueFormat(format); err != nil {
+			t.Fatal(err)
+		}
+		if err := l.Store(); err != nil {
+			t.Fatal(err)
+		}
+		data, err := l.fs.ReadFile("/test/.lit/issues")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if isYAML(data) != (format == "yaml") {
+			t.Errorf("%s: stored:\n%s", format, data)
+		}
+		if err := l.Load(); err != nil {
+			t.Fatal(err)
+		}
+		if got := dgrlText(t, l.issues); got != want {
+			t.Errorf("%s: loaded:\n%s\nwant:\n%s", format, got, want)
+		}
+		if strings.Contains(string(data), "issue-format") {
+			t.Errorf("%s: config written to issue file", format)
+		}
+	}
+}